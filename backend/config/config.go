@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	ethClient "github.com/ethereum/go-ethereum/ethclient"
@@ -19,14 +23,163 @@ type ChainConfig struct {
 	TokenContractAddrEnv  string `json:"token_contract_addr_env"`
 	VaultContractAddrEnv  string `json:"vault_contract_addr_env"`
 	RouterContractAddrEnv string `json:"router_contract_addr_env"`
+	// Literal contract addresses, used when the matching env var is unset
+	TokenContractAddr  string `json:"token_contract_addr,omitempty"`
+	VaultContractAddr  string `json:"vault_contract_addr,omitempty"`
+	RouterContractAddr string `json:"router_contract_addr,omitempty"`
+	// CCIPChainSelector identifies the chain to CCIP; messages to this chain use it
+	CCIPChainSelector uint64 `json:"ccip_chain_selector,omitempty"`
+	// LinkTokenAddr is the LINK token the Messenger pays CCIP fees with
+	LinkTokenAddr string `json:"link_token_addr,omitempty"`
+	// TokenDecimals is the bridged token's decimals; 18 when unset
+	TokenDecimals int `json:"token_decimals,omitempty"`
+	// Signer enables the transaction engine for the chain
+	Signer *SignerConfig `json:"signer,omitempty"`
+}
+
+// SignerConfig describes the backend-controlled account that sends
+// transactions on a chain and how its transactions are managed
+type SignerConfig struct {
+	// KeystoreFile is an encrypted JSON keystore; its password is read from PasswordEnvVar
+	KeystoreFile   string `json:"keystore_file"`
+	PasswordEnvVar string `json:"password_env_var"`
+	// ConfirmationBlocks is how deep a receipt must be before a transaction is final
+	ConfirmationBlocks uint64 `json:"confirmation_blocks"`
+	// StuckAfterSeconds is how long an attempt may stay unmined before its fees are bumped
+	StuckAfterSeconds int `json:"stuck_after_seconds"`
+	// FeeBumpPercent raises both fee caps on replacement; nodes require at least 10
+	FeeBumpPercent int `json:"fee_bump_percent"`
+	// MaxFeePerGasGwei caps the fee the engine will ever offer; 0 means no cap
+	MaxFeePerGasGwei float64 `json:"max_fee_per_gas_gwei"`
+	// MaxAttempts limits how many times a transaction is sent, replacements included
+	MaxAttempts int `json:"max_attempts"`
+	// PollIntervalSeconds is how often pending transactions are checked
+	PollIntervalSeconds int `json:"poll_interval_seconds"`
 }
 
 type Config struct {
 	Chains           map[string]*ChainConfig `json:"chains"`
 	GlobalABIFiles   map[string]string       `json:"global_abi_files"`
+	Health           HealthConfig            `json:"health"`
+	Auth             AuthConfig              `json:"auth"`
+	RateLimits       RateLimitConfig         `json:"rate_limits"`
+	// EventAPIURL is the base URL the event monitor posts decoded events to
+	EventAPIURL      string                  `json:"event_api_url"`
+	// Lanes declares which chains bridge to which; empty means every pair of
+	// chains with a CCIP chain selector
+	Lanes            []LaneConfig            `json:"lanes"`
+	AllowlistDrift   AllowlistDriftConfig    `json:"allowlist_drift"`
+	StuckTransfers   StuckTransferConfig     `json:"stuck_transfers"`
+	Alerts           AlertConfig             `json:"alerts"`
+	Reports          ReportConfig            `json:"reports"`
+	Retention        RetentionConfig         `json:"retention"`
+	// Monitors lists the default tenant's monitored contracts; empty watches
+	// the Token contract on chain 80002
+	Monitors         []MonitorConfig         `json:"monitors"`
+	// Tenants are further projects served alongside the default tenant
+	Tenants          map[string]*TenantConfig `json:"tenants"`
+}
+
+// AlertConfig lists where alerts are delivered. Without notifiers alerts are only logged.
+type AlertConfig struct {
+	Notifiers []NotifierConfig `json:"notifiers"`
+}
+
+// NotifierConfig configures one alert destination. Type is webhook, slack,
+// smtp or log; secrets are read from environment variables.
+type NotifierConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// URL receives webhook and slack posts; URLEnvVar overrides it, for Slack URLs that embed a token
+	URL       string `json:"url,omitempty"`
+	URLEnvVar string `json:"url_env_var,omitempty"`
+	// SMTP delivery; authentication is skipped when Username is empty
+	SMTPHost       string   `json:"smtp_host,omitempty"`
+	SMTPPort       int      `json:"smtp_port,omitempty"`
+	Username       string   `json:"username,omitempty"`
+	PasswordEnvVar string   `json:"password_env_var,omitempty"`
+	From           string   `json:"from,omitempty"`
+	To             []string `json:"to,omitempty"`
+}
+
+// LaneConfig declares a one-way bridge lane. The source Messenger must
+// allowlist the destination chain, and the destination Messenger must
+// allowlist the source chain and the source Messenger as a sender.
+type LaneConfig struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// StuckAfterMinutes overrides how long a transfer on this lane may go
+	// without a MessageReceived before it is reported as stuck
+	StuckAfterMinutes int `json:"stuck_after_minutes,omitempty"`
+}
+
+// AllowlistDriftConfig controls the job comparing Messenger allowlists with the declared lanes
+type AllowlistDriftConfig struct {
+	// IntervalSeconds is how often the allowlists are read
+	IntervalSeconds int `json:"interval_seconds"`
+}
+
+// HealthConfig controls the readiness checks reported by /readyz
+type HealthConfig struct {
+	// HeadMaxAgeSeconds is how old a chain's latest block may be before the RPC is considered stale
+	HeadMaxAgeSeconds int `json:"head_max_age_seconds"`
+	// CheckTimeoutSeconds bounds each individual component check
+	CheckTimeoutSeconds int `json:"check_timeout_seconds"`
+	// CriticalComponents lists component names that must be healthy for the server to
+	// report ready; a trailing "*" matches any suffix, e.g. "chain:*"
+	CriticalComponents []string `json:"critical_components"`
+}
+
+// AuthConfig controls API key and request signature checks on protected routes
+type AuthConfig struct {
+	// Disabled turns off authentication on write routes (local development only)
+	Disabled bool `json:"disabled"`
+	// SignatureMaxSkewSeconds is how far a signed request's timestamp may drift from server time
+	SignatureMaxSkewSeconds int `json:"signature_max_skew_seconds"`
+	// AdminKeyEnvVar names the environment variable holding the bootstrap admin key
+	AdminKeyEnvVar string `json:"admin_key_env_var"`
+	// IngestKeyEnvVar names the environment variable holding the key the event monitor uses
+	IngestKeyEnvVar string `json:"ingest_key_env_var"`
+	// SigningKeyEnvVar names the environment variable holding the server-side
+	// key that encrypts the stored signing keys; signed requests need it
+	SigningKeyEnvVar string `json:"signing_key_env_var"`
+}
+
+// RateLimit describes a token bucket: it refills at RequestsPerSecond up to Burst
+// tokens. DailyQuota, when set, caps the requests allowed per UTC day.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+	DailyQuota        int64   `json:"daily_quota,omitempty"`
+}
+
+// RouteRateLimit holds the limits for anonymous clients (by IP) and API key holders
+type RouteRateLimit struct {
+	PerIP  RateLimit `json:"per_ip"`
+	PerKey RateLimit `json:"per_key"`
+}
+
+// RateLimitConfig holds the default limits and per-route overrides. Routes are
+// keyed by method and gin route pattern, e.g. "GET /api/contract/:chainID/:index".
+type RateLimitConfig struct {
+	Disabled bool                      `json:"disabled"`
+	Default  RouteRateLimit            `json:"default"`
+	Routes   map[string]RouteRateLimit `json:"routes"`
 }
 
-var globalConfig *Config
+// configMu guards globalConfig and configPath, which Reload replaces while
+// requests read them
+var (
+	configMu     sync.RWMutex
+	globalConfig *Config
+	// configPath is the file the configuration was loaded from, read again by Reload
+	configPath string
+)
+
+var (
+	abiCacheMu sync.RWMutex
+	abiCache   = make(map[string]abi.ABI)
+)
 
 func Init() error {
 	err := godotenv.Load(".env")
@@ -44,24 +197,255 @@ func Init() error {
 		return fmt.Errorf("CONFIG_FILE_PATH not set in .env file")
 	}
 
-	var loadErr error
-	globalConfig, loadErr = loadConfig(configFilePath)
-	if loadErr != nil {
-		return loadErr
+	return InitFromFile(configFilePath)
+}
+
+// InitFromFile loads the configuration from the given file without reading .env
+func InitFromFile(filePath string) error {
+	loaded, err := loadConfig(filePath)
+	if err != nil {
+		return err
+	}
+	configMu.Lock()
+	globalConfig = loaded
+	configPath = filePath
+	configMu.Unlock()
+	return nil
+}
+
+// Reload reads the configuration file again and drops the cached ABIs.
+// Settings that services copy at startup, such as auth, rate limits and
+// signers, keep their old values until the server restarts.
+func Reload() error {
+	configMu.RLock()
+	filePath := configPath
+	configMu.RUnlock()
+	if filePath == "" {
+		return fmt.Errorf("configuration was not loaded from a file")
 	}
+	loaded, err := loadConfig(filePath)
+	if err != nil {
+		return err
+	}
+	configMu.Lock()
+	globalConfig = loaded
+	configMu.Unlock()
 
+	abiCacheMu.Lock()
+	abiCache = make(map[string]abi.ABI)
+	abiCacheMu.Unlock()
 	return nil
 }
 
+// current returns the loaded configuration. Callers read it once per lookup,
+// so a concurrent Reload never mixes two versions in one result.
+func current() *Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return globalConfig
+}
+
 func GetChainConfig(chainID string) (*ChainConfig, error) {
-	if globalConfig.Chains == nil {
-		return nil, fmt.Errorf("no chain configurations loaded")
+	return ForTenant(DefaultTenant).GetChainConfig(chainID)
+}
+
+// ChainIDs returns the default tenant's chain IDs in sorted order
+func ChainIDs() []string {
+	return ForTenant(DefaultTenant).ChainIDs()
+}
+
+// StuckTransferConfig controls the detector for transfers that never arrive
+type StuckTransferConfig struct {
+	// IntervalSeconds is how often sent messages are checked
+	IntervalSeconds int `json:"interval_seconds"`
+	// DefaultTimeoutMinutes applies to lanes without stuck_after_minutes
+	DefaultTimeoutMinutes int `json:"default_timeout_minutes"`
+	// LookbackHours bounds how old a MessageSent may be and still be checked
+	LookbackHours int `json:"lookback_hours"`
+}
+
+// ReportConfig controls the scheduled volume and fee reports
+type ReportConfig struct {
+	// IntervalMinutes is how often the current and previous periods' reports are regenerated
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
+// RetentionConfig controls which events are archived out of MongoDB and where the archives are kept
+type RetentionConfig struct {
+	// IntervalMinutes is how often the policies are applied
+	IntervalMinutes int               `json:"interval_minutes"`
+	Policies        []RetentionPolicy `json:"policies"`
+	Archive         ArchiveConfig     `json:"archive"`
+}
+
+// RetentionPolicy archives events older than MaxAgeDays. EventName limits the
+// policy to one event type; without it the policy applies to every event.
+type RetentionPolicy struct {
+	EventName  string `json:"event_name,omitempty"`
+	MaxAgeDays int    `json:"max_age_days"`
+}
+
+// ArchiveConfig selects where archives are written. Type is local or s3;
+// s3 works with any S3-compatible store.
+type ArchiveConfig struct {
+	Type string `json:"type"`
+	// Directory holds local archives
+	Directory string `json:"directory,omitempty"`
+	// Endpoint is the S3 host, e.g. "s3.amazonaws.com"; Insecure connects over plain HTTP
+	Endpoint string `json:"endpoint,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Bucket   string `json:"bucket,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	// AccessKeyEnvVar and SecretKeyEnvVar name the environment variables holding the S3 credentials
+	AccessKeyEnvVar string `json:"access_key_env_var,omitempty"`
+	SecretKeyEnvVar string `json:"secret_key_env_var,omitempty"`
+}
+
+// ForTenant returns the archive location of a tenant: other tenants' archives
+// are kept under tenants/<id> in the directory or bucket prefix
+func (a ArchiveConfig) ForTenant(id string) ArchiveConfig {
+	if id == "" || id == DefaultTenant {
+		return a
+	}
+	a.Directory = filepath.Join(a.Directory, "tenants", id)
+	a.Prefix = path.Join(a.Prefix, "tenants", id)
+	return a
+}
+
+// GetLanes returns the default tenant's lanes
+func GetLanes() []LaneConfig {
+	return ForTenant(DefaultTenant).GetLanes()
+}
+
+// GetAllowlistDriftConfig returns the allowlist drift settings with defaults applied
+func GetAllowlistDriftConfig() AllowlistDriftConfig {
+	drift := current().AllowlistDrift
+	if drift.IntervalSeconds <= 0 {
+		drift.IntervalSeconds = 300
 	}
-	chainConfig, exists := globalConfig.Chains[chainID]
-	if !exists {
-		return nil, fmt.Errorf("configuration for chain %s not found", chainID)
+	return drift
+}
+
+// GetStuckTransferConfig returns the stuck transfer settings with defaults applied
+func GetStuckTransferConfig() StuckTransferConfig {
+	stuck := current().StuckTransfers
+	if stuck.IntervalSeconds <= 0 {
+		stuck.IntervalSeconds = 60
+	}
+	if stuck.DefaultTimeoutMinutes <= 0 {
+		stuck.DefaultTimeoutMinutes = 30
+	}
+	if stuck.LookbackHours <= 0 {
+		stuck.LookbackHours = 168
+	}
+	return stuck
+}
+
+// GetReportConfig returns the report settings with defaults applied
+func GetReportConfig() ReportConfig {
+	reports := current().Reports
+	if reports.IntervalMinutes <= 0 {
+		reports.IntervalMinutes = 60
 	}
-	return chainConfig, nil
+	return reports
+}
+
+// GetRetentionConfig returns the retention settings with defaults applied
+func GetRetentionConfig() RetentionConfig {
+	retention := current().Retention
+	if retention.IntervalMinutes <= 0 {
+		retention.IntervalMinutes = 1440
+	}
+	if retention.Archive.Type == "" {
+		retention.Archive.Type = "local"
+	}
+	if retention.Archive.Directory == "" {
+		retention.Archive.Directory = "archive"
+	}
+	if retention.Archive.AccessKeyEnvVar == "" {
+		retention.Archive.AccessKeyEnvVar = "ARCHIVE_ACCESS_KEY_ID"
+	}
+	if retention.Archive.SecretKeyEnvVar == "" {
+		retention.Archive.SecretKeyEnvVar = "ARCHIVE_SECRET_ACCESS_KEY"
+	}
+	return retention
+}
+
+// StuckTimeout returns how long a transfer between two of the default
+// tenant's chains may take before it is considered stuck
+func StuckTimeout(fromChainID, toChainID string) time.Duration {
+	return ForTenant(DefaultTenant).StuckTimeout(fromChainID, toChainID)
+}
+
+// TokenDecimals returns the decimals of the bridged token on a default tenant chain
+func TokenDecimals(chainID string) int {
+	return ForTenant(DefaultTenant).TokenDecimals(chainID)
+}
+
+// ChainIDForSelector returns the default tenant's chain with a CCIP chain selector
+func ChainIDForSelector(selector uint64) (string, bool) {
+	return ForTenant(DefaultTenant).ChainIDForSelector(selector)
+}
+
+// GetAlertConfig returns the alert delivery settings
+func GetAlertConfig() AlertConfig {
+	return current().Alerts
+}
+
+// GetHealthConfig returns the health settings with defaults applied
+func GetHealthConfig() HealthConfig {
+	health := current().Health
+	if health.HeadMaxAgeSeconds <= 0 {
+		health.HeadMaxAgeSeconds = 120
+	}
+	if health.CheckTimeoutSeconds <= 0 {
+		health.CheckTimeoutSeconds = 5
+	}
+	if health.CriticalComponents == nil {
+		health.CriticalComponents = []string{"mongo"}
+	}
+	return health
+}
+
+// GetSignerConfig returns the default tenant chain's signer config with
+// defaults applied, or false if the chain has no signer
+func GetSignerConfig(chainID string) (SignerConfig, bool) {
+	return ForTenant(DefaultTenant).GetSignerConfig(chainID)
+}
+
+// GetAuthConfig returns the authentication settings with defaults applied
+func GetAuthConfig() AuthConfig {
+	return current().authConfig()
+}
+
+func (c *Config) authConfig() AuthConfig {
+	auth := c.Auth
+	if auth.SignatureMaxSkewSeconds <= 0 {
+		auth.SignatureMaxSkewSeconds = 300
+	}
+	if auth.AdminKeyEnvVar == "" {
+		auth.AdminKeyEnvVar = "ADMIN_API_KEY"
+	}
+	if auth.IngestKeyEnvVar == "" {
+		auth.IngestKeyEnvVar = "INGEST_API_KEY"
+	}
+	if auth.SigningKeyEnvVar == "" {
+		auth.SigningKeyEnvVar = "API_KEY_SIGNING_KEY"
+	}
+	return auth
+}
+
+// GetRateLimitConfig returns the rate limit settings with defaults applied
+func GetRateLimitConfig() RateLimitConfig {
+	limits := current().RateLimits
+	if limits.Default.PerIP.RequestsPerSecond <= 0 {
+		limits.Default.PerIP = RateLimit{RequestsPerSecond: 10, Burst: 20}
+	}
+	if limits.Default.PerKey.RequestsPerSecond <= 0 {
+		limits.Default.PerKey = RateLimit{RequestsPerSecond: 50, Burst: 100}
+	}
+	return limits
 }
 
 func GetABI(contractType string) (abi.ABI, error) {
@@ -77,56 +461,43 @@ func GetABI(contractType string) (abi.ABI, error) {
 			return abi.ABI{}, fmt.Errorf("unknown contract type: %s", contractType)
 	}
 
-	return loadABI(abiFileName)
-}
+	abiCacheMu.RLock()
+	cached, exists := abiCache[abiFileName]
+	abiCacheMu.RUnlock()
+	if exists {
+		return cached, nil
+	}
 
-func GetEthereumConnection(chainID string) (*ethClient.Client, error) {
-	config, err := GetChainConfig(chainID)
+	parsed, err := loadABI(abiFileName)
 	if err != nil {
-		return nil, err
+		return abi.ABI{}, err
 	}
-	rpcURL := os.Getenv(config.RPCURLEnvVar)
-	if rpcURL == "" {
-		return nil, fmt.Errorf("RPC URL environment variable '%s' not set", config.RPCURLEnvVar)
-	}
-	return ethClient.Dial(rpcURL)
+
+	abiCacheMu.Lock()
+	abiCache[abiFileName] = parsed
+	abiCacheMu.Unlock()
+	return parsed, nil
+}
+
+func GetEthereumConnection(chainID string) (*ethClient.Client, error) {
+	return ForTenant(DefaultTenant).GetEthereumConnection(chainID)
 }
 
 func GetEthereumWebSocketConnection(chainID string) (*ethClient.Client, error) {
-	config, err := GetChainConfig(chainID)
-	if err != nil {
-		return nil, err
-	}
-	wsURL := os.Getenv(config.WebsocketURLEnv)
-	if wsURL == "" {
-		return nil, fmt.Errorf("Websocket URL environment variable '%s' not set", config.WebsocketURLEnv)
-	}
-	return ethClient.Dial(wsURL)
+	return ForTenant(DefaultTenant).GetEthereumWebSocketConnection(chainID)
 }
 
 func GetContractAddress(chainID string, contractType string) (string, error) {
-	config, err := GetChainConfig(chainID)
-	if err != nil {
-			return "", err
-	}
-	
-	var envVar string
-	switch contractType {
-	case "Token":
-			envVar = config.TokenContractAddrEnv
-	case "Vault":
-			envVar = config.VaultContractAddrEnv
-	case "Router":
-			envVar = config.RouterContractAddrEnv
-	default:
-			return "", fmt.Errorf("unknown contract type: %s", contractType)
-	}
-	
-	addrStr := os.Getenv(envVar)
-	if addrStr == "" {
-			return "", fmt.Errorf("Contract address environment variable '%s' not set", envVar)
+	return ForTenant(DefaultTenant).GetContractAddress(chainID, contractType)
+}
+
+// EventAPIURL returns the base URL of the event ingestion API
+func EventAPIURL() string {
+	url := current().EventAPIURL
+	if url == "" {
+		return "http://localhost:8080"
 	}
-	return addrStr, nil
+	return strings.TrimSuffix(url, "/")
 }
 
 func ServerAddress() string {
@@ -143,6 +514,9 @@ func loadConfig(filePath string) (*Config, error) {
 	if err := json.Unmarshal(configFile, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config JSON: %v", err)
 	}
+	if err := validateTenants(&config); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
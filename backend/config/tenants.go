@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	ethClient "github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultTenant is the tenant served from the top-level chains and lanes,
+// the main database and the unprefixed /api routes
+const DefaultTenant = "default"
+
+// tenantIDPattern restricts tenant IDs to what is safe in URLs, database
+// names and object keys
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// TenantConfig is one project served by the deployment: its own chain and
+// contract registry, lanes, monitors, bootstrap keys and database
+type TenantConfig struct {
+	// ID is the key of the tenant in the tenants map
+	ID   string `json:"-"`
+	Name string `json:"name"`
+	// Database is the MongoDB database holding the tenant's data
+	Database string                  `json:"database"`
+	Chains   map[string]*ChainConfig `json:"chains"`
+	// Lanes declares which chains bridge to which; empty means every pair of
+	// chains with a CCIP chain selector
+	Lanes []LaneConfig `json:"lanes"`
+	// Monitors lists the contracts whose events are watched and ingested
+	Monitors []MonitorConfig `json:"monitors"`
+	// AdminKeyEnvVar and IngestKeyEnvVar name the environment variables holding
+	// the tenant's bootstrap admin key and the key its event monitors ingest with
+	AdminKeyEnvVar  string `json:"admin_key_env_var"`
+	IngestKeyEnvVar string `json:"ingest_key_env_var"`
+}
+
+// MonitorConfig starts an event monitor for a contract on a chain
+type MonitorConfig struct {
+	ChainID      string `json:"chain_id"`
+	ContractType string `json:"contract_type"`
+}
+
+// ForTenant returns the registry of a tenant; an empty ID is the default tenant.
+// An unknown tenant has no chains, so every lookup through it fails.
+func ForTenant(id string) *TenantConfig {
+	cfg := current()
+	if id == "" || id == DefaultTenant {
+		auth := cfg.authConfig()
+		monitors := cfg.Monitors
+		if len(monitors) == 0 {
+			monitors = []MonitorConfig{{ChainID: "80002", ContractType: "Token"}}
+		}
+		return &TenantConfig{
+			ID:              DefaultTenant,
+			Chains:          cfg.Chains,
+			Lanes:           cfg.Lanes,
+			Monitors:        monitors,
+			AdminKeyEnvVar:  auth.AdminKeyEnvVar,
+			IngestKeyEnvVar: auth.IngestKeyEnvVar,
+		}
+	}
+	if tenant, exists := cfg.Tenants[id]; exists {
+		return tenant
+	}
+	return &TenantConfig{ID: id}
+}
+
+// TenantIDs returns the default tenant followed by the configured tenants in sorted order
+func TenantIDs() []string {
+	tenants := current().Tenants
+	ids := make([]string, 0, len(tenants))
+	for id := range tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return append([]string{DefaultTenant}, ids...)
+}
+
+// TenantExists reports whether a tenant is configured; the default tenant always is
+func TenantExists(id string) bool {
+	_, exists := current().Tenants[id]
+	return id == DefaultTenant || exists
+}
+
+// validateTenants checks the tenant IDs and databases and fills in the key env vars
+func validateTenants(config *Config) error {
+	databases := make(map[string]string)
+	for id, tenant := range config.Tenants {
+		if id == DefaultTenant {
+			return fmt.Errorf("tenant ID %q is reserved for the top-level configuration", id)
+		}
+		if !tenantIDPattern.MatchString(id) {
+			return fmt.Errorf("tenant ID %q must be 1-32 lowercase letters, digits or dashes", id)
+		}
+		if tenant == nil {
+			return fmt.Errorf("tenant %s has no configuration", id)
+		}
+		if tenant.Database == "" {
+			return fmt.Errorf("tenant %s needs a database", id)
+		}
+		if other, exists := databases[tenant.Database]; exists {
+			return fmt.Errorf("tenants %s and %s share the database %s", other, id, tenant.Database)
+		}
+		databases[tenant.Database] = id
+
+		tenant.ID = id
+		envPrefix := strings.ToUpper(strings.ReplaceAll(id, "-", "_"))
+		if tenant.AdminKeyEnvVar == "" {
+			tenant.AdminKeyEnvVar = envPrefix + "_ADMIN_API_KEY"
+		}
+		if tenant.IngestKeyEnvVar == "" {
+			tenant.IngestKeyEnvVar = envPrefix + "_INGEST_API_KEY"
+		}
+	}
+	return nil
+}
+
+// APIPath is the path the tenant's API is mounted at
+func (t *TenantConfig) APIPath() string {
+	if t.ID == DefaultTenant {
+		return "/api"
+	}
+	return "/api/tenants/" + t.ID
+}
+
+func (t *TenantConfig) GetChainConfig(chainID string) (*ChainConfig, error) {
+	if t.Chains == nil {
+		return nil, fmt.Errorf("no chain configurations loaded")
+	}
+	chainConfig, exists := t.Chains[chainID]
+	if !exists {
+		return nil, fmt.Errorf("configuration for chain %s not found", chainID)
+	}
+	return chainConfig, nil
+}
+
+// ChainIDs returns the tenant's chain IDs in sorted order
+func (t *TenantConfig) ChainIDs() []string {
+	ids := make([]string, 0, len(t.Chains))
+	for id := range t.Chains {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GetLanes returns the declared lanes, or a full mesh of the chains with a
+// CCIP chain selector when none are declared
+func (t *TenantConfig) GetLanes() []LaneConfig {
+	if len(t.Lanes) > 0 {
+		return t.Lanes
+	}
+	var lanes []LaneConfig
+	for _, from := range t.ChainIDs() {
+		for _, to := range t.ChainIDs() {
+			if from != to && t.Chains[from].CCIPChainSelector != 0 && t.Chains[to].CCIPChainSelector != 0 {
+				lanes = append(lanes, LaneConfig{From: from, To: to})
+			}
+		}
+	}
+	return lanes
+}
+
+// StuckTimeout returns how long a transfer between two chains may take
+// before it is considered stuck
+func (t *TenantConfig) StuckTimeout(fromChainID, toChainID string) time.Duration {
+	minutes := GetStuckTransferConfig().DefaultTimeoutMinutes
+	for _, lane := range t.Lanes {
+		if lane.From == fromChainID && lane.To == toChainID && lane.StuckAfterMinutes > 0 {
+			minutes = lane.StuckAfterMinutes
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// TokenDecimals returns the decimals of the bridged token on a chain
+func (t *TenantConfig) TokenDecimals(chainID string) int {
+	if chainConfig, err := t.GetChainConfig(chainID); err == nil && chainConfig.TokenDecimals > 0 {
+		return chainConfig.TokenDecimals
+	}
+	return 18
+}
+
+// ChainIDForSelector returns the tenant's chain with a CCIP chain selector
+func (t *TenantConfig) ChainIDForSelector(selector uint64) (string, bool) {
+	for id, chainConfig := range t.Chains {
+		if selector != 0 && chainConfig.CCIPChainSelector == selector {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// GetSignerConfig returns the chain's signer config with defaults applied, or
+// false if the chain has no signer
+func (t *TenantConfig) GetSignerConfig(chainID string) (SignerConfig, bool) {
+	chainConfig, err := t.GetChainConfig(chainID)
+	if err != nil || chainConfig.Signer == nil {
+		return SignerConfig{}, false
+	}
+
+	signer := *chainConfig.Signer
+	if signer.ConfirmationBlocks == 0 {
+		signer.ConfirmationBlocks = 2
+	}
+	if signer.StuckAfterSeconds <= 0 {
+		signer.StuckAfterSeconds = 90
+	}
+	if signer.FeeBumpPercent < 10 {
+		signer.FeeBumpPercent = 12
+	}
+	if signer.MaxAttempts <= 0 {
+		signer.MaxAttempts = 5
+	}
+	if signer.PollIntervalSeconds <= 0 {
+		signer.PollIntervalSeconds = 5
+	}
+	return signer, true
+}
+
+func (t *TenantConfig) GetEthereumConnection(chainID string) (*ethClient.Client, error) {
+	chainConfig, err := t.GetChainConfig(chainID)
+	if err != nil {
+		return nil, err
+	}
+	rpcURL := os.Getenv(chainConfig.RPCURLEnvVar)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("RPC URL environment variable '%s' not set", chainConfig.RPCURLEnvVar)
+	}
+	return ethClient.Dial(rpcURL)
+}
+
+func (t *TenantConfig) GetEthereumWebSocketConnection(chainID string) (*ethClient.Client, error) {
+	chainConfig, err := t.GetChainConfig(chainID)
+	if err != nil {
+		return nil, err
+	}
+	wsURL := os.Getenv(chainConfig.WebsocketURLEnv)
+	if wsURL == "" {
+		return nil, fmt.Errorf("Websocket URL environment variable '%s' not set", chainConfig.WebsocketURLEnv)
+	}
+	return ethClient.Dial(wsURL)
+}
+
+func (t *TenantConfig) GetContractAddress(chainID string, contractType string) (string, error) {
+	chainConfig, err := t.GetChainConfig(chainID)
+	if err != nil {
+		return "", err
+	}
+
+	var envVar, literal string
+	switch contractType {
+	case "Token":
+		envVar, literal = chainConfig.TokenContractAddrEnv, chainConfig.TokenContractAddr
+	case "Vault":
+		envVar, literal = chainConfig.VaultContractAddrEnv, chainConfig.VaultContractAddr
+	case "Router":
+		envVar, literal = chainConfig.RouterContractAddrEnv, chainConfig.RouterContractAddr
+	default:
+		return "", fmt.Errorf("unknown contract type: %s", contractType)
+	}
+
+	addrStr := ""
+	if envVar != "" {
+		addrStr = os.Getenv(envVar)
+	}
+	if addrStr == "" {
+		addrStr = literal
+	}
+	if addrStr == "" {
+		return "", fmt.Errorf("Contract address environment variable '%s' not set", envVar)
+	}
+	return addrStr, nil
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"backend/database"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertController manages alert rules and lists fired alerts
+type AlertController struct {
+	Engine *services.AlertEngine
+	Audit  *services.AuditLog
+}
+
+// CreateRule stores a new alert rule
+func (a *AlertController) CreateRule(c *gin.Context) {
+	var rule models.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	created, err := a.Engine.CreateRule(c.Request.Context(), rule)
+	if respondRuleError(c, err) {
+		return
+	}
+	recordAudit(c, a.Audit, services.AuditRecord{Action: models.AuditAlertRuleCreate, Target: created.ID, Payload: created})
+	c.JSON(http.StatusCreated, gin.H{"data": created})
+}
+
+// ListRules returns every alert rule
+func (a *AlertController) ListRules(c *gin.Context) {
+	rules, err := a.Engine.Rules.ListRules(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing alert rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list alert rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// GetRule returns one alert rule
+func (a *AlertController) GetRule(c *gin.Context) {
+	rule, err := a.Engine.Rules.GetRule(c.Request.Context(), c.Param("id"))
+	if respondRuleError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+// UpdateRule replaces an alert rule
+func (a *AlertController) UpdateRule(c *gin.Context) {
+	var rule models.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	updated, err := a.Engine.UpdateRule(c.Request.Context(), c.Param("id"), rule)
+	if respondRuleError(c, err) {
+		return
+	}
+	recordAudit(c, a.Audit, services.AuditRecord{Action: models.AuditAlertRuleUpdate, Target: updated.ID, Payload: updated})
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
+
+// DeleteRule removes an alert rule
+func (a *AlertController) DeleteRule(c *gin.Context) {
+	id := c.Param("id")
+	if respondRuleError(c, a.Engine.DeleteRule(c.Request.Context(), id)) {
+		return
+	}
+	recordAudit(c, a.Audit, services.AuditRecord{Action: models.AuditAlertRuleDelete, Target: id})
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted"})
+}
+
+// ListAlerts returns the most recently fired alerts, newest first, with where they were delivered
+func (a *AlertController) ListAlerts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": a.Engine.Dispatcher.Recent()})
+}
+
+// respondRuleError writes the response for a failed rule operation and
+// reports whether there was an error
+func respondRuleError(c *gin.Context, err error) bool {
+	var validation *services.RuleValidationError
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &validation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": validation.Fields})
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+	default:
+		log.Printf("Error managing alert rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to manage alert rules", "details": err.Error()})
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllowlistDriftController exposes the findings of the allowlist drift job
+type AllowlistDriftController struct {
+	Drift *services.AllowlistDriftDetector
+}
+
+// GetDrift returns the latest report, running a check if none has run yet
+func (a *AllowlistDriftController) GetDrift(c *gin.Context) {
+	report := a.Drift.Report()
+	if report == nil {
+		report = a.Drift.Check(c.Request.Context())
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// CheckDrift reads the allowlists now instead of waiting for the next run
+func (a *AllowlistDriftController) CheckDrift(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": a.Drift.Check(c.Request.Context())})
+}
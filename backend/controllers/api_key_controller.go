@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController exposes the admin API for managing API keys
+type APIKeyController struct {
+	Auth  *services.AuthService
+	Audit *services.AuditLog
+}
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+// CreateAPIKey issues a new key. The plaintext key is only included in this response.
+func (a *APIKeyController) CreateAPIKey(c *gin.Context) {
+	var request createAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	key, plaintext, err := a.Auth.CreateKey(c.Request.Context(), request.Name, request.Scopes)
+	record := services.AuditRecord{Action: models.AuditAPIKeyCreate, Payload: request, Err: err}
+	if key != nil {
+		record.Target = key.ID
+	}
+	recordAudit(c, a.Audit, record)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create API key", "details": err.Error()})
+		return
+	}
+
+	log.Printf("Created API key %s (%s) with scopes %v", key.ID, key.Name, key.Scopes)
+	c.JSON(http.StatusCreated, gin.H{
+		"data": key,
+		"key":  plaintext,
+	})
+}
+
+// ListAPIKeys returns every key without its hash
+func (a *APIKeyController) ListAPIKeys(c *gin.Context) {
+	keys, err := a.Auth.Keys.ListKeys(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing API keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// RevokeAPIKey disables a key immediately
+func (a *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	err := a.Auth.Keys.RevokeKey(c.Request.Context(), id, time.Now().UTC())
+	if !errors.Is(err, database.ErrNotFound) {
+		recordAudit(c, a.Audit, services.AuditRecord{Action: models.AuditAPIKeyRevoke, Target: id, Err: err})
+	}
+	if errors.Is(err, database.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error revoking API key %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	log.Printf("Revoked API key %s", id)
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArchiveController lists the event archives, runs the retention policies on
+// demand and restores archived events
+type ArchiveController struct {
+	Archiver *services.EventArchiver
+	Audit    *services.AuditLog
+}
+
+// ListArchives returns the archive manifest, optionally limited to the
+// archives holding an event type or events created in [from, to)
+func (a *ArchiveController) ListArchives(c *gin.Context) {
+	filter := database.ArchiveFilter{EventName: c.Query("event_name")}
+	var err error
+	if value := c.Query("from"); value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": "from must be an RFC 3339 timestamp"})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": "to must be an RFC 3339 timestamp"})
+			return
+		}
+	}
+
+	archives, err := a.Archiver.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Error listing archives: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list archives"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": archives, "count": len(archives)})
+}
+
+// RunArchive applies the retention policies now instead of waiting for the next run
+func (a *ArchiveController) RunArchive(c *gin.Context) {
+	archives, err := a.Archiver.Archive(c.Request.Context())
+	recordAudit(c, a.Audit, services.AuditRecord{Action: models.AuditEventArchive, Target: "events", Payload: gin.H{"archives": len(archives)}, Err: err})
+	if err != nil {
+		log.Printf("Error archiving events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive events", "details": err.Error(), "data": archives})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": archives, "count": len(archives)})
+}
+
+type restoreEventsRequest struct {
+	EventName string    `json:"event_name"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// RestoreEvents stores again the archived events created in [from, to)
+func (a *ArchiveController) RestoreEvents(c *gin.Context) {
+	var body restoreEventsRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	filter := database.ArchiveFilter{EventName: body.EventName, From: body.From, To: body.To}
+
+	result, err := a.Archiver.Restore(c.Request.Context(), filter)
+	if errors.Is(err, services.ErrInvalidRestore) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restore", "details": err.Error()})
+		return
+	}
+	recordAudit(c, a.Audit, services.AuditRecord{Action: models.AuditEventRestore, Target: "events", Payload: body, Err: err})
+	if err != nil {
+		log.Printf("Error restoring events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore events", "details": err.Error(), "data": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
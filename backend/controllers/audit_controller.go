@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/database"
+	"backend/middleware"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController serves the audit log for compliance reviews
+type AuditController struct {
+	Audit *services.AuditLog
+}
+
+// ListAudit returns entries in sequence order. Pass the last sequence seen as
+// after to fetch the next page.
+func (a *AuditController) ListAudit(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+
+	entries := []models.AuditEntry{}
+	err = a.Audit.Store.EachAudit(c.Request.Context(), filter, func(entry models.AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error reading the audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read the audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// ExportAudit streams every matching entry as newline-delimited JSON. Each
+// line carries its hash and previous hash, so an export of the full log can
+// be verified offline.
+func (a *AuditController) ExportAudit(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit_log.ndjson"`)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	err = a.Audit.Store.EachAudit(c.Request.Context(), filter, func(entry models.AuditEntry) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		// The status is already sent; the truncated body is all we can signal
+		log.Printf("Error exporting the audit log: %v", err)
+	}
+}
+
+// VerifyAudit recomputes the hash chain and reports the first broken entry
+func (a *AuditController) VerifyAudit(c *gin.Context) {
+	result, err := a.Audit.Verify(c.Request.Context())
+	if err != nil {
+		log.Printf("Error verifying the audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify the audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// parseAuditFilter reads the action, actor, from, to, after and limit query parameters
+func parseAuditFilter(c *gin.Context) (database.AuditFilter, error) {
+	filter := database.AuditFilter{
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+	}
+
+	var err error
+	if value := c.Query("from"); value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("from must be an RFC 3339 timestamp")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	if value := c.Query("after"); value != "" {
+		if filter.AfterSequence, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return filter, errors.New("after must be a sequence number")
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 || filter.Limit > 1000 {
+			return filter, errors.New("limit must be between 1 and 1000")
+		}
+	}
+	return filter, nil
+}
+
+// recordAudit adds a privileged action to the audit log, attributed to the
+// request's API key
+func recordAudit(c *gin.Context, audit *services.AuditLog, record services.AuditRecord) {
+	record.Actor = "anonymous"
+	if key := middleware.CurrentAPIKey(c); key != nil {
+		record.Actor = key.ID
+	}
+	record.ClientIP = c.ClientIP()
+	audit.Record(c.Request.Context(), record)
+}
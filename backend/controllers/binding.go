@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"backend/models"
+)
+
+// decodeStrictJSON decodes a request body, rejecting unknown fields and values of
+// the wrong type. Field-level problems are returned as FieldErrors; err is set
+// only when the body is not valid JSON at all.
+func decodeStrictJSON(body io.Reader, target interface{}) ([]models.FieldError, error) {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(target)
+	if err == nil {
+		if decoder.More() {
+			return nil, fmt.Errorf("request body must contain a single JSON object")
+		}
+		return nil, nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []models.FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be a %s, got %s", typeErr.Type, typeErr.Value),
+		}}, nil
+	}
+
+	// encoding/json reports unknown fields as `json: unknown field "name"`
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		return []models.FieldError{{Field: strings.Trim(field, `"`), Message: "unknown field"}}, nil
+	}
+
+	return nil, err
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"backend/models"
+)
+
+func TestDecodeStrictJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+		wantMsg   string
+		wantErr   bool
+	}{
+		{"valid", `{"ChainId": "80002", "amount": "1"}`, "", "", false},
+		{"unknown field", `{"ChainId": "80002", "amout": "1"}`, "amout", "unknown field", false},
+		{"wrong type", `{"ChainId": 80002}`, "ChainId", "must be a string, got number", false},
+		{"not JSON", `ChainId=80002`, "", "", true},
+		{"truncated", `{"ChainId": "80002"`, "", "", true},
+		{"two objects", `{"ChainId": "80002"} {"ChainId": "1"}`, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event models.EventData
+			fieldErrors, err := decodeStrictJSON(strings.NewReader(tt.body), &event)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("err = nil, field errors %+v, want an error", fieldErrors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeStrictJSON: %v", err)
+			}
+			if tt.wantField == "" {
+				if len(fieldErrors) != 0 || event.ChainID != "80002" || event.Amount != "1" {
+					t.Fatalf("decoded %+v with field errors %+v", event, fieldErrors)
+				}
+				return
+			}
+			if len(fieldErrors) != 1 || fieldErrors[0].Field != tt.wantField || fieldErrors[0].Message != tt.wantMsg {
+				t.Fatalf("field errors %+v, want %s: %s", fieldErrors, tt.wantField, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContractAdminController submits owner operations on the bridge contracts
+type ContractAdminController struct {
+	Admin *services.ContractAdmin
+	Audit *services.AuditLog
+}
+
+// contractOperationRequest holds the function arguments, named as in the ABI.
+// Values may be JSON strings, numbers, booleans or arrays.
+type contractOperationRequest struct {
+	Args   map[string]json.RawMessage `json:"args"`
+	DryRun bool                       `json:"dry_run"`
+}
+
+// ExecuteOperation dry-runs an operation with eth_call and, unless dry_run is
+// set, submits it through the chain's transaction engine. The response holds
+// the tracked transaction, which GET /api/admin/transactions/:id follows.
+func (a *ContractAdminController) ExecuteOperation(c *gin.Context) {
+	chainID, contractType, operation := c.Param("chainID"), c.Param("index"), c.Param("operation")
+
+	var request contractOperationRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	args := make(map[string]string, len(request.Args))
+	for name, raw := range request.Args {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			text = string(raw)
+		}
+		args[name] = text
+	}
+
+	result, err := a.Admin.Execute(c.Request.Context(), chainID, contractType, operation, args, request.DryRun)
+	if !request.DryRun && !errors.Is(err, services.ErrInvalidCall) {
+		record := services.AuditRecord{
+			Action:  models.AuditContractOperation,
+			Target:  chainID + "/" + contractType + "." + operation,
+			Payload: request,
+			Err:     err,
+		}
+		if result != nil && result.Transaction != nil {
+			if attempt := result.Transaction.LastAttempt(); attempt != nil {
+				record.TransactionHash = attempt.Hash
+			}
+		}
+		recordAudit(c, a.Audit, record)
+	}
+
+	switch {
+	case errors.Is(err, services.ErrInvalidCall):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation", "details": err.Error()})
+	case errors.Is(err, services.ErrNoSigner):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No signer configured for this chain", "details": err.Error()})
+	case errors.Is(err, services.ErrDryRunReverted):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Operation would revert", "details": err.Error(), "data": result})
+	case err != nil:
+		log.Printf("Error submitting %s.%s on chain %s: %v", contractType, operation, chainID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to submit operation", "details": err.Error(), "data": result})
+	case result.DryRun:
+		c.JSON(http.StatusOK, gin.H{"data": result})
+	default:
+		log.Printf("Submitted %s.%s on chain %s as transaction %s", contractType, operation, chainID, result.Transaction.ID)
+		c.JSON(http.StatusAccepted, gin.H{"data": result})
+	}
+}
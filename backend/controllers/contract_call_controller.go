@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContractCallController runs read-only contract calls for clients that
+// shouldn't need their own RPC endpoint
+type ContractCallController struct {
+	Caller *services.ContractCaller
+}
+
+// CallContract runs a view or pure function. Query parameters other than
+// block are the function's arguments, named as in the ABI (arg0, arg1, ...
+// for unnamed inputs); arrays are passed as JSON arrays.
+func (cc *ContractCallController) CallContract(c *gin.Context) {
+	chainID, contractType, method := c.Param("chainID"), c.Param("index"), c.Param("method")
+
+	args := make(map[string]string)
+	for name, values := range c.Request.URL.Query() {
+		if name != "block" && len(values) > 0 {
+			args[name] = values[len(values)-1]
+		}
+	}
+
+	result, err := cc.Caller.Call(c.Request.Context(), chainID, contractType, method, args, c.Query("block"))
+	if errors.Is(err, services.ErrInvalidCall) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contract call", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error calling %s.%s on chain %s: %v", contractType, method, chainID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Contract call failed", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
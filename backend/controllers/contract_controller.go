@@ -6,19 +6,30 @@ import (
     "net/http"
     "github.com/gin-gonic/gin"
     "backend/config"
+    "backend/services"
 )
 
+// ContractController serves contract details along with their live on-chain state
+type ContractController struct {
+    State *services.ContractStateService
+    // Tenant selects the contract registry; empty is the default tenant
+    Tenant string
+}
+
 type ContractData struct {
     Name             string      `json:"name"`
     Description      string      `json:"description"`
     Details          string      `json:"details"`
     ContractAddress  string      `json:"contractAddress"`
     ABI              interface{} `json:"abi"`
+    // Live is read from the chain; LiveError explains why it is missing
+    Live             *services.ContractState `json:"live,omitempty"`
+    LiveError        string      `json:"liveError,omitempty"`
 }
 
 // GetContractData handles GET requests for contract data
 
-func GetContractData(c *gin.Context) {
+func (cc *ContractController) GetContractData(c *gin.Context) {
     index := c.Param("index")
     chainID := c.Param("chainID")
 
@@ -32,7 +43,7 @@ func GetContractData(c *gin.Context) {
         return
     }
 
-    contractAddress, err := config.GetContractAddress(chainID, index)
+    contractAddress, err := config.ForTenant(cc.Tenant).GetContractAddress(chainID, index)
     if err != nil {
         log.Printf("Failed to fetch contract address: %v", err)
         c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch contract address: %v", err)})
@@ -68,6 +79,15 @@ func GetContractData(c *gin.Context) {
     // Set the fetched ABI and contract address
     contractData.ContractAddress = contractAddress
     contractData.ABI = abi
+
+    // Live state is best effort so the static details are served even when the chain is unreachable
+    live, err := cc.State.State(c.Request.Context(), chainID, index)
+    if err != nil {
+        log.Printf("Failed to read live state of %s on chain %s: %v", index, chainID, err)
+        contractData.LiveError = err.Error()
+    } else {
+        contractData.Live = live
+    }
     log.Printf("Sending contract data response for %s", index)
     c.JSON(http.StatusOK, contractData)
 }
\ No newline at end of file
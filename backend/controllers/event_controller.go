@@ -1,73 +1,95 @@
 package controllers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math/big"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"backend/config"
 	"backend/database"
 	"backend/models"
+	"backend/services"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var (
+// EventController ingests and serves contract events from an EventStore
+type EventController struct {
+	// Tenant selects the registry ingested events are validated against
+	Tenant string
+	Store  database.EventStore
+	// Decimals formats amounts in whole tokens for responses
+	Decimals *services.TokenDecimals
+
+	mu                  sync.Mutex
 	totalRequests       int64
 	totalProcessingTime time.Duration
-)
+}
 
-func HandleMintEvent(c *gin.Context) {
-	handleEvent(c, "Mint")
+// NewEventController creates an event controller for a tenant backed by the given store
+func NewEventController(tenant string, store database.EventStore, decimals *services.TokenDecimals) *EventController {
+	return &EventController{Tenant: tenant, Store: store, Decimals: decimals}
 }
 
-func HandleBurnEvent(c *gin.Context) {
-	handleEvent(c, "Burn")
+func (e *EventController) HandleMintEvent(c *gin.Context) {
+	e.handleEvent(c, "Mint")
 }
 
-func HandleTokensReleasedEvent(c *gin.Context) {
-	handleEvent(c, "TokensReleased")
+func (e *EventController) HandleBurnEvent(c *gin.Context) {
+	e.handleEvent(c, "Burn")
 }
 
-func HandleTokensLockedEvent(c *gin.Context) {
-	handleEvent(c, "TokensLocked")
+func (e *EventController) HandleTokensReleasedEvent(c *gin.Context) {
+	e.handleEvent(c, "TokensReleased")
 }
 
-func HandleMessageSentEvent(c *gin.Context) {
-	handleEvent(c, "MessageSent")
+func (e *EventController) HandleTokensLockedEvent(c *gin.Context) {
+	e.handleEvent(c, "TokensLocked")
 }
 
-func HandleMessageReceivedEvent(c *gin.Context) {
-	handleEvent(c, "MessageReceived")
+func (e *EventController) HandleMessageSentEvent(c *gin.Context) {
+	e.handleEvent(c, "MessageSent")
 }
 
-func handleEvent(c *gin.Context, eventName string) {
+func (e *EventController) HandleMessageReceivedEvent(c *gin.Context) {
+	e.handleEvent(c, "MessageReceived")
+}
+
+func (e *EventController) handleEvent(c *gin.Context, eventName string) {
 	start := time.Now()
 
 	var eventData models.EventData
-	if err := c.ShouldBindJSON(&eventData); err != nil {
+	if fieldErrors, err := decodeStrictJSON(c.Request.Body, &eventData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
 		return
+	} else if len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": fieldErrors})
+		return
 	}
 
 	eventData.EventName = eventName
-	log.Printf("Received %s event data: %+v", eventName, eventData)
-
-	db := database.GetDatabase()
-	collection := db.Collection("events")
+	if fieldErrors := services.ValidateEventData(config.ForTenant(e.Tenant), eventData); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": fieldErrors})
+		return
+	}
 
-	ensureIndexes(collection)
+	log.Printf("Received %s event data: %+v", eventName, eventData)
 
 	now := time.Now()
-	eventData.CreatedAt = now.Format(time.RFC3339Nano)
-eventData.UpdatedAt = now.Format(time.RFC3339Nano)
+	eventData.CreatedAt = models.FormatTime(now)
+	eventData.UpdatedAt = models.FormatTime(now)
 
-	_, err := collection.InsertOne(context.Background(), eventData)
-	if err != nil {
+	if err := e.Store.InsertEvent(c.Request.Context(), &eventData); errors.Is(err, database.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Event already stored", "details": "an event with key " + eventData.Key() + " exists"})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store event data", "details": err.Error()})
 		return
 	}
@@ -75,41 +97,26 @@ eventData.UpdatedAt = now.Format(time.RFC3339Nano)
 	storedData, _ := json.Marshal(eventData)
 	log.Printf("Stored %s event data: %s", eventName, storedData)
 
+	e.Decimals.Format(c.Request.Context(), &eventData)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": eventName + " event data received and stored successfully",
 		"data":    eventData,
 	})
 
 	duration := time.Since(start)
-	totalRequests++
-	totalProcessingTime += duration
-
-	log.Printf("Request processed in %v", duration)
-	log.Printf("Average processing time: %v", totalProcessingTime/time.Duration(totalRequests))
-}
-
-func ensureIndexes(collection *mongo.Collection) {
-	ctx := context.Background()
-	indexes := []mongo.IndexModel{
-		{Keys: bson.D{{"event_name", 1}, {"timestamp", -1}}},
-		{Keys: bson.D{{"caller_address", 1}, {"event_name", 1}, {"timestamp", -1}}},
-		{Keys: bson.D{{"chain_id", 1}, {"event_name", 1}, {"timestamp", -1}}},
-		{Keys: bson.D{{"contract_address", 1}, {"event_name", 1}, {"timestamp", -1}}},
-		{Keys: bson.D{{"message_id", 1}}, Options: options.Index().SetSparse(true)},
-		{Keys: bson.D{{"to_from_user", 1}, {"event_name", 1}, {"timestamp", -1}}, Options: options.Index().SetSparse(true)},
-	}
 
-	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	e.mu.Lock()
+	e.totalRequests++
+	e.totalProcessingTime += duration
+	average := e.totalProcessingTime / time.Duration(e.totalRequests)
+	e.mu.Unlock()
 
-	_, err := collection.Indexes().CreateMany(ctx, indexes, opts)
-	if err != nil {
-		log.Printf("Error creating indexes: %v", err)
-	} else {
-		log.Println("Indexes created successfully")
-	}
+	log.Printf("Request processed in %v", duration)
+	log.Printf("Average processing time: %v", average)
 }
 
-func GetLastEventData(c *gin.Context) {
+func (e *EventController) GetLastEventData(c *gin.Context) {
 	callerAddress := c.Param("callerAddress")
 	eventName := c.Query("eventName")
 
@@ -120,24 +127,9 @@ func GetLastEventData(c *gin.Context) {
 
 	log.Printf("Retrieving last event data for caller address: %s, event name: %s", callerAddress, eventName)
 
-	db := database.GetDatabase()
-	collection := db.Collection("events")
-
-	var lastEventData models.EventData
-
-	filter := bson.M{"caller_address": callerAddress}
-	if eventName != "" {
-		filter["event_name"] = eventName
-	}
-
-	err := collection.FindOne(
-		context.Background(),
-		filter,
-		options.FindOne().SetSort(bson.M{"timestamp": -1}),
-	).Decode(&lastEventData)
-
+	lastEventData, err := e.Store.LatestEventByAddress(c.Request.Context(), callerAddress, eventName)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, database.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "No events found for this caller address and event type"})
 			return
 		}
@@ -149,14 +141,196 @@ func GetLastEventData(c *gin.Context) {
 	lastEventDataJSON, _ := json.Marshal(lastEventData)
 	log.Printf("Retrieved last event data: %s", string(lastEventDataJSON))
 
+	e.Decimals.Format(c.Request.Context(), lastEventData)
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": lastEventData,
 	})
 }
 
-func GetPerformanceMetrics(c *gin.Context) {
+// ListEvents returns events matching the query filters, newest first
+func (e *EventController) ListEvents(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	events, err := e.Store.FindEvents(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Error querying events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query events"})
+		return
+	}
+	for i := range events {
+		e.Decimals.Format(c.Request.Context(), &events[i])
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"totalRequests":         totalRequests,
-		"averageProcessingTime": totalProcessingTime / time.Duration(totalRequests),
+		"data":   events,
+		"count":  len(events),
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
 	})
 }
+
+// GetEventStats returns event counts and amount totals per event name and chain
+func (e *EventController) GetEventStats(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	aggregates, err := e.Store.AggregateEvents(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Error aggregating events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": aggregates})
+}
+
+func (e *EventController) GetPerformanceMetrics(c *gin.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var average time.Duration
+	if e.totalRequests > 0 {
+		average = e.totalProcessingTime / time.Duration(e.totalRequests)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"totalRequests":         e.totalRequests,
+		"averageProcessingTime": average,
+	})
+}
+
+// Trailers sent after an export's last row. A download without
+// X-Export-Complete: true was cut short.
+const (
+	TrailerExportRows     = "X-Export-Rows"
+	TrailerExportComplete = "X-Export-Complete"
+)
+
+// ExportEvents streams the events matching the query filters, oldest first, as
+// CSV, NDJSON or Parquet. An export holds at most MaxExportEvents rows; larger
+// matches are read in pages with offset.
+func (e *EventController) ExportEvents(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", services.ExportCSV))
+	contentType, supported := services.ExportContentTypes[format]
+	if !supported {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": "format must be csv, ndjson or parquet"})
+		return
+	}
+	filter, err := parseEventQuery(c, database.MaxExportEvents, database.MaxExportEvents)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="events-%s.%s"`, time.Now().UTC().Format("20060102T150405Z"), format))
+	c.Header("Trailer", TrailerExportRows+", "+TrailerExportComplete)
+	c.Status(http.StatusOK)
+
+	// Errors after the first byte can no longer change the status, so the
+	// trailers tell the client whether the download is complete
+	exported, complete := 0, false
+	defer func() {
+		c.Writer.Header().Set(TrailerExportRows, strconv.Itoa(exported))
+		c.Writer.Header().Set(TrailerExportComplete, strconv.FormatBool(complete))
+	}()
+
+	exporter, err := services.NewEventExporter(format, c.Writer)
+	if err != nil {
+		log.Printf("Error starting %s event export: %v", format, err)
+		return
+	}
+
+	err = e.Store.EachEvent(c.Request.Context(), filter, func(event models.EventData) error {
+		e.Decimals.Format(c.Request.Context(), &event)
+		if err := exporter.Write(event); err != nil {
+			return err
+		}
+		exported++
+		if exported%1000 == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error exporting events after %d rows: %v", exported, err)
+		return
+	}
+	if err := exporter.Close(); err != nil {
+		log.Printf("Error finishing %s event export: %v", format, err)
+		return
+	}
+	complete = true
+	log.Printf("Exported %d events as %s", exported, format)
+}
+
+// parseEventFilter reads the event query parameters shared by the read endpoints
+func parseEventFilter(c *gin.Context) (database.EventFilter, error) {
+	return parseEventQuery(c, database.DefaultEventLimit, database.MaxEventLimit)
+}
+
+// parseEventQuery reads the event query parameters with the given default and
+// maximum limit
+func parseEventQuery(c *gin.Context, defaultLimit, maxLimit int) (database.EventFilter, error) {
+	filter := database.EventFilter{
+		EventName:       c.Query("event_name"),
+		ChainID:         c.Query("chain_id"),
+		CallerAddress:   c.Query("caller_address"),
+		ContractAddress: c.Query("contract_address"),
+		ToFromUser:      c.Query("to_from_user"),
+		MessageID:       c.Query("message_id"),
+		TransactionHash: c.Query("transaction_hash"),
+		RunID:           c.Query("run_id"),
+		Limit:           defaultLimit,
+	}
+
+	var err error
+	if value := c.Query("from"); value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("from must be an RFC 3339 timestamp")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	if filter.MinAmount, err = parseAmountBound(c, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseAmountBound(c, "max_amount"); err != nil {
+		return filter, err
+	}
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 || filter.Limit > maxLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if filter.Offset, err = strconv.Atoi(value); err != nil || filter.Offset < 0 {
+			return filter, errors.New("offset must be a non-negative integer")
+		}
+	}
+	return filter, nil
+}
+
+// parseAmountBound reads an optional base-unit amount bound. It must fit in
+// Decimal128 so the bound can be compared with stored amounts.
+func parseAmountBound(c *gin.Context, name string) (*big.Int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	bound, ok := new(big.Int).SetString(value, 10)
+	if !ok || bound.Sign() < 0 || models.DecimalAmount(value) == nil {
+		return nil, fmt.Errorf("%s must be a non-negative integer amount in base units", name)
+	}
+	return bound, nil
+}
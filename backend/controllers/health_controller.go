@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var startedAt = time.Now()
+
+// HealthController serves the liveness and readiness probes
+type HealthController struct {
+	// Health holds one health service per tenant
+	Health []*services.HealthService
+}
+
+// Healthz reports that the process is alive and able to serve requests
+func (h *HealthController) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "ok",
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// Readyz reports whether every critical dependency is healthy
+func (h *HealthController) Readyz(c *gin.Context) {
+	report := services.CombinedReadiness(c.Request.Context(), h.Health)
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
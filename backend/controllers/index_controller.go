@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"backend/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IndexController reports how the database's indexes differ from the declared ones
+type IndexController struct {
+	// Indexes is nil when the server runs without MongoDB
+	Indexes *database.IndexManager
+}
+
+// GetIndexes lists every declared and existing index with its drift state
+// and $indexStats usage
+func (i *IndexController) GetIndexes(c *gin.Context) {
+	if i.Indexes == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Index reports need MongoDB"})
+		return
+	}
+
+	statuses, err := i.Indexes.Report(c.Request.Context())
+	if err != nil {
+		log.Printf("Error reading indexes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read indexes", "details": err.Error()})
+		return
+	}
+
+	drift := 0
+	for _, status := range statuses {
+		if status.State != database.IndexOK {
+			drift++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": statuses, "drift": drift})
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"backend/config"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceController exposes operator actions: reloading the configuration
+// and replaying contract events from the chain
+type MaintenanceController struct {
+	Replayer *services.EventReplayer
+	Audit    *services.AuditLog
+	// Tenant is the tenant whose chains are reported after a reload
+	Tenant string
+}
+
+// ReloadConfig re-reads the configuration file, for every tenant
+func (m *MaintenanceController) ReloadConfig(c *gin.Context) {
+	err := config.Reload()
+	recordAudit(c, m.Audit, services.AuditRecord{Action: models.AuditConfigReload, Target: "config", Err: err})
+	if err != nil {
+		log.Printf("Error reloading configuration: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload configuration", "details": err.Error()})
+		return
+	}
+
+	log.Printf("Reloaded configuration")
+	c.JSON(http.StatusOK, gin.H{"message": "Configuration reloaded", "chains": config.ForTenant(m.Tenant).ChainIDs()})
+}
+
+type replayEventsRequest struct {
+	ChainID      string  `json:"chain_id" binding:"required"`
+	ContractType string  `json:"contract_type" binding:"required"`
+	FromBlock    *uint64 `json:"from_block" binding:"required"`
+	ToBlock      *uint64 `json:"to_block" binding:"required"`
+}
+
+// ReplayEvents reads a contract's logs over a block range and stores the
+// decoded events, for ranges the monitor missed
+func (m *MaintenanceController) ReplayEvents(c *gin.Context) {
+	var body replayEventsRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	request := services.ReplayRequest{
+		ChainID:      body.ChainID,
+		ContractType: body.ContractType,
+		FromBlock:    *body.FromBlock,
+		ToBlock:      *body.ToBlock,
+	}
+
+	// Rejected ranges are audited too, so the log shows every replay attempt
+	result, err := m.Replayer.Replay(c.Request.Context(), request)
+	recordAudit(c, m.Audit, services.AuditRecord{
+		Action:  models.AuditEventReplay,
+		Target:  request.ChainID + "/" + request.ContractType,
+		Payload: request,
+		Err:     err,
+	})
+	if errors.Is(err, services.ErrInvalidReplay) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid replay", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error replaying %s on chain %s: %v", request.ContractType, request.ChainID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to replay events", "details": err.Error(), "data": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+
+	"backend/services"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// maxQuoteTextLength bounds the message text a quote can be requested for
+const maxQuoteTextLength = 1024
+
+// QuoteController prices cross-chain transfers
+type QuoteController struct {
+	Quotes *services.QuoteService
+}
+
+// GetQuote returns the CCIP fee for a transfer along with the Messenger's LINK
+// balance and whether the destination chain is allowlisted
+func (q *QuoteController) GetQuote(c *gin.Context) {
+	request, err := parseQuoteRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote request", "details": err.Error()})
+		return
+	}
+
+	quote, err := q.Quotes.Quote(c.Request.Context(), request)
+	if errors.Is(err, services.ErrUnsupportedLane) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot quote this transfer", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error quoting %s -> %s: %v", request.FromChainID, request.ToChainID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to get fee quote", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quote})
+}
+
+// parseQuoteRequest reads from, to, amount and receiver, plus the optional
+// text and client that go into the message payload. The client defaults to
+// the receiver.
+func parseQuoteRequest(c *gin.Context) (services.QuoteRequest, error) {
+	request := services.QuoteRequest{
+		FromChainID: c.Query("from"),
+		ToChainID:   c.Query("to"),
+		Text:        c.Query("text"),
+	}
+	if request.FromChainID == "" || request.ToChainID == "" {
+		return request, errors.New("from and to chain IDs are required")
+	}
+
+	amount, ok := new(big.Int).SetString(c.Query("amount"), 10)
+	if !ok || amount.Sign() <= 0 {
+		return request, errors.New("amount must be a positive integer in wei")
+	}
+	request.Amount = amount
+
+	receiver := c.Query("receiver")
+	if !common.IsHexAddress(receiver) || common.HexToAddress(receiver) == (common.Address{}) {
+		return request, errors.New("receiver must be a non-zero address")
+	}
+	request.Receiver = common.HexToAddress(receiver)
+
+	request.Client = request.Receiver
+	if client := c.Query("client"); client != "" {
+		if !common.IsHexAddress(client) {
+			return request, errors.New("client must be an address")
+		}
+		request.Client = common.HexToAddress(client)
+	}
+	if len(request.Text) > maxQuoteTextLength {
+		return request, errors.New("text is too long")
+	}
+	return request, nil
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"net/http"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitController reports rate limiter usage to admins
+type RateLimitController struct {
+	Limiter *services.RateLimiter
+}
+
+// GetKeyUsage returns the current buckets and counters for one API key
+func (r *RateLimitController) GetKeyUsage(c *gin.Context) {
+	usage, exists := r.Limiter.Usage("key:" + c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No usage recorded for this API key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
+
+// ListUsage returns the usage of every client currently tracked by the limiter
+func (r *RateLimitController) ListUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": r.Limiter.AllUsage()})
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/database"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bounds on the number of reports listed at once
+const (
+	defaultReportLimit = 30
+	maxReportLimit     = 366
+)
+
+// ReportController serves the daily and weekly transfer reports
+type ReportController struct {
+	Reports *services.ReportGenerator
+}
+
+// GetReports returns the stored report for the period containing date
+// (YYYY-MM-DD), or the most recent reports of the period when no date is given
+func (r *ReportController) GetReports(c *gin.Context) {
+	period := c.Param("period")
+	date, hasDate, err := parseReportDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	if hasDate {
+		report, err := r.Reports.Get(c.Request.Context(), period, date)
+		switch {
+		case errors.Is(err, services.ErrInvalidReport):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report", "details": err.Error()})
+		case errors.Is(err, database.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "No report has been generated for this period"})
+		case err != nil:
+			log.Printf("Error reading %s report: %v", period, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read report"})
+		default:
+			c.JSON(http.StatusOK, gin.H{"data": report})
+		}
+		return
+	}
+
+	limit := defaultReportLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxReportLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": "limit must be between 1 and 366"})
+			return
+		}
+	}
+	reports, err := r.Reports.List(c.Request.Context(), period, limit)
+	if errors.Is(err, services.ErrInvalidReport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error listing %s reports: %v", period, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reports"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reports, "count": len(reports)})
+}
+
+// RegenerateReport rebuilds the report for the period containing date, or the
+// current period, instead of waiting for the next scheduled run
+func (r *ReportController) RegenerateReport(c *gin.Context) {
+	period := c.Param("period")
+	date, hasDate, err := parseReportDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+	if !hasDate {
+		date = time.Now().UTC()
+	}
+
+	report, err := r.Reports.Generate(c.Request.Context(), period, date)
+	if errors.Is(err, services.ErrInvalidReport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error generating %s report: %v", period, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate report", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// parseReportDate reads the optional date query parameter
+func parseReportDate(c *gin.Context) (time.Time, bool, error) {
+	value := c.Query("date")
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false, errors.New("date must be formatted YYYY-MM-DD")
+	}
+	return date, true, nil
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"backend/database"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransactionController lets admins follow transactions sent by the backend's signers
+type TransactionController struct {
+	Transactions *services.TxEngines
+}
+
+// ListTransactions returns transactions newest first, filtered by chain_id and status
+func (t *TransactionController) ListTransactions(c *gin.Context) {
+	filter := database.TxFilter{
+		ChainID: c.Query("chain_id"),
+		Status:  c.Query("status"),
+		Limit:   100,
+	}
+	switch filter.Status {
+	case "", models.TxPending, models.TxConfirmed, models.TxReverted, models.TxFailed, models.TxStuck:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "details": filter.Status})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit", "details": "limit must be between 1 and 1000"})
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	txs, err := t.Transactions.Store.ListTxs(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Error listing transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list transactions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": txs})
+}
+
+// GetTransaction returns one transaction with all of its attempts
+func (t *TransactionController) GetTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	tx, err := t.Transactions.Store.GetTx(c.Request.Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching transaction %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tx})
+}
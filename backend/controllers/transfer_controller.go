@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"net/http"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransferController reports on cross-chain transfers
+type TransferController struct {
+	Stuck *services.StuckTransferDetector
+}
+
+// ListStuckTransfers returns the transfers the detector last found stuck,
+// optionally filtered by source_chain_id and destination_chain_id
+func (t *TransferController) ListStuckTransfers(c *gin.Context) {
+	transfers, checkedAt := t.Stuck.Stuck()
+	source, destination := c.Query("source_chain_id"), c.Query("destination_chain_id")
+
+	matched := make([]services.StuckTransfer, 0, len(transfers))
+	for _, transfer := range transfers {
+		if (source == "" || transfer.SourceChainID == source) && (destination == "" || transfer.DestinationChainID == destination) {
+			matched = append(matched, transfer)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": matched, "checked_at": checkedAt})
+}
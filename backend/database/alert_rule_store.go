@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AlertRuleStore persists alert rules
+type AlertRuleStore interface {
+	CreateRule(ctx context.Context, rule *models.AlertRule) error
+	GetRule(ctx context.Context, id string) (*models.AlertRule, error)
+	ListRules(ctx context.Context) ([]models.AlertRule, error)
+	// UpdateRule replaces a rule, returning ErrNotFound if it doesn't exist
+	UpdateRule(ctx context.Context, rule *models.AlertRule) error
+	DeleteRule(ctx context.Context, id string) error
+}
+
+// MongoAlertRuleStore stores alert rules in the alert_rules collection
+type MongoAlertRuleStore struct {
+	rules *mongo.Collection
+}
+
+// NewMongoAlertRuleStore creates an alert rule store backed by the given database
+func NewMongoAlertRuleStore(db *mongo.Database) *MongoAlertRuleStore {
+	return &MongoAlertRuleStore{rules: db.Collection("alert_rules")}
+}
+
+func (s *MongoAlertRuleStore) CreateRule(ctx context.Context, rule *models.AlertRule) error {
+	_, err := s.rules.InsertOne(ctx, rule)
+	return err
+}
+
+func (s *MongoAlertRuleStore) GetRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	err := s.rules.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *MongoAlertRuleStore) ListRules(ctx context.Context) ([]models.AlertRule, error) {
+	cursor, err := s.rules.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	rules := []models.AlertRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *MongoAlertRuleStore) UpdateRule(ctx context.Context, rule *models.AlertRule) error {
+	result, err := s.rules.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoAlertRuleStore) DeleteRule(ctx context.Context, id string) error {
+	result, err := s.rules.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned by stores when the requested document does not exist
+var ErrNotFound = errors.New("not found")
+
+// APIKeyStore persists API keys
+type APIKeyStore interface {
+	CreateKey(ctx context.Context, key *models.APIKey) error
+	GetKey(ctx context.Context, id string) (*models.APIKey, error)
+	ListKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, id string, at time.Time) error
+	TouchKey(ctx context.Context, id string, at time.Time) error
+}
+
+// NonceStore remembers request nonces so signed requests cannot be replayed
+type NonceStore interface {
+	// UseNonce records the nonce and returns false if it was already used
+	UseNonce(ctx context.Context, keyID, nonce string, expiresAt time.Time) (bool, error)
+}
+
+// MongoAPIKeyStore stores API keys in the api_keys collection
+type MongoAPIKeyStore struct {
+	keys *mongo.Collection
+}
+
+// NewMongoAPIKeyStore creates an API key store backed by the given database
+func NewMongoAPIKeyStore(db *mongo.Database) *MongoAPIKeyStore {
+	return &MongoAPIKeyStore{keys: db.Collection("api_keys")}
+}
+
+func (s *MongoAPIKeyStore) CreateKey(ctx context.Context, key *models.APIKey) error {
+	_, err := s.keys.InsertOne(ctx, key)
+	return err
+}
+
+func (s *MongoAPIKeyStore) GetKey(ctx context.Context, id string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := s.keys.FindOne(ctx, bson.M{"_id": id}).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *MongoAPIKeyStore) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	cursor, err := s.keys.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *MongoAPIKeyStore) RevokeKey(ctx context.Context, id string, at time.Time) error {
+	result, err := s.keys.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoAPIKeyStore) TouchKey(ctx context.Context, id string, at time.Time) error {
+	_, err := s.keys.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
+
+// MongoNonceStore records used nonces in the auth_nonces collection. A unique
+// index rejects duplicates and a TTL index expires nonces once their timestamp
+// window has passed.
+type MongoNonceStore struct {
+	nonces *mongo.Collection
+}
+
+// NewMongoNonceStore creates a nonce store backed by the given database. It
+// relies on the auth_nonces indexes declared in Indexes.
+func NewMongoNonceStore(db *mongo.Database) *MongoNonceStore {
+	return &MongoNonceStore{nonces: db.Collection("auth_nonces")}
+}
+
+func (s *MongoNonceStore) UseNonce(ctx context.Context, keyID, nonce string, expiresAt time.Time) (bool, error) {
+	_, err := s.nonces.InsertOne(ctx, bson.M{"key_id": keyID, "nonce": nonce, "expires_at": expiresAt})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ArchiveFilter selects archives holding events created in [From, To). Zero
+// times are unbounded. EventName also selects archives of every event type.
+type ArchiveFilter struct {
+	EventName string
+	From      time.Time
+	To        time.Time
+}
+
+// ArchiveStore keeps the manifest of event archives
+type ArchiveStore interface {
+	// AddArchive records an archive file once it has been written
+	AddArchive(ctx context.Context, archive *models.Archive) error
+	// ListArchives returns the matching archives, oldest events first
+	ListArchives(ctx context.Context, filter ArchiveFilter) ([]models.Archive, error)
+}
+
+// MongoArchiveStore keeps the archive manifest in the archives collection
+type MongoArchiveStore struct {
+	archives *mongo.Collection
+}
+
+// NewMongoArchiveStore creates an archive store backed by the given database
+func NewMongoArchiveStore(db *mongo.Database) *MongoArchiveStore {
+	return &MongoArchiveStore{archives: db.Collection("archives")}
+}
+
+func (s *MongoArchiveStore) AddArchive(ctx context.Context, archive *models.Archive) error {
+	_, err := s.archives.InsertOne(ctx, archive)
+	return err
+}
+
+func (s *MongoArchiveStore) ListArchives(ctx context.Context, filter ArchiveFilter) ([]models.Archive, error) {
+	query := bson.M{}
+	if filter.EventName != "" {
+		query["event_name"] = bson.M{"$in": bson.A{filter.EventName, nil}}
+	}
+	if !filter.From.IsZero() {
+		query["last_event_at"] = bson.M{"$gte": filter.From}
+	}
+	if !filter.To.IsZero() {
+		query["first_event_at"] = bson.M{"$lt": filter.To}
+	}
+
+	cursor, err := s.archives.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "first_event_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	archives := []models.Archive{}
+	if err := cursor.All(ctx, &archives); err != nil {
+		return nil, err
+	}
+	return archives, nil
+}
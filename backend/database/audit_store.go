@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrConflict is returned when an audit entry's sequence number or an event's key is already taken
+var ErrConflict = errors.New("conflict")
+
+// AuditFilter selects audit entries. Empty fields are ignored.
+type AuditFilter struct {
+	Action string
+	Actor  string
+	From   time.Time
+	To     time.Time
+	// AfterSequence pages through the log in order
+	AfterSequence uint64
+	// Limit of 0 returns every matching entry
+	Limit int
+}
+
+// AuditStore is an append-only log of privileged actions. It offers no way
+// to update or delete entries.
+type AuditStore interface {
+	// LastAudit returns the newest entry, or ErrNotFound if the log is empty
+	LastAudit(ctx context.Context) (*models.AuditEntry, error)
+	// AppendAudit inserts an entry, returning ErrConflict if its sequence number is taken
+	AppendAudit(ctx context.Context, entry *models.AuditEntry) error
+	// EachAudit calls fn for matching entries in sequence order until fn returns an error
+	EachAudit(ctx context.Context, filter AuditFilter, fn func(models.AuditEntry) error) error
+}
+
+// MongoAuditStore keeps the audit log in the audit_log collection, keyed by sequence number
+type MongoAuditStore struct {
+	entries *mongo.Collection
+}
+
+// NewMongoAuditStore creates an audit store backed by the given database
+func NewMongoAuditStore(db *mongo.Database) *MongoAuditStore {
+	return &MongoAuditStore{entries: db.Collection("audit_log")}
+}
+
+func (s *MongoAuditStore) LastAudit(ctx context.Context) (*models.AuditEntry, error) {
+	var entry models.AuditEntry
+	err := s.entries.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *MongoAuditStore) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	_, err := s.entries.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *MongoAuditStore) EachAudit(ctx context.Context, filter AuditFilter, fn func(models.AuditEntry) error) error {
+	query := bson.M{}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.AfterSequence > 0 {
+		query["_id"] = bson.M{"$gt": filter.AfterSequence}
+	}
+	timeRange := bson.M{}
+	if !filter.From.IsZero() {
+		timeRange["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timeRange["$lte"] = filter.To
+	}
+	if len(timeRange) > 0 {
+		query["time"] = timeRange
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := s.entries.Find(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventMigrations bring the events collection to the current schema
+var EventMigrations = []Migration{
+	{
+		ID:          "0001_chain_id_field",
+		Description: "Rename the ChainId field to chain_id",
+		Up: updateMigration("events",
+			bson.M{"ChainId": bson.M{"$exists": true}},
+			bson.M{"$rename": bson.M{"ChainId": chainIDField}}),
+		Down: updateMigration("events",
+			bson.M{chainIDField: bson.M{"$exists": true}},
+			bson.M{"$rename": bson.M{chainIDField: "ChainId"}}),
+	},
+	{
+		ID:          "0002_time_layout",
+		Description: "Rewrite created_at, updated_at and timestamp in the sortable UTC layout, keeping the originals in legacy_times",
+		Up:          normaliseEventTimes,
+		Down:        restoreEventTimes,
+	},
+	{
+		ID:          "0003_amount_values",
+		Description: "Store amount and fees as Decimal128 in amount_value and fees_value",
+		Up:          setAmountValues,
+		Down: updateMigration("events",
+			bson.M{"$or": bson.A{bson.M{"amount_value": bson.M{"$exists": true}}, bson.M{"fees_value": bson.M{"$exists": true}}}},
+			bson.M{"$unset": bson.M{"amount_value": "", "fees_value": ""}}),
+	},
+	{
+		ID:          "0004_event_key",
+		Description: "Key events by chain, transaction hash, event name and log index in event_key",
+		Up:          setEventKeys,
+		Down: updateMigration("events",
+			bson.M{"event_key": bson.M{"$exists": true}},
+			bson.M{"$unset": bson.M{"event_key": ""}}),
+	},
+}
+
+// eventTimeFields are the event fields holding times
+var eventTimeFields = []string{"created_at", "updated_at", "timestamp"}
+
+// legacyTimeLayouts are the layouts events have been stored with: RFC 3339
+// from the first API, and the monitor's display layout
+var legacyTimeLayouts = []string{models.TimeLayout, time.RFC3339Nano, "2006-01-02 15:04:05 MST"}
+
+// timeLayoutPattern matches times already in models.TimeLayout
+var timeLayoutPattern = primitive.Regex{Pattern: `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{9}Z$`}
+
+// migrationBatchSize is how many document updates are sent in one bulk write
+const migrationBatchSize = 500
+
+func normaliseEventTimes(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	events := db.Collection("events")
+	var outdated bson.A
+	for _, field := range eventTimeFields {
+		outdated = append(outdated, bson.M{field: bson.M{"$exists": true, "$not": timeLayoutPattern}})
+	}
+	cursor, err := events.Find(ctx, bson.M{"$or": outdated})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var changed, unreadable int64
+	var batch []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return changed, err
+		}
+
+		set := bson.M{}
+		for _, field := range eventTimeFields {
+			value, isString := doc[field].(string)
+			if !isString || value == "" {
+				continue
+			}
+			normalised, ok := normaliseTime(value)
+			if !ok {
+				unreadable++
+				continue
+			}
+			if normalised != value {
+				set[field] = normalised
+				set["legacy_times."+field] = value
+			}
+		}
+		if len(set) == 0 {
+			continue
+		}
+		changed++
+		if dryRun {
+			continue
+		}
+
+		batch = append(batch, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": doc["_id"]}).SetUpdate(bson.M{"$set": set}))
+		if len(batch) == migrationBatchSize {
+			if _, err := events.BulkWrite(ctx, batch); err != nil {
+				return changed, err
+			}
+			batch = nil
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return changed, err
+	}
+	if len(batch) > 0 {
+		if _, err := events.BulkWrite(ctx, batch); err != nil {
+			return changed, err
+		}
+	}
+	if unreadable > 0 {
+		log.Printf("Left %d event times in a layout that couldn't be read", unreadable)
+	}
+	return changed, nil
+}
+
+// normaliseTime rewrites a time stored in any legacy layout in models.TimeLayout
+func normaliseTime(value string) (string, bool) {
+	for _, layout := range legacyTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return models.FormatTime(t), true
+		}
+	}
+	return "", false
+}
+
+func restoreEventTimes(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	restore := bson.M{}
+	for _, field := range eventTimeFields {
+		restore[field] = bson.M{"$ifNull": bson.A{"$legacy_times." + field, "$" + field}}
+	}
+	return updateMigration("events",
+		bson.M{"legacy_times": bson.M{"$exists": true}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: restore}},
+			{{Key: "$unset", Value: "legacy_times"}},
+		})(ctx, db, dryRun)
+}
+
+// setEventKeys fills in event_key. Events stored before log indexes were
+// recorded can't be given their real one without the chain, so they are
+// numbered by the order they were stored in among the same-named events of
+// their transaction.
+func setEventKeys(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	events := db.Collection("events")
+	cursor, err := events.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"event_key": bson.M{"$exists": false}},
+			bson.M{"log_index": bson.M{"$exists": false}},
+		}}}},
+		{{Key: "$setWindowFields", Value: bson.M{
+			"partitionBy": bson.M{"chain_id": "$" + chainIDField, "transaction_hash": "$transaction_hash", "event_name": "$event_name"},
+			"sortBy":      bson.M{"_id": 1},
+			"output":      bson.M{"ordinal": bson.M{"$documentNumber": bson.M{}}},
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var changed int64
+	var batch []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID              interface{} `bson:"_id"`
+			ChainID         string      `bson:"chain_id"`
+			TransactionHash string      `bson:"transaction_hash"`
+			EventName       string      `bson:"event_name"`
+			LogIndex        *uint       `bson:"log_index"`
+			Ordinal         int64       `bson:"ordinal"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return changed, err
+		}
+		event := models.EventData{ChainID: doc.ChainID, TransactionHash: doc.TransactionHash, EventName: doc.EventName}
+		if doc.LogIndex != nil {
+			event.LogIndex = *doc.LogIndex
+		} else {
+			event.LogIndex = uint(doc.Ordinal - 1)
+		}
+		changed++
+		if dryRun {
+			continue
+		}
+
+		set := bson.M{"log_index": event.LogIndex, "event_key": event.Key()}
+		batch = append(batch, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": doc.ID}).SetUpdate(bson.M{"$set": set}))
+		if len(batch) == migrationBatchSize {
+			if _, err := events.BulkWrite(ctx, batch); err != nil {
+				return changed, err
+			}
+			batch = nil
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return changed, err
+	}
+	if len(batch) > 0 {
+		if _, err := events.BulkWrite(ctx, batch); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// setAmountValues converts amount and fees to Decimal128. Amounts too precise
+// for Decimal128 are set to null.
+func setAmountValues(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	var changed int64
+	for _, fields := range [][2]string{{"amount", "amount_value"}, {"fees", "fees_value"}} {
+		raw, value := fields[0], fields[1]
+		documents, err := updateMigration("events",
+			bson.M{raw: bson.M{"$exists": true}, value: bson.M{"$exists": false}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{
+				value: bson.M{"$convert": bson.M{"input": "$" + raw, "to": "decimal", "onError": nil, "onNull": nil}},
+			}}}})(ctx, db, dryRun)
+		if err != nil {
+			return changed, err
+		}
+		changed += documents
+	}
+	return changed, nil
+}
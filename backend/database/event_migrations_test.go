@@ -0,0 +1,46 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEventMigrationsOrder(t *testing.T) {
+	seen := make(map[string]bool)
+	for i, migration := range EventMigrations {
+		if migration.ID == "" || migration.Description == "" || migration.Up == nil {
+			t.Fatalf("migration %d is incomplete: %+v", i, migration)
+		}
+		if seen[migration.ID] {
+			t.Fatalf("migration %s is declared twice", migration.ID)
+		}
+		seen[migration.ID] = true
+		if i > 0 && strings.Compare(EventMigrations[i-1].ID, migration.ID) >= 0 {
+			t.Fatalf("migration %s is declared after %s", migration.ID, EventMigrations[i-1].ID)
+		}
+	}
+}
+
+func TestNormaliseTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		ok    bool
+	}{
+		{"current layout", "2026-03-11T15:04:05.000000000Z", "2026-03-11T15:04:05.000000000Z", true},
+		{"rfc3339", "2026-03-11T15:04:05Z", "2026-03-11T15:04:05.000000000Z", true},
+		{"rfc3339 with offset", "2026-03-11T17:04:05.5+02:00", "2026-03-11T15:04:05.500000000Z", true},
+		{"monitor display layout", "2026-03-11 15:04:05 UTC", "2026-03-11T15:04:05.000000000Z", true},
+		{"unix seconds", "1773241445", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := normaliseTime(tt.value)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("normaliseTime(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
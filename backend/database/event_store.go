@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"backend/models"
+)
+
+// DefaultEventLimit and MaxEventLimit bound the number of events returned by
+// FindEvents. MaxExportEvents bounds a single export.
+const (
+	DefaultEventLimit = 100
+	MaxEventLimit     = 1000
+	MaxExportEvents   = 100000
+)
+
+// EventFilter selects events. Empty fields are ignored; From and To bound created_at.
+// Events without an amount never match an amount bound.
+type EventFilter struct {
+	EventName       string
+	ChainID         string
+	CallerAddress   string
+	ContractAddress string
+	ToFromUser      string
+	MessageID       string
+	TransactionHash string
+	RunID           string
+	From            time.Time
+	To              time.Time
+	// MinAmount and MaxAmount bound the base-unit amount, inclusively
+	MinAmount *big.Int
+	MaxAmount *big.Int
+	Limit     int
+	Offset    int
+}
+
+// EventAggregate summarises the events sharing an event name and chain
+type EventAggregate struct {
+	EventName   string `json:"event_name" bson:"event_name"`
+	ChainID     string `json:"chain_id" bson:"chain_id"`
+	Count       int64  `json:"count" bson:"count"`
+	TotalAmount string `json:"total_amount" bson:"total_amount"`
+}
+
+// EventStore persists and queries contract events
+type EventStore interface {
+	// InsertEvent stores a new event, returning ErrConflict if one with the same key is stored
+	InsertEvent(ctx context.Context, event *models.EventData) error
+	// UpsertEvent replaces the event with the same key, or inserts it. A
+	// replaced event keeps its stored created_at.
+	UpsertEvent(ctx context.Context, event *models.EventData) error
+	// FindEvents returns matching events, newest first
+	FindEvents(ctx context.Context, filter EventFilter) ([]models.EventData, error)
+	// EachEvent streams matching events to fn, oldest first, stopping at fn's
+	// first error. Offset is honoured and a Limit of 0 means no limit.
+	EachEvent(ctx context.Context, filter EventFilter, fn func(models.EventData) error) error
+	// LatestEventByAddress returns the newest event for a caller, optionally of one type.
+	// It returns ErrNotFound if there is none.
+	LatestEventByAddress(ctx context.Context, callerAddress, eventName string) (*models.EventData, error)
+	// AggregateEvents counts events and sums their amounts per event name and chain
+	AggregateEvents(ctx context.Context, filter EventFilter) ([]EventAggregate, error)
+	// DeleteEvents removes the events matching filter whose event key is in
+	// keys, ignoring paging, and returns how many were removed
+	DeleteEvents(ctx context.Context, filter EventFilter, keys []string) (int64, error)
+	// Ping checks that the store is reachable
+	Ping(ctx context.Context) error
+}
+
+// limit returns the filter's limit clamped to the allowed range
+func (f EventFilter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultEventLimit
+	}
+	if f.Limit > MaxEventLimit {
+		return MaxEventLimit
+	}
+	return f.Limit
+}
@@ -0,0 +1,303 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexSpec declares one index of a collection
+type IndexSpec struct {
+	Keys   bson.D
+	Unique bool
+	Sparse bool
+	// ExpireAfterSeconds makes a TTL index when set
+	ExpireAfterSeconds *int64
+}
+
+// Signature renders the index keys the way MongoDB names indexes by default,
+// e.g. "event_name_1_timestamp_-1"
+func (s IndexSpec) Signature() string {
+	return keySignature(s.Keys)
+}
+
+func keySignature(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+func (s IndexSpec) model() mongo.IndexModel {
+	opts := options.Index()
+	if s.Unique {
+		opts.SetUnique(true)
+	}
+	if s.Sparse {
+		opts.SetSparse(true)
+	}
+	if s.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(int32(*s.ExpireAfterSeconds))
+	}
+	return mongo.IndexModel{Keys: s.Keys, Options: opts}
+}
+
+func expireAfter(seconds int64) *int64 {
+	return &seconds
+}
+
+// Indexes declares the indexes of every collection, other than _id's
+var Indexes = map[string][]IndexSpec{
+	"events": {
+		{Keys: bson.D{{Key: "event_name", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "caller_address", Value: 1}, {Key: "event_name", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: chainIDField, Value: 1}, {Key: "event_name", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "contract_address", Value: 1}, {Key: "event_name", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "message_id", Value: 1}}, Sparse: true},
+		{Keys: bson.D{{Key: "to_from_user", Value: 1}, {Key: "event_name", Value: 1}, {Key: "timestamp", Value: -1}}, Sparse: true},
+		{Keys: bson.D{{Key: "run_id", Value: 1}}, Sparse: true},
+		{Keys: bson.D{{Key: "event_name", Value: 1}, {Key: "amount_value", Value: 1}}, Sparse: true},
+		{Keys: bson.D{{Key: "event_key", Value: 1}}, Unique: true},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "event_name", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	"auth_nonces": {
+		{Keys: bson.D{{Key: "key_id", Value: 1}, {Key: "nonce", Value: 1}}, Unique: true},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: expireAfter(0)},
+	},
+	"stuck_transfer_alerts": {
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: expireAfter(0)},
+	},
+	"transactions": {
+		{Keys: bson.D{{Key: "chain_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "attempts.hash", Value: 1}}},
+	},
+	"audit_log": {
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "time", Value: 1}}},
+	},
+	"archives": {
+		{Keys: bson.D{{Key: "first_event_at", Value: 1}}},
+	},
+	"reports": {
+		{Keys: bson.D{{Key: "period", Value: 1}, {Key: "start", Value: -1}}},
+	},
+}
+
+// Index states reported by IndexManager.Report
+const (
+	IndexOK        = "ok"
+	IndexMissing   = "missing"
+	IndexExtra     = "extra"
+	IndexDifferent = "different"
+)
+
+// IndexStatus compares one declared or existing index with the other side
+type IndexStatus struct {
+	Collection string `json:"collection"`
+	// Name is the existing index's name, or the default name of a missing one
+	Name  string `json:"name"`
+	Keys  string `json:"keys"`
+	State string `json:"state"`
+	// Differences lists the options that differ from the declaration
+	Differences []string    `json:"differences,omitempty"`
+	Usage       *IndexUsage `json:"usage,omitempty"`
+}
+
+// IndexUsage is an index's $indexStats, summed over the hosts that reported it
+type IndexUsage struct {
+	Ops   int64     `json:"ops"`
+	Since time.Time `json:"since"`
+}
+
+// existingIndex is an index as listed by MongoDB
+type existingIndex struct {
+	Name               string `bson:"name"`
+	Key                bson.D `bson:"key"`
+	Unique             bool   `bson:"unique"`
+	Sparse             bool   `bson:"sparse"`
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+}
+
+// IndexManager applies the declared indexes and reports how the database differs from them
+type IndexManager struct {
+	db *mongo.Database
+}
+
+// NewIndexManager creates an index manager for the given database
+func NewIndexManager(db *mongo.Database) *IndexManager {
+	return &IndexManager{db: db}
+}
+
+// Ensure creates every declared index that doesn't exist yet. Existing
+// indexes are never changed or dropped; Report shows where they differ.
+func (m *IndexManager) Ensure(ctx context.Context) error {
+	for _, collection := range m.collections() {
+		existing, err := m.list(ctx, collection)
+		if err != nil {
+			return err
+		}
+		present := make(map[string]bool, len(existing))
+		for _, index := range existing {
+			present[keySignature(index.Key)] = true
+		}
+
+		var missing []mongo.IndexModel
+		for _, spec := range Indexes[collection] {
+			if !present[spec.Signature()] {
+				missing = append(missing, spec.model())
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		if _, err := m.db.Collection(collection).Indexes().CreateMany(ctx, missing); err != nil {
+			return fmt.Errorf("creating indexes on %s: %v", collection, err)
+		}
+		log.Printf("Created %d indexes on %s", len(missing), collection)
+	}
+	return nil
+}
+
+// Report lists every declared and existing index with its state and usage.
+// Extra indexes exist in the database but are not declared.
+func (m *IndexManager) Report(ctx context.Context) ([]IndexStatus, error) {
+	statuses := []IndexStatus{}
+	for _, collection := range m.collections() {
+		existing, err := m.list(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		usage, err := m.usage(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, compareIndexes(collection, Indexes[collection], existing, usage)...)
+	}
+	return statuses, nil
+}
+
+// compareIndexes matches a collection's declared indexes with the existing
+// ones by their keys
+func compareIndexes(collection string, specs []IndexSpec, existing []existingIndex, usage map[string]*IndexUsage) []IndexStatus {
+	var statuses []IndexStatus
+	bySignature := make(map[string]existingIndex, len(existing))
+	for _, index := range existing {
+		bySignature[keySignature(index.Key)] = index
+	}
+	declared := make(map[string]bool)
+	for _, spec := range specs {
+		signature := spec.Signature()
+		declared[signature] = true
+		status := IndexStatus{Collection: collection, Name: signature, Keys: signature, State: IndexMissing}
+		if index, exists := bySignature[signature]; exists {
+			status.Name = index.Name
+			status.Usage = usage[index.Name]
+			status.Differences = indexDifferences(spec, index)
+			status.State = IndexOK
+			if len(status.Differences) > 0 {
+				status.State = IndexDifferent
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	for _, index := range existing {
+		signature := keySignature(index.Key)
+		if index.Name == "_id_" || declared[signature] {
+			continue
+		}
+		statuses = append(statuses, IndexStatus{Collection: collection, Name: index.Name, Keys: signature, State: IndexExtra, Usage: usage[index.Name]})
+	}
+	return statuses
+}
+
+// collections returns the declared collections in name order
+func (m *IndexManager) collections() []string {
+	names := make([]string, 0, len(Indexes))
+	for name := range Indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *IndexManager) list(ctx context.Context, collection string) ([]existingIndex, error) {
+	cursor, err := m.db.Collection(collection).Indexes().List(ctx)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Name == "NamespaceNotFound" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var indexes []existingIndex
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
+
+// indexStatsRow is one host's $indexStats entry for an index
+type indexStatsRow struct {
+	Name     string `bson:"name"`
+	Accesses struct {
+		Ops   int64     `bson:"ops"`
+		Since time.Time `bson:"since"`
+	} `bson:"accesses"`
+}
+
+// usage reads $indexStats for a collection, keyed by index name
+func (m *IndexManager) usage(ctx context.Context, collection string) (map[string]*IndexUsage, error) {
+	var rows []indexStatsRow
+	if err := aggregateAll(ctx, m.db.Collection(collection), mongo.Pipeline{{{Key: "$indexStats", Value: bson.M{}}}}, &rows); err != nil {
+		return nil, err
+	}
+	return sumIndexUsage(rows), nil
+}
+
+// sumIndexUsage adds up the operations each host reports for an index and
+// keeps the earliest time its counting began
+func sumIndexUsage(rows []indexStatsRow) map[string]*IndexUsage {
+	usage := make(map[string]*IndexUsage, len(rows))
+	for _, row := range rows {
+		index, exists := usage[row.Name]
+		if !exists {
+			usage[row.Name] = &IndexUsage{Ops: row.Accesses.Ops, Since: row.Accesses.Since}
+			continue
+		}
+		index.Ops += row.Accesses.Ops
+		if row.Accesses.Since.Before(index.Since) {
+			index.Since = row.Accesses.Since
+		}
+	}
+	return usage
+}
+
+func indexDifferences(spec IndexSpec, index existingIndex) []string {
+	var differences []string
+	if spec.Unique != index.Unique {
+		differences = append(differences, fmt.Sprintf("unique is %t, declared %t", index.Unique, spec.Unique))
+	}
+	if spec.Sparse != index.Sparse {
+		differences = append(differences, fmt.Sprintf("sparse is %t, declared %t", index.Sparse, spec.Sparse))
+	}
+	switch {
+	case spec.ExpireAfterSeconds == nil && index.ExpireAfterSeconds != nil:
+		differences = append(differences, fmt.Sprintf("expires after %d seconds, declared no TTL", *index.ExpireAfterSeconds))
+	case spec.ExpireAfterSeconds != nil && index.ExpireAfterSeconds == nil:
+		differences = append(differences, fmt.Sprintf("has no TTL, declared %d seconds", *spec.ExpireAfterSeconds))
+	case spec.ExpireAfterSeconds != nil && *spec.ExpireAfterSeconds != *index.ExpireAfterSeconds:
+		differences = append(differences, fmt.Sprintf("expires after %d seconds, declared %d", *index.ExpireAfterSeconds, *spec.ExpireAfterSeconds))
+	}
+	return differences
+}
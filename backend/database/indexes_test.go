@@ -0,0 +1,117 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestIndexDifferences(t *testing.T) {
+	day := int64(86400)
+	hour := int64(3600)
+	tests := []struct {
+		name  string
+		spec  IndexSpec
+		index existingIndex
+		want  []string
+	}{
+		{"same", IndexSpec{Unique: true}, existingIndex{Unique: true}, nil},
+		{"not unique", IndexSpec{Unique: true}, existingIndex{}, []string{"unique is false, declared true"}},
+		{"sparse", IndexSpec{}, existingIndex{Sparse: true}, []string{"sparse is true, declared false"}},
+		{"unexpected TTL", IndexSpec{}, existingIndex{ExpireAfterSeconds: &day}, []string{"expires after 86400 seconds, declared no TTL"}},
+		{"missing TTL", IndexSpec{ExpireAfterSeconds: &day}, existingIndex{}, []string{"has no TTL, declared 86400 seconds"}},
+		{"other TTL", IndexSpec{ExpireAfterSeconds: &day}, existingIndex{ExpireAfterSeconds: &hour}, []string{"expires after 3600 seconds, declared 86400"}},
+		{"same TTL", IndexSpec{ExpireAfterSeconds: expireAfter(3600)}, existingIndex{ExpireAfterSeconds: &hour}, nil},
+		{
+			"several",
+			IndexSpec{Unique: true, ExpireAfterSeconds: &day},
+			existingIndex{Sparse: true},
+			[]string{"unique is false, declared true", "sparse is true, declared false", "has no TTL, declared 86400 seconds"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexDifferences(tt.spec, tt.index); strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Fatalf("differences %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareIndexes(t *testing.T) {
+	specs := []IndexSpec{
+		{Keys: bson.D{{Key: "event_key", Value: 1}}, Unique: true},
+		{Keys: bson.D{{Key: "event_name", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "run_id", Value: 1}}, Sparse: true},
+	}
+	// listIndexes returns key directions as int32 or double, and custom names
+	existing := decodeIndexes(t,
+		bson.M{"name": "_id_", "key": bson.D{{Key: "_id", Value: int32(1)}}},
+		bson.M{"name": "event_key_1", "key": bson.D{{Key: "event_key", Value: int32(1)}}, "unique": true},
+		bson.M{"name": "by_name", "key": bson.D{{Key: "event_name", Value: 1.0}, {Key: "timestamp", Value: -1.0}}, "expireAfterSeconds": 3600.0},
+		bson.M{"name": "old_1", "key": bson.D{{Key: "old", Value: int32(1)}}},
+	)
+	usage := map[string]*IndexUsage{"event_key_1": {Ops: 5}, "old_1": {Ops: 0}}
+
+	var got []string
+	for _, status := range compareIndexes("events", specs, existing, usage) {
+		line := status.Name + " " + status.Keys + " " + status.State
+		if status.Usage != nil {
+			line += " used"
+		}
+		got = append(got, strings.Join(append([]string{line}, status.Differences...), "; "))
+	}
+	want := []string{
+		"event_key_1 event_key_1 ok used",
+		"by_name event_name_1_timestamp_-1 different; expires after 3600 seconds, declared no TTL",
+		"run_id_1 run_id_1 missing",
+		"old_1 old_1 extra used",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("statuses\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSumIndexUsage(t *testing.T) {
+	earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+	row := func(name string, ops int64, since time.Time) indexStatsRow {
+		var r indexStatsRow
+		r.Name, r.Accesses.Ops, r.Accesses.Since = name, ops, since
+		return r
+	}
+
+	// Each replica set member reports its own counters
+	usage := sumIndexUsage([]indexStatsRow{
+		row("event_key_1", 3, later),
+		row("event_key_1", 4, earlier),
+		row("run_id_1", 0, later),
+	})
+	if len(usage) != 2 {
+		t.Fatalf("usage for %d indexes, want 2", len(usage))
+	}
+	if got := usage["event_key_1"]; got.Ops != 7 || !got.Since.Equal(earlier) {
+		t.Fatalf("event_key_1 usage %+v, want 7 ops since %v", got, earlier)
+	}
+	if got := usage["run_id_1"]; got.Ops != 0 || !got.Since.Equal(later) {
+		t.Fatalf("run_id_1 usage %+v, want 0 ops since %v", got, later)
+	}
+}
+
+// decodeIndexes round-trips index documents through BSON as listIndexes returns them
+func decodeIndexes(t *testing.T, documents ...bson.M) []existingIndex {
+	t.Helper()
+	indexes := make([]existingIndex, len(documents))
+	for i, document := range documents {
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bson.Unmarshal(raw, &indexes[i]); err != nil {
+			t.Fatalf("decoding %v: %v", document, err)
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,729 @@
+package database
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/models"
+)
+
+// MemoryEventStore is an EventStore kept entirely in memory, for tests and demos
+type MemoryEventStore struct {
+	mu     sync.RWMutex
+	events []models.EventData
+	// byKey indexes events by event key, standing in for the unique event_key index
+	byKey map[string]int
+}
+
+// NewMemoryEventStore creates an empty in-memory event store
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{byKey: make(map[string]int)}
+}
+
+func (s *MemoryEventStore) InsertEvent(ctx context.Context, event *models.EventData) error {
+	event.SetAmountValues()
+	event.EventKey = event.Key()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.byKey[event.EventKey]; exists {
+		return ErrConflict
+	}
+	s.byKey[event.EventKey] = len(s.events)
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *MemoryEventStore) UpsertEvent(ctx context.Context, event *models.EventData) error {
+	event.SetAmountValues()
+	event.EventKey = event.Key()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, exists := s.byKey[event.EventKey]; exists {
+		createdAt := s.events[i].CreatedAt
+		s.events[i] = *event
+		s.events[i].CreatedAt = createdAt
+		return nil
+	}
+	s.byKey[event.EventKey] = len(s.events)
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *MemoryEventStore) FindEvents(ctx context.Context, filter EventFilter) ([]models.EventData, error) {
+	matched := s.matching(filter)
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt > matched[j].CreatedAt
+	})
+
+	if filter.Offset >= len(matched) {
+		return []models.EventData{}, nil
+	}
+	matched = matched[filter.Offset:]
+	if limit := filter.limit(); len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
+
+func (s *MemoryEventStore) EachEvent(ctx context.Context, filter EventFilter, fn func(models.EventData) error) error {
+	matched := s.matching(filter)
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt < matched[j].CreatedAt
+	})
+
+	if filter.Offset >= len(matched) {
+		return nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	for _, event := range matched {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryEventStore) LatestEventByAddress(ctx context.Context, callerAddress, eventName string) (*models.EventData, error) {
+	matched := s.matching(EventFilter{CallerAddress: callerAddress, EventName: eventName})
+	if len(matched) == 0 {
+		return nil, ErrNotFound
+	}
+
+	latest := matched[0]
+	for _, event := range matched[1:] {
+		if event.Timestamp > latest.Timestamp {
+			latest = event
+		}
+	}
+	return &latest, nil
+}
+
+func (s *MemoryEventStore) AggregateEvents(ctx context.Context, filter EventFilter) ([]EventAggregate, error) {
+	type groupKey struct{ eventName, chainID string }
+	totals := make(map[groupKey]*big.Int)
+	counts := make(map[groupKey]int64)
+
+	for _, event := range s.matching(filter) {
+		key := groupKey{event.EventName, event.ChainID}
+		if _, exists := totals[key]; !exists {
+			totals[key] = new(big.Int)
+		}
+		if amount, ok := new(big.Int).SetString(event.Amount, 10); ok {
+			totals[key].Add(totals[key], amount)
+		}
+		counts[key]++
+	}
+
+	aggregates := make([]EventAggregate, 0, len(totals))
+	for key, total := range totals {
+		aggregates = append(aggregates, EventAggregate{
+			EventName:   key.eventName,
+			ChainID:     key.chainID,
+			Count:       counts[key],
+			TotalAmount: total.String(),
+		})
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if aggregates[i].EventName != aggregates[j].EventName {
+			return aggregates[i].EventName < aggregates[j].EventName
+		}
+		return aggregates[i].ChainID < aggregates[j].ChainID
+	})
+	return aggregates, nil
+}
+
+func (s *MemoryEventStore) DeleteEvents(ctx context.Context, filter EventFilter, keys []string) (int64, error) {
+	remove := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		remove[key] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	kept := s.events[:0]
+	from, to := createdAtBounds(filter)
+	for _, event := range s.events {
+		if remove[event.EventKey] && eventMatches(filter, from, to, event) {
+			deleted++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	s.events = kept
+
+	// Removing events shifts the rest, so rebuild the index
+	s.byKey = make(map[string]int, len(s.events))
+	for i, event := range s.events {
+		s.byKey[event.EventKey] = i
+	}
+	return deleted, nil
+}
+
+func (s *MemoryEventStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// matching returns a copy of every event that satisfies the filter, ignoring paging
+func (s *MemoryEventStore) matching(filter EventFilter) []models.EventData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []models.EventData{}
+	from, to := createdAtBounds(filter)
+	for _, event := range s.events {
+		if eventMatches(filter, from, to, event) {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// createdAtBounds formats the filter's time bounds for comparison with created_at
+func createdAtBounds(filter EventFilter) (string, string) {
+	from, to := "", ""
+	if !filter.From.IsZero() {
+		from = models.FormatTime(filter.From)
+	}
+	if !filter.To.IsZero() {
+		to = models.FormatTime(filter.To)
+	}
+	return from, to
+}
+
+func eventMatches(filter EventFilter, from, to string, event models.EventData) bool {
+	if !matches(filter.EventName, event.EventName) ||
+		!matches(filter.ChainID, event.ChainID) ||
+		!matches(filter.CallerAddress, event.CallerAddress) ||
+		!matches(filter.ContractAddress, event.ContractAddress) ||
+		!matches(filter.ToFromUser, event.ToFromUser) ||
+		!matches(filter.MessageID, event.MessageID) ||
+		!matches(filter.TransactionHash, event.TransactionHash) ||
+		!matches(filter.RunID, event.RunID) {
+		return false
+	}
+	if from != "" && event.CreatedAt < from {
+		return false
+	}
+	if to != "" && event.CreatedAt >= to {
+		return false
+	}
+	return amountInRange(event.Amount, filter.MinAmount, filter.MaxAmount)
+}
+
+func matches(want, got string) bool {
+	return want == "" || want == got
+}
+
+func amountInRange(amount string, min, max *big.Int) bool {
+	if min == nil && max == nil {
+		return true
+	}
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return false
+	}
+	return (min == nil || value.Cmp(min) >= 0) && (max == nil || value.Cmp(max) <= 0)
+}
+
+// MemoryAPIKeyStore is an APIKeyStore kept in memory
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]models.APIKey
+}
+
+// NewMemoryAPIKeyStore creates an empty in-memory API key store
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{keys: make(map[string]models.APIKey)}
+}
+
+func (s *MemoryAPIKeyStore) CreateKey(ctx context.Context, key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = *key
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) GetKey(ctx context.Context, id string) (*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, exists := s.keys[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &key, nil
+}
+
+func (s *MemoryAPIKeyStore) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]models.APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *MemoryAPIKeyStore) RevokeKey(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.keys[id]
+	if !exists {
+		return ErrNotFound
+	}
+	key.RevokedAt = &at
+	s.keys[id] = key
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) TouchKey(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, exists := s.keys[id]; exists {
+		key.LastUsedAt = &at
+		s.keys[id] = key
+	}
+	return nil
+}
+
+// MemoryNonceStore is a NonceStore kept in memory. Expired nonces are
+// dropped whenever a new one is recorded.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+// NewMemoryNonceStore creates an empty in-memory nonce store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *MemoryNonceStore) UseNonce(ctx context.Context, keyID, nonce string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, expiry := range s.nonces {
+		if now.After(expiry) {
+			delete(s.nonces, key)
+		}
+	}
+
+	key := keyID + "/" + nonce
+	if _, used := s.nonces[key]; used {
+		return false, nil
+	}
+	s.nonces[key] = expiresAt
+	return true, nil
+}
+
+// MemoryStuckAlertStore is a StuckAlertStore kept in memory. Expired records
+// are dropped whenever a new one is recorded.
+type MemoryStuckAlertStore struct {
+	mu     sync.Mutex
+	alerts map[string]time.Time
+}
+
+// NewMemoryStuckAlertStore creates an empty in-memory stuck alert store
+func NewMemoryStuckAlertStore() *MemoryStuckAlertStore {
+	return &MemoryStuckAlertStore{alerts: make(map[string]time.Time)}
+}
+
+func (s *MemoryStuckAlertStore) MarkAlerted(ctx context.Context, messageID string, at, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, expiry := range s.alerts {
+		if at.After(expiry) {
+			delete(s.alerts, id)
+		}
+	}
+	if _, alerted := s.alerts[messageID]; alerted {
+		return false, nil
+	}
+	s.alerts[messageID] = expiresAt
+	return true, nil
+}
+
+// MemoryTxStore is a TxStore kept in memory
+type MemoryTxStore struct {
+	mu     sync.RWMutex
+	txs    map[string]models.Transaction
+	nonces map[string]uint64
+}
+
+// NewMemoryTxStore creates an empty in-memory transaction store
+func NewMemoryTxStore() *MemoryTxStore {
+	return &MemoryTxStore{txs: make(map[string]models.Transaction), nonces: make(map[string]uint64)}
+}
+
+func (s *MemoryTxStore) SaveTx(ctx context.Context, tx *models.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *tx
+	saved.Attempts = append([]models.TxAttempt(nil), tx.Attempts...)
+	s.txs[tx.ID] = saved
+	return nil
+}
+
+func (s *MemoryTxStore) GetTx(ctx context.Context, id string) (*models.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tx, exists := s.txs[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	tx.Attempts = append([]models.TxAttempt(nil), tx.Attempts...)
+	return &tx, nil
+}
+
+func (s *MemoryTxStore) ListTxs(ctx context.Context, filter TxFilter) ([]models.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	txs := []models.Transaction{}
+	for _, tx := range s.txs {
+		if matches(filter.ChainID, tx.ChainID) && matches(filter.Status, tx.Status) {
+			tx.Attempts = append([]models.TxAttempt(nil), tx.Attempts...)
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].CreatedAt.After(txs[j].CreatedAt) })
+	if filter.Limit > 0 && len(txs) > filter.Limit {
+		txs = txs[:filter.Limit]
+	}
+	return txs, nil
+}
+
+func (s *MemoryTxStore) GetNonce(ctx context.Context, chainID, address string) (uint64, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	nonce, exists := s.nonces[chainID+"/"+address]
+	return nonce, exists, nil
+}
+
+func (s *MemoryTxStore) SetNonce(ctx context.Context, chainID, address string, nonce uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nonces[chainID+"/"+address] = nonce
+	return nil
+}
+
+// MemoryAuditStore is an AuditStore kept in memory
+type MemoryAuditStore struct {
+	mu      sync.RWMutex
+	entries []models.AuditEntry
+}
+
+// NewMemoryAuditStore creates an empty in-memory audit log
+func NewMemoryAuditStore() *MemoryAuditStore {
+	return &MemoryAuditStore{}
+}
+
+func (s *MemoryAuditStore) LastAudit(ctx context.Context) (*models.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.entries) == 0 {
+		return nil, ErrNotFound
+	}
+	entry := s.entries[len(s.entries)-1]
+	return &entry, nil
+}
+
+func (s *MemoryAuditStore) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) > 0 && s.entries[len(s.entries)-1].Sequence >= entry.Sequence {
+		return ErrConflict
+	}
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+
+func (s *MemoryAuditStore) EachAudit(ctx context.Context, filter AuditFilter, fn func(models.AuditEntry) error) error {
+	s.mu.RLock()
+	entries := append([]models.AuditEntry(nil), s.entries...)
+	s.mu.RUnlock()
+
+	matched := 0
+	for _, entry := range entries {
+		if !matches(filter.Action, entry.Action) || !matches(filter.Actor, entry.Actor) ||
+			entry.Sequence <= filter.AfterSequence ||
+			(!filter.From.IsZero() && entry.Time.Before(filter.From)) ||
+			(!filter.To.IsZero() && entry.Time.After(filter.To)) {
+			continue
+		}
+		if filter.Limit > 0 && matched == filter.Limit {
+			break
+		}
+		matched++
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryAlertRuleStore is an AlertRuleStore kept in memory
+type MemoryAlertRuleStore struct {
+	mu    sync.RWMutex
+	rules map[string]models.AlertRule
+}
+
+// NewMemoryAlertRuleStore creates an empty in-memory alert rule store
+func NewMemoryAlertRuleStore() *MemoryAlertRuleStore {
+	return &MemoryAlertRuleStore{rules: make(map[string]models.AlertRule)}
+}
+
+func (s *MemoryAlertRuleStore) CreateRule(ctx context.Context, rule *models.AlertRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rules[rule.ID] = *rule
+	return nil
+}
+
+func (s *MemoryAlertRuleStore) GetRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rule, exists := s.rules[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &rule, nil
+}
+
+func (s *MemoryAlertRuleStore) ListRules(ctx context.Context) ([]models.AlertRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]models.AlertRule, 0, len(s.rules))
+	for _, rule := range s.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules, nil
+}
+
+func (s *MemoryAlertRuleStore) UpdateRule(ctx context.Context, rule *models.AlertRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.rules[rule.ID]; !exists {
+		return ErrNotFound
+	}
+	s.rules[rule.ID] = *rule
+	return nil
+}
+
+func (s *MemoryAlertRuleStore) DeleteRule(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.rules[id]; !exists {
+		return ErrNotFound
+	}
+	delete(s.rules, id)
+	return nil
+}
+
+// MemoryReportStore is a ReportStore that aggregates a MemoryEventStore and keeps reports in memory
+type MemoryReportStore struct {
+	events *MemoryEventStore
+
+	mu      sync.RWMutex
+	reports map[string]models.Report
+}
+
+// NewMemoryReportStore creates an in-memory report store over the given events
+func NewMemoryReportStore(events *MemoryEventStore) *MemoryReportStore {
+	return &MemoryReportStore{events: events, reports: make(map[string]models.Report)}
+}
+
+func (s *MemoryReportStore) TransferStats(ctx context.Context, from, to time.Time) (*TransferStats, error) {
+	type lane struct {
+		stats     LaneStats
+		volume    *big.Int
+		fees      *big.Int
+		users     map[string]bool
+		latencies []float64
+	}
+	lanes := make(map[laneID]*lane)
+	var order []laneID
+	chainLatencies := make(map[string][]float64)
+	var latencies []float64
+
+	for _, sent := range s.events.matching(EventFilter{EventName: "MessageSent", From: from, To: to}) {
+		key := laneID{sent.ChainID, sent.DestinationChainSelector}
+		l, exists := lanes[key]
+		if !exists {
+			l = &lane{
+				stats:  LaneStats{SourceChainID: key.SourceChainID, DestinationChainSelector: key.DestinationChainSelector},
+				volume: new(big.Int),
+				fees:   new(big.Int),
+				users:  make(map[string]bool),
+			}
+			lanes[key] = l
+			order = append(order, key)
+		}
+
+		l.stats.Transfers++
+		if amount, ok := new(big.Int).SetString(sent.Amount, 10); ok {
+			l.volume.Add(l.volume, amount)
+		}
+		if fees, ok := new(big.Int).SetString(sent.Fees, 10); ok {
+			l.fees.Add(l.fees, fees)
+		}
+		if !l.users[sent.CallerAddress] {
+			l.users[sent.CallerAddress] = true
+			l.stats.Users = append(l.stats.Users, sent.CallerAddress)
+		}
+
+		if sent.MessageID == "" {
+			continue
+		}
+		received := s.events.matching(EventFilter{EventName: "MessageReceived", MessageID: sent.MessageID})
+		if len(received) == 0 {
+			continue
+		}
+		l.stats.Delivered++
+		sentAt, sentErr := time.Parse(models.TimeLayout, sent.Timestamp)
+		receivedAt, receivedErr := time.Parse(models.TimeLayout, received[0].Timestamp)
+		if sentErr == nil && receivedErr == nil {
+			latency := receivedAt.Sub(sentAt).Seconds()
+			l.latencies = append(l.latencies, latency)
+			chainLatencies[sent.ChainID] = append(chainLatencies[sent.ChainID], latency)
+			latencies = append(latencies, latency)
+		}
+	}
+
+	stats := &TransferStats{Lanes: make([]LaneStats, 0, len(order)), ChainMedianLatencies: make(map[string]float64)}
+	for _, key := range order {
+		l := lanes[key]
+		l.stats.Volume = l.volume.String()
+		l.stats.Fees = l.fees.String()
+		l.stats.MedianLatency = median(l.latencies)
+		stats.Lanes = append(stats.Lanes, l.stats)
+	}
+	for chainID, values := range chainLatencies {
+		stats.ChainMedianLatencies[chainID] = *median(values)
+	}
+	stats.MedianLatency = median(latencies)
+	return stats, nil
+}
+
+// median returns the median of values, nil if there are none. values is sorted in place.
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+	middle := values[len(values)/2]
+	if len(values)%2 == 0 {
+		middle = (values[len(values)/2-1] + middle) / 2
+	}
+	return &middle
+}
+
+func (s *MemoryReportStore) SaveReport(ctx context.Context, report *models.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports[report.ID] = *report
+	return nil
+}
+
+func (s *MemoryReportStore) GetReport(ctx context.Context, id string) (*models.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report, exists := s.reports[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &report, nil
+}
+
+func (s *MemoryReportStore) ListReports(ctx context.Context, period string, limit int) ([]models.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := []models.Report{}
+	for _, report := range s.reports {
+		if report.Period == period {
+			reports = append(reports, report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Start.After(reports[j].Start)
+	})
+	if len(reports) > limit {
+		reports = reports[:limit]
+	}
+	return reports, nil
+}
+
+// MemoryArchiveStore is an ArchiveStore kept in memory
+type MemoryArchiveStore struct {
+	mu       sync.RWMutex
+	archives []models.Archive
+}
+
+// NewMemoryArchiveStore creates an empty in-memory archive store
+func NewMemoryArchiveStore() *MemoryArchiveStore {
+	return &MemoryArchiveStore{}
+}
+
+func (s *MemoryArchiveStore) AddArchive(ctx context.Context, archive *models.Archive) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.archives = append(s.archives, *archive)
+	return nil
+}
+
+func (s *MemoryArchiveStore) ListArchives(ctx context.Context, filter ArchiveFilter) ([]models.Archive, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	archives := []models.Archive{}
+	for _, archive := range s.archives {
+		if filter.EventName != "" && archive.EventName != "" && archive.EventName != filter.EventName {
+			continue
+		}
+		if (!filter.From.IsZero() && archive.LastEventAt.Before(filter.From)) ||
+			(!filter.To.IsZero() && !archive.FirstEventAt.Before(filter.To)) {
+			continue
+		}
+		archives = append(archives, archive)
+	}
+	sort.SliceStable(archives, func(i, j int) bool {
+		return archives[i].FirstEventAt.Before(archives[j].FirstEventAt)
+	})
+	return archives, nil
+}
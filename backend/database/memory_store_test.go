@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestMemoryUpsertEventKeepsCreatedAt(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	original := models.EventData{ID: "0xabc", EventName: "Mint", ChainID: "80002", TransactionHash: "0xabc", Amount: "1",
+		CreatedAt: "2026-01-05T12:00:00.000000000Z", UpdatedAt: "2026-01-05T12:00:00.000000000Z"}
+	if err := store.UpsertEvent(ctx, &original); err != nil {
+		t.Fatalf("UpsertEvent: %v", err)
+	}
+
+	replayed := original
+	replayed.Amount = "2"
+	replayed.CreatedAt = "2026-02-01T00:00:00.000000000Z"
+	replayed.UpdatedAt = "2026-02-01T00:00:00.000000000Z"
+	if err := store.UpsertEvent(ctx, &replayed); err != nil {
+		t.Fatalf("UpsertEvent: %v", err)
+	}
+
+	events, err := store.FindEvents(ctx, EventFilter{})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("stored %d events, want 1", len(events))
+	}
+	if events[0].Amount != "2" || events[0].UpdatedAt != replayed.UpdatedAt || events[0].CreatedAt != original.CreatedAt {
+		t.Fatalf("stored %+v, want the replayed event with the original created_at", events[0])
+	}
+}
+
+func TestMemoryStuckAlertStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStuckAlertStore()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		messageID string
+		at        time.Time
+		want      bool
+	}{
+		{"0x01", now, true},
+		{"0x01", now.Add(time.Minute), false},
+		{"0x02", now.Add(time.Minute), true},
+		// Records are dropped once they expire, after the lookback window
+		{"0x01", now.Add(25 * time.Hour), true},
+	}
+	for i, step := range steps {
+		first, err := store.MarkAlerted(ctx, step.messageID, step.at, step.at.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("MarkAlerted: %v", err)
+		}
+		if first != step.want {
+			t.Fatalf("step %d: MarkAlerted(%s) = %v, want %v", i, step.messageID, first, step.want)
+		}
+	}
+}
+
+func TestMemoryTransferStatsMedians(t *testing.T) {
+	ctx := context.Background()
+	events := NewMemoryEventStore()
+	sentAt := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+	transfers := []struct {
+		chainID  string
+		selector uint64
+		latency  int
+	}{
+		{"80002", 1, 10},
+		{"80002", 1, 30},
+		{"80002", 2, 50},
+		{"11155111", 3, 100},
+	}
+	for i, transfer := range transfers {
+		messageID := fmt.Sprintf("0x%02d", i)
+		sent := models.EventData{ID: messageID + "s", EventName: "MessageSent", ChainID: transfer.chainID, TransactionHash: messageID + "s",
+			MessageID: messageID, DestinationChainSelector: transfer.selector, CreatedAt: models.FormatTime(sentAt), Timestamp: models.FormatTime(sentAt)}
+		// Both events are stored at once, as a replay would, so only their
+		// block timestamps are apart
+		received := models.EventData{ID: messageID + "r", EventName: "MessageReceived", TransactionHash: messageID + "r", MessageID: messageID,
+			CreatedAt: models.FormatTime(sentAt), Timestamp: models.FormatTime(sentAt.Add(time.Duration(transfer.latency) * time.Second))}
+		for _, event := range []*models.EventData{&sent, &received} {
+			if err := events.InsertEvent(ctx, event); err != nil {
+				t.Fatalf("InsertEvent: %v", err)
+			}
+		}
+	}
+
+	stats, err := NewMemoryReportStore(events).TransferStats(ctx, sentAt.Add(-time.Hour), sentAt.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("TransferStats: %v", err)
+	}
+	wantLanes := map[uint64]float64{1: 20, 2: 50, 3: 100}
+	for _, lane := range stats.Lanes {
+		if lane.MedianLatency == nil || *lane.MedianLatency != wantLanes[lane.DestinationChainSelector] {
+			t.Errorf("lane %d median = %v, want %v", lane.DestinationChainSelector, lane.MedianLatency, wantLanes[lane.DestinationChainSelector])
+		}
+	}
+	// A chain's median covers its transfers, not its lanes' medians
+	if got := stats.ChainMedianLatencies["80002"]; got != 30 {
+		t.Errorf("chain 80002 median = %v, want 30", got)
+	}
+	if stats.MedianLatency == nil || *stats.MedianLatency != 40 {
+		t.Errorf("median = %v, want 40", stats.MedianLatency)
+	}
+}
+
+func TestMemoryEventKeysIncludeLogIndex(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	first := models.EventData{ID: "0xabc", EventName: "Mint", ChainID: "80002", TransactionHash: "0xabc", LogIndex: 3, Amount: "1"}
+	second := first
+	second.LogIndex, second.Amount = 4, "2"
+
+	if err := store.InsertEvent(ctx, &first); err != nil {
+		t.Fatalf("InsertEvent: %v", err)
+	}
+	// A second event of the same name in the transaction is a different event
+	if err := store.InsertEvent(ctx, &second); err != nil {
+		t.Fatalf("InsertEvent of a second log: %v", err)
+	}
+	if err := store.InsertEvent(ctx, &first); !errors.Is(err, ErrConflict) {
+		t.Fatalf("InsertEvent of a stored log err = %v, want ErrConflict", err)
+	}
+
+	replayed := second
+	replayed.Amount = "5"
+	if err := store.UpsertEvent(ctx, &replayed); err != nil {
+		t.Fatalf("UpsertEvent: %v", err)
+	}
+	events, err := store.FindEvents(ctx, EventFilter{})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	amounts := make(map[uint]string)
+	for _, event := range events {
+		amounts[event.LogIndex] = event.Amount
+	}
+	if len(events) != 2 || amounts[3] != "1" || amounts[4] != "5" {
+		t.Fatalf("stored %+v, want log 3 kept and log 4 replaced", events)
+	}
+}
+
+func TestMemoryEventIndexAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	events := make([]models.EventData, 3)
+	for i := range events {
+		events[i] = models.EventData{EventName: "Mint", ChainID: "80002", TransactionHash: fmt.Sprintf("0x%d", i), Amount: "1500"}
+		if err := store.InsertEvent(ctx, &events[i]); err != nil {
+			t.Fatalf("InsertEvent: %v", err)
+		}
+	}
+	if events[0].EventKey != events[0].Key() || events[0].AmountValue == nil {
+		t.Fatalf("InsertEvent left EventKey %q and AmountValue %v unset", events[0].EventKey, events[0].AmountValue)
+	}
+
+	deleted, err := store.DeleteEvents(ctx, EventFilter{}, []string{events[0].EventKey})
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteEvents = %d, %v, want 1", deleted, err)
+	}
+	// The remaining events moved, so upserts must still find them by key
+	replayed := events[2]
+	replayed.Amount = "2500"
+	if err := store.UpsertEvent(ctx, &replayed); err != nil {
+		t.Fatalf("UpsertEvent: %v", err)
+	}
+	if err := store.InsertEvent(ctx, &events[1]); !errors.Is(err, ErrConflict) {
+		t.Fatalf("InsertEvent of a stored event err = %v, want ErrConflict", err)
+	}
+	if err := store.InsertEvent(ctx, &events[0]); err != nil {
+		t.Fatalf("InsertEvent of a deleted event: %v", err)
+	}
+
+	stored, err := store.FindEvents(ctx, EventFilter{TransactionHash: "0x2"})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(stored) != 1 || stored[0].Amount != "2500" {
+		t.Fatalf("stored %+v, want the replayed amount", stored)
+	}
+	all, _ := store.FindEvents(ctx, EventFilter{})
+	if len(all) != 3 {
+		t.Fatalf("stored %d events, want 3", len(all))
+	}
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrMigrationLocked is returned when another process is running migrations
+var ErrMigrationLocked = errors.New("migrations are locked by another process")
+
+// migrationLockID is the schema_migrations document held while migrations run
+const migrationLockID = "_lock"
+
+// staleMigrationLock is how old a lock may get before it is assumed abandoned
+const staleMigrationLock = 30 * time.Minute
+
+// Migration moves the stored data from one schema version to the next. Up
+// applies it and Down reverts it. Both return how many documents they changed,
+// or with dryRun set, how many they would change without changing any.
+type Migration struct {
+	// ID orders the migrations and is recorded once applied, e.g. "0001_chain_id_field"
+	ID          string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error)
+	// Down is nil for migrations that can't be reverted
+	Down func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error)
+}
+
+// AppliedMigration records a migration in the schema_migrations collection
+type AppliedMigration struct {
+	ID          string    `json:"id" bson:"_id"`
+	Description string    `json:"description" bson:"description"`
+	AppliedAt   time.Time `json:"applied_at" bson:"applied_at"`
+	Documents   int64     `json:"documents" bson:"documents"`
+	DurationMS  int64     `json:"duration_ms" bson:"duration_ms"`
+}
+
+// MigrationStatus tells whether a known migration has been applied
+type MigrationStatus struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	Reversible  bool       `json:"reversible"`
+}
+
+// MigrationResult is the outcome of applying or reverting one migration
+type MigrationResult struct {
+	ID        string `json:"id"`
+	Direction string `json:"direction"`
+	Documents int64  `json:"documents"`
+	DryRun    bool   `json:"dry_run"`
+}
+
+// Migrator applies migrations in ID order and records them in schema_migrations
+type Migrator struct {
+	db         *mongo.Database
+	records    *mongo.Collection
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the given migrations, which must be in ID order
+func NewMigrator(db *mongo.Database, migrations []Migration) *Migrator {
+	return &Migrator{db: db, records: db.Collection("schema_migrations"), migrations: migrations}
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{ID: migration.ID, Description: migration.Description, Reversible: migration.Down != nil}
+		if record, exists := applied[migration.ID]; exists {
+			status.Applied = true
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in order, stopping at the first failure
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]MigrationResult, error) {
+	release, err := m.lock(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []MigrationResult{}
+	for _, migration := range m.migrations {
+		if _, exists := applied[migration.ID]; exists {
+			continue
+		}
+
+		start := time.Now()
+		documents, err := migration.Up(ctx, m.db, dryRun)
+		if err != nil {
+			return results, fmt.Errorf("migration %s failed: %v", migration.ID, err)
+		}
+		results = append(results, MigrationResult{ID: migration.ID, Direction: "up", Documents: documents, DryRun: dryRun})
+		if dryRun {
+			log.Printf("Migration %s would change %d documents", migration.ID, documents)
+			continue
+		}
+
+		record := AppliedMigration{
+			ID:          migration.ID,
+			Description: migration.Description,
+			AppliedAt:   time.Now().UTC(),
+			Documents:   documents,
+			DurationMS:  time.Since(start).Milliseconds(),
+		}
+		if _, err := m.records.InsertOne(ctx, record); err != nil {
+			return results, fmt.Errorf("migration %s applied but not recorded: %v", migration.ID, err)
+		}
+		log.Printf("Applied migration %s, changing %d documents", migration.ID, documents)
+	}
+	return results, nil
+}
+
+// Down reverts the last steps applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, steps int, dryRun bool) ([]MigrationResult, error) {
+	release, err := m.lock(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []MigrationResult{}
+	for i := len(m.migrations) - 1; i >= 0 && len(results) < steps; i-- {
+		migration := m.migrations[i]
+		if _, exists := applied[migration.ID]; !exists {
+			continue
+		}
+		if migration.Down == nil {
+			return results, fmt.Errorf("migration %s can't be reverted", migration.ID)
+		}
+
+		documents, err := migration.Down(ctx, m.db, dryRun)
+		if err != nil {
+			return results, fmt.Errorf("reverting migration %s failed: %v", migration.ID, err)
+		}
+		results = append(results, MigrationResult{ID: migration.ID, Direction: "down", Documents: documents, DryRun: dryRun})
+		if dryRun {
+			log.Printf("Reverting migration %s would change %d documents", migration.ID, documents)
+			continue
+		}
+
+		if _, err := m.records.DeleteOne(ctx, bson.M{"_id": migration.ID}); err != nil {
+			return results, fmt.Errorf("migration %s reverted but still recorded: %v", migration.ID, err)
+		}
+		log.Printf("Reverted migration %s, changing %d documents", migration.ID, documents)
+	}
+	return results, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[string]AppliedMigration, error) {
+	cursor, err := m.records.Find(ctx, bson.M{"_id": bson.M{"$ne": migrationLockID}})
+	if err != nil {
+		return nil, err
+	}
+	var records []AppliedMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]AppliedMigration, len(records))
+	for _, record := range records {
+		applied[record.ID] = record
+	}
+	return applied, nil
+}
+
+// lock stops two processes migrating at once. Dry runs change nothing, so
+// they don't take the lock. A lock older than staleMigrationLock is taken over.
+func (m *Migrator) lock(ctx context.Context, dryRun bool) (func(), error) {
+	if dryRun {
+		return func() {}, nil
+	}
+
+	now := time.Now().UTC()
+	_, err := m.records.DeleteOne(ctx, bson.M{"_id": migrationLockID, "locked_at": bson.M{"$lt": now.Add(-staleMigrationLock)}})
+	if err != nil {
+		return nil, err
+	}
+	_, err = m.records.InsertOne(ctx, bson.M{"_id": migrationLockID, "locked_at": now})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrMigrationLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if _, err := m.records.DeleteOne(context.Background(), bson.M{"_id": migrationLockID}); err != nil {
+			log.Printf("Error releasing migration lock: %v", err)
+		}
+	}, nil
+}
+
+// updateMigration is a migration step that updates every document matching
+// filter; a dry run counts them instead
+func updateMigration(collection string, filter bson.M, update interface{}) func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+	return func(ctx context.Context, db *mongo.Database, dryRun bool) (int64, error) {
+		if dryRun {
+			return db.Collection(collection).CountDocuments(ctx, filter)
+		}
+		result, err := db.Collection(collection).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	}
+}
@@ -44,3 +44,11 @@ func ConnectToMongoDB() {
 func GetDatabase() *mongo.Database {
 	return Client.Database("go_ccip_server")
 }
+
+// GetTenantDatabase returns a tenant's database; an empty name is the main database
+func GetTenantDatabase(name string) *mongo.Database {
+	if name == "" {
+		return GetDatabase()
+	}
+	return Client.Database(name)
+}
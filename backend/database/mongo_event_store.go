@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// chainIDField is the bson key models.EventData uses for the chain ID
+const chainIDField = "chain_id"
+
+// MongoEventStore stores events in the events collection
+type MongoEventStore struct {
+	db     *mongo.Database
+	events *mongo.Collection
+}
+
+// NewMongoEventStore creates an event store backed by the given database
+func NewMongoEventStore(db *mongo.Database) *MongoEventStore {
+	return &MongoEventStore{db: db, events: db.Collection("events")}
+}
+
+func (s *MongoEventStore) InsertEvent(ctx context.Context, event *models.EventData) error {
+	event.SetAmountValues()
+	event.EventKey = event.Key()
+	_, err := s.events.InsertOne(ctx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *MongoEventStore) UpsertEvent(ctx context.Context, event *models.EventData) error {
+	event.SetAmountValues()
+	event.EventKey = event.Key()
+
+	encoded, err := bson.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(encoded, &fields); err != nil {
+		return err
+	}
+	delete(fields, "created_at")
+	_, err = s.events.UpdateOne(ctx,
+		bson.M{"event_key": event.EventKey},
+		bson.M{"$set": fields, "$setOnInsert": bson.M{"created_at": event.CreatedAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *MongoEventStore) FindEvents(ctx context.Context, filter EventFilter) ([]models.EventData, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(filter.Offset)).
+		SetLimit(int64(filter.limit()))
+
+	cursor, err := s.events.Find(ctx, mongoEventFilter(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []models.EventData{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *MongoEventStore) EachEvent(ctx context.Context, filter EventFilter, fn func(models.EventData) error) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64(filter.Offset)).
+		SetLimit(int64(filter.Limit))
+
+	cursor, err := s.events.Find(ctx, mongoEventFilter(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.EventData
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (s *MongoEventStore) LatestEventByAddress(ctx context.Context, callerAddress, eventName string) (*models.EventData, error) {
+	filter := bson.M{"caller_address": callerAddress}
+	if eventName != "" {
+		filter["event_name"] = eventName
+	}
+
+	var event models.EventData
+	err := s.events.FindOne(ctx, filter, options.FindOne().SetSort(bson.M{"timestamp": -1})).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *MongoEventStore) AggregateEvents(ctx context.Context, filter EventFilter) ([]EventAggregate, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: mongoEventFilter(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"event_name": "$event_name", "chain_id": "$" + chainIDField},
+			"count": bson.M{"$sum": 1},
+			"total": bson.M{"$sum": mongoAmount("$amount_value", "$amount")},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.event_name", Value: 1}, {Key: "_id.chain_id", Value: 1}}}},
+	}
+
+	cursor, err := s.events.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ID struct {
+			EventName string `bson:"event_name"`
+			ChainID   string `bson:"chain_id"`
+		} `bson:"_id"`
+		Count int64                `bson:"count"`
+		Total primitive.Decimal128 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	aggregates := make([]EventAggregate, 0, len(rows))
+	for _, row := range rows {
+		aggregates = append(aggregates, EventAggregate{
+			EventName:   row.ID.EventName,
+			ChainID:     row.ID.ChainID,
+			Count:       row.Count,
+			TotalAmount: row.Total.String(),
+		})
+	}
+	return aggregates, nil
+}
+
+func (s *MongoEventStore) DeleteEvents(ctx context.Context, filter EventFilter, keys []string) (int64, error) {
+	query := mongoEventFilter(filter)
+	query["event_key"] = bson.M{"$in": keys}
+	result, err := s.events.DeleteMany(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (s *MongoEventStore) Ping(ctx context.Context) error {
+	return s.db.Client().Ping(ctx, readpref.Primary())
+}
+
+// mongoEventFilter converts an EventFilter into a query document
+func mongoEventFilter(filter EventFilter) bson.M {
+	query := bson.M{}
+	fields := map[string]string{
+		"event_name":       filter.EventName,
+		chainIDField:       filter.ChainID,
+		"caller_address":   filter.CallerAddress,
+		"contract_address": filter.ContractAddress,
+		"to_from_user":     filter.ToFromUser,
+		"message_id":       filter.MessageID,
+		"transaction_hash": filter.TransactionHash,
+		"run_id":           filter.RunID,
+	}
+	for field, value := range fields {
+		if value != "" {
+			query[field] = value
+		}
+	}
+
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = models.FormatTime(filter.From)
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = models.FormatTime(filter.To)
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	amount := bson.M{}
+	if filter.MinAmount != nil {
+		amount["$gte"] = models.DecimalAmount(filter.MinAmount.String())
+	}
+	if filter.MaxAmount != nil {
+		amount["$lte"] = models.DecimalAmount(filter.MaxAmount.String())
+	}
+	if len(amount) > 0 {
+		query["amount_value"] = amount
+	}
+	return query
+}
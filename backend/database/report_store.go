@@ -0,0 +1,277 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LaneStats aggregates the transfers sent on one lane, keyed by the source
+// chain and the destination's CCIP chain selector
+type LaneStats struct {
+	SourceChainID            string
+	DestinationChainSelector uint64
+	Transfers                int64
+	Delivered                int64
+	Volume                   string
+	Fees                     string
+	// Users are the distinct senders
+	Users []string
+	// MedianLatency is the median sent-to-received time in seconds of the
+	// delivered transfers whose timestamps could be read, nil if there are none
+	MedianLatency *float64
+}
+
+// TransferStats aggregates the transfers sent during a period. Medians can't
+// be combined, so the source chains' and the period's are computed separately.
+type TransferStats struct {
+	Lanes []LaneStats
+	// ChainMedianLatencies holds the median latency of each source chain with a measured transfer
+	ChainMedianLatencies map[string]float64
+	// MedianLatency covers every lane, nil if no transfer could be measured
+	MedianLatency *float64
+}
+
+// ReportStore aggregates transfers from the stored events and keeps the reports built from them
+type ReportStore interface {
+	// TransferStats aggregates the MessageSent events created in [from, to) per lane
+	TransferStats(ctx context.Context, from, to time.Time) (*TransferStats, error)
+	// SaveReport inserts the report or replaces the one with the same ID
+	SaveReport(ctx context.Context, report *models.Report) error
+	// GetReport returns ErrNotFound if there is no report with the ID
+	GetReport(ctx context.Context, id string) (*models.Report, error)
+	// ListReports returns a period's reports, newest first
+	ListReports(ctx context.Context, period string, limit int) ([]models.Report, error)
+}
+
+// MongoReportStore aggregates the events collection and stores reports in the reports collection
+type MongoReportStore struct {
+	events  *mongo.Collection
+	reports *mongo.Collection
+}
+
+// NewMongoReportStore creates a report store backed by the given database
+func NewMongoReportStore(db *mongo.Database) *MongoReportStore {
+	return &MongoReportStore{events: db.Collection("events"), reports: db.Collection("reports")}
+}
+
+// laneID is the group key of laneKey
+type laneID struct {
+	SourceChainID            string `bson:"source_chain_id"`
+	DestinationChainSelector uint64 `bson:"destination_chain_selector"`
+}
+
+// laneKey groups MessageSent events by lane
+var laneKey = bson.M{"source_chain_id": "$" + chainIDField, "destination_chain_selector": "$destination_chain_selector"}
+
+func (s *MongoReportStore) TransferStats(ctx context.Context, from, to time.Time) (*TransferStats, error) {
+	match := bson.D{{Key: "$match", Value: mongoEventFilter(EventFilter{EventName: "MessageSent", From: from, To: to})}}
+
+	volumes := mongo.Pipeline{
+		match,
+		{{Key: "$group", Value: bson.M{
+			"_id":       laneKey,
+			"transfers": bson.M{"$sum": 1},
+			"volume":    bson.M{"$sum": mongoAmount("$amount_value", "$amount")},
+			"fees":      bson.M{"$sum": mongoAmount("$fees_value", "$fees")},
+			"users":     bson.M{"$addToSet": "$caller_address"},
+		}}},
+	}
+	var volumeRows []struct {
+		ID        laneID               `bson:"_id"`
+		Transfers int64                `bson:"transfers"`
+		Volume    primitive.Decimal128 `bson:"volume"`
+		Fees      primitive.Decimal128 `bson:"fees"`
+		Users     []string             `bson:"users"`
+	}
+	if err := aggregateAll(ctx, s.events, volumes, &volumeRows); err != nil {
+		return nil, err
+	}
+
+	// Delivered transfers are joined to their MessageReceived by message ID.
+	// Latency is measured between the two events' block timestamps, since
+	// created_at is when the backend stored them. timestamp has a fixed
+	// layout, so its first 23 bytes are an ISO 8601 time to the millisecond
+	// that $dateFromString can read. The medians are
+	// picked inside the pipeline so no latency list is returned.
+	latencies := mongo.Pipeline{
+		match,
+		{{Key: "$lookup", Value: bson.M{
+			"from":         s.events.Name(),
+			"localField":   "message_id",
+			"foreignField": "message_id",
+			"as":           "matches",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"lane":    laneKey,
+			"sent_at": mongoTime("$timestamp"),
+			"received": bson.M{"$arrayElemAt": bson.A{bson.M{"$filter": bson.M{
+				"input": "$matches",
+				"cond":  bson.M{"$eq": bson.A{"$$this.event_name", "MessageReceived"}},
+			}}, 0}},
+		}}},
+		{{Key: "$match", Value: bson.M{"received": bson.M{"$exists": true}}}},
+		{{Key: "$project", Value: bson.M{
+			"lane": 1,
+			"latency": bson.M{"$divide": bson.A{
+				bson.M{"$subtract": bson.A{mongoTime("$received.timestamp"), "$sent_at"}},
+				1000,
+			}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"delivered": bson.A{bson.M{"$group": bson.M{"_id": "$lane", "delivered": bson.M{"$sum": 1}}}},
+			"lanes":     mongoMedian("$lane"),
+			"chains":    mongoMedian("$lane.source_chain_id"),
+			"total":     mongoMedian(nil),
+		}}},
+	}
+	var latencyRows []struct {
+		Delivered []struct {
+			ID        laneID `bson:"_id"`
+			Delivered int64  `bson:"delivered"`
+		} `bson:"delivered"`
+		Lanes []struct {
+			ID     laneID  `bson:"_id"`
+			Median float64 `bson:"median"`
+		} `bson:"lanes"`
+		Chains []struct {
+			ID     string  `bson:"_id"`
+			Median float64 `bson:"median"`
+		} `bson:"chains"`
+		Total []struct {
+			Median float64 `bson:"median"`
+		} `bson:"total"`
+	}
+	if err := aggregateAll(ctx, s.events, latencies, &latencyRows); err != nil {
+		return nil, err
+	}
+
+	stats := &TransferStats{Lanes: make([]LaneStats, 0, len(volumeRows)), ChainMedianLatencies: make(map[string]float64)}
+	byLane := make(map[laneID]*LaneStats)
+	for _, row := range volumeRows {
+		stats.Lanes = append(stats.Lanes, LaneStats{
+			SourceChainID:            row.ID.SourceChainID,
+			DestinationChainSelector: row.ID.DestinationChainSelector,
+			Transfers:                row.Transfers,
+			Volume:                   row.Volume.String(),
+			Fees:                     row.Fees.String(),
+			Users:                    row.Users,
+		})
+	}
+	for i := range stats.Lanes {
+		lane := &stats.Lanes[i]
+		byLane[laneID{lane.SourceChainID, lane.DestinationChainSelector}] = lane
+	}
+	if len(latencyRows) == 0 {
+		return stats, nil
+	}
+	row := latencyRows[0]
+	for _, delivered := range row.Delivered {
+		if lane, exists := byLane[delivered.ID]; exists {
+			lane.Delivered = delivered.Delivered
+		}
+	}
+	for _, median := range row.Lanes {
+		if lane, exists := byLane[median.ID]; exists {
+			lane.MedianLatency = &median.Median
+		}
+	}
+	for _, median := range row.Chains {
+		stats.ChainMedianLatencies[median.ID] = median.Median
+	}
+	if len(row.Total) > 0 {
+		stats.MedianLatency = &row.Total[0].Median
+	}
+	return stats, nil
+}
+
+func (s *MongoReportStore) SaveReport(ctx context.Context, report *models.Report) error {
+	_, err := s.reports.ReplaceOne(ctx, bson.M{"_id": report.ID}, report, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoReportStore) GetReport(ctx context.Context, id string) (*models.Report, error) {
+	var report models.Report
+	err := s.reports.FindOne(ctx, bson.M{"_id": id}).Decode(&report)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (s *MongoReportStore) ListReports(ctx context.Context, period string, limit int) ([]models.Report, error) {
+	opts := options.Find().SetSort(bson.M{"start": -1}).SetLimit(int64(limit))
+	cursor, err := s.reports.Find(ctx, bson.M{"period": period}, opts)
+	if err != nil {
+		return nil, err
+	}
+	reports := []models.Report{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func aggregateAll(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+// mongoAmount reads an amount's Decimal128 value, converting the raw string
+// for events stored without one and treating malformed amounts as zero
+func mongoAmount(valueField, rawField string) bson.M {
+	zero := primitive.NewDecimal128(0, 0)
+	return bson.M{"$ifNull": bson.A{
+		valueField,
+		bson.M{"$convert": bson.M{"input": rawField, "to": "decimal", "onError": zero, "onNull": zero}},
+	}}
+}
+
+// mongoMedian returns $facet stages giving the median latency of each group
+// of documents with the given $group key, or of all of them when the key is
+// nil. Each latency is ranked within its group and only the middle one or two
+// are averaged, so the latencies are never gathered into one document.
+// Unreadable timestamps give a null latency, which is left out.
+func mongoMedian(key interface{}) bson.A {
+	window := bson.M{
+		"sortBy": bson.M{"latency": 1},
+		"output": bson.M{
+			"rank":  bson.M{"$documentNumber": bson.M{}},
+			"count": bson.M{"$count": bson.M{}, "window": bson.M{"documents": bson.A{"unbounded", "unbounded"}}},
+		},
+	}
+	if key != nil {
+		window["partitionBy"] = key
+	}
+	middle := bson.A{
+		bson.M{"$floor": bson.M{"$divide": bson.A{bson.M{"$add": bson.A{"$count", 1}}, 2}}},
+		bson.M{"$add": bson.A{bson.M{"$floor": bson.M{"$divide": bson.A{"$count", 2}}}, 1}},
+	}
+	return bson.A{
+		bson.M{"$match": bson.M{"latency": bson.M{"$ne": nil}}},
+		bson.M{"$setWindowFields": window},
+		bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$rank", middle}}}},
+		bson.M{"$group": bson.M{"_id": key, "median": bson.M{"$avg": "$latency"}}},
+	}
+}
+
+// mongoTime parses a timestamp, created_at or updated_at string, giving null if it is unreadable
+func mongoTime(field string) bson.M {
+	return bson.M{"$dateFromString": bson.M{
+		"dateString": bson.M{"$concat": bson.A{bson.M{"$substrBytes": bson.A{field, 0, 23}}, "Z"}},
+		"onError":    nil,
+		"onNull":     nil,
+	}}
+}
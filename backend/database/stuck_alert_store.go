@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StuckAlertStore remembers which stuck transfers have been alerted on, so
+// alerts are not repeated after a restart or by another server
+type StuckAlertStore interface {
+	// MarkAlerted records the alert for a message and returns false if one was
+	// already recorded. The record can be dropped once expiresAt has passed.
+	MarkAlerted(ctx context.Context, messageID string, at, expiresAt time.Time) (bool, error)
+}
+
+// MongoStuckAlertStore records alerted messages in the stuck_transfer_alerts
+// collection, keyed by message ID. A TTL index drops them once the detector's
+// lookback window has passed.
+type MongoStuckAlertStore struct {
+	alerts *mongo.Collection
+}
+
+// NewMongoStuckAlertStore creates a stuck alert store backed by the given database
+func NewMongoStuckAlertStore(db *mongo.Database) *MongoStuckAlertStore {
+	return &MongoStuckAlertStore{alerts: db.Collection("stuck_transfer_alerts")}
+}
+
+func (s *MongoStuckAlertStore) MarkAlerted(ctx context.Context, messageID string, at, expiresAt time.Time) (bool, error) {
+	_, err := s.alerts.InsertOne(ctx, bson.M{"_id": messageID, "alerted_at": at, "expires_at": expiresAt})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TxFilter selects transactions. Empty fields are ignored.
+type TxFilter struct {
+	ChainID string
+	Status  string
+	Limit   int
+}
+
+// TxStore persists the transaction engine's transactions and signer nonces
+type TxStore interface {
+	// SaveTx inserts or replaces a transaction
+	SaveTx(ctx context.Context, tx *models.Transaction) error
+	// GetTx returns ErrNotFound if there is no such transaction
+	GetTx(ctx context.Context, id string) (*models.Transaction, error)
+	// ListTxs returns matching transactions, newest first
+	ListTxs(ctx context.Context, filter TxFilter) ([]models.Transaction, error)
+	// GetNonce returns the next nonce recorded for a signer, or false if none is
+	GetNonce(ctx context.Context, chainID, address string) (uint64, bool, error)
+	// SetNonce records the next nonce for a signer
+	SetNonce(ctx context.Context, chainID, address string, nonce uint64) error
+}
+
+// MongoTxStore stores transactions in the transactions collection and signer
+// nonces in tx_nonces
+type MongoTxStore struct {
+	txs    *mongo.Collection
+	nonces *mongo.Collection
+}
+
+// NewMongoTxStore creates a transaction store backed by the given database
+func NewMongoTxStore(db *mongo.Database) *MongoTxStore {
+	return &MongoTxStore{txs: db.Collection("transactions"), nonces: db.Collection("tx_nonces")}
+}
+
+func (s *MongoTxStore) SaveTx(ctx context.Context, tx *models.Transaction) error {
+	_, err := s.txs.ReplaceOne(ctx, bson.M{"_id": tx.ID}, tx, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoTxStore) GetTx(ctx context.Context, id string) (*models.Transaction, error) {
+	var tx models.Transaction
+	err := s.txs.FindOne(ctx, bson.M{"_id": id}).Decode(&tx)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (s *MongoTxStore) ListTxs(ctx context.Context, filter TxFilter) ([]models.Transaction, error) {
+	query := bson.M{}
+	if filter.ChainID != "" {
+		query["chain_id"] = filter.ChainID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := s.txs.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	txs := []models.Transaction{}
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+func (s *MongoTxStore) GetNonce(ctx context.Context, chainID, address string) (uint64, bool, error) {
+	var doc struct {
+		Nonce uint64 `bson:"nonce"`
+	}
+	err := s.nonces.FindOne(ctx, bson.M{"_id": chainID + "/" + address}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return doc.Nonce, true, nil
+}
+
+func (s *MongoTxStore) SetNonce(ctx context.Context, chainID, address string, nonce uint64) error {
+	_, err := s.nonces.UpdateOne(ctx,
+		bson.M{"_id": chainID + "/" + address},
+		bson.M{"$set": bson.M{"chain_id": chainID, "address": address, "nonce": nonce}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
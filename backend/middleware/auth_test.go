@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/database"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticateBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := &services.AuthService{Keys: database.NewMemoryAPIKeyStore(), Nonces: database.NewMemoryNonceStore()}
+
+	tests := []struct {
+		name string
+		size int
+		want int
+	}{
+		{"within limit", MaxBodyBytes, http.StatusUnauthorized},
+		{"over limit", MaxBodyBytes + 1, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/events/mint", strings.NewReader(strings.Repeat("x", tt.size)))
+			c.Request.Header.Set(services.HeaderAPIKey, "ak_unknown.secret")
+			if _, ok := authenticate(c, auth); ok {
+				t.Fatal("authenticate accepted an unknown key")
+			}
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
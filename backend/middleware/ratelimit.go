@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitByIP runs before authentication. Anonymous requests are limited per
+// client IP. Requests carrying credentials are rejected while the IP has too
+// many failed authentications, and each new failure counts against it.
+func RateLimitByIP(limiter *services.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter.Disabled() {
+			c.Next()
+			return
+		}
+
+		if !hasCredentials(c.Request) {
+			route := c.Request.Method + " " + apiRoute(c.FullPath())
+			if applyDecision(c, limiter.Allow(route, c.ClientIP(), "")) {
+				c.Next()
+			}
+			return
+		}
+
+		decision := limiter.AllowCredentials(c.ClientIP())
+		if !decision.Allowed {
+			abortLimited(c, decision, "Too many failed authentications")
+			return
+		}
+		c.Next()
+		if c.Writer.Status() == http.StatusUnauthorized {
+			limiter.RecordAuthFailure(c.ClientIP())
+		}
+	}
+}
+
+// RateLimitByKey runs after authentication and limits requests per API key
+func RateLimitByKey(limiter *services.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := CurrentAPIKey(c)
+		if limiter.Disabled() || key == nil {
+			c.Next()
+			return
+		}
+
+		route := c.Request.Method + " " + apiRoute(c.FullPath())
+		if applyDecision(c, limiter.Allow(route, c.ClientIP(), key.ID)) {
+			c.Next()
+		}
+	}
+}
+
+// applyDecision sets the rate limit headers and aborts the request when it was
+// not allowed. It reports whether the request may continue.
+func applyDecision(c *gin.Context, decision services.RateDecision) bool {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit.Burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	if decision.Allowed {
+		return true
+	}
+
+	message := "Rate limit exceeded"
+	if decision.QuotaExceeded {
+		message = "Daily quota exceeded"
+	}
+	abortLimited(c, decision, message)
+	return false
+}
+
+func abortLimited(c *gin.Context, decision services.RateDecision, message string) {
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":               message,
+		"retry_after_seconds": retryAfter,
+	})
+}
+
+// apiRoute maps a tenant's route pattern to the matching /api pattern, e.g.
+// "/api/tenants/acme/events" to "/api/events", so per-route limits apply to every tenant
+func apiRoute(fullPath string) string {
+	rest, found := strings.CutPrefix(fullPath, "/api/tenants/")
+	if !found {
+		return fullPath
+	}
+	if _, route, found := strings.Cut(rest, "/"); found {
+		return "/api/" + route
+	}
+	return "/api"
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Rule condition operators. gt, gte, lt and lte compare numerically.
+const (
+	OpEq  = "eq"
+	OpNe  = "ne"
+	OpGt  = "gt"
+	OpGte = "gte"
+	OpLt  = "lt"
+	OpLte = "lte"
+)
+
+// AlertRule fires when a decoded event matches it. Without a window every
+// matching event fires; with one, the rule fires when more than Count
+// matching events arrive within WindowSeconds, counted per GroupBy value.
+type AlertRule struct {
+	ID         string          `json:"id" bson:"_id"`
+	Name       string          `json:"name" bson:"name"`
+	Disabled   bool            `json:"disabled" bson:"disabled"`
+	EventName  string          `json:"event_name" bson:"event_name"`
+	ChainID    string          `json:"chain_id,omitempty" bson:"chain_id,omitempty"`
+	Conditions []RuleCondition `json:"conditions,omitempty" bson:"conditions,omitempty"`
+	Count      int             `json:"count,omitempty" bson:"count,omitempty"`
+	// WindowSeconds of 0 makes every matching event fire
+	WindowSeconds int `json:"window_seconds,omitempty" bson:"window_seconds,omitempty"`
+	// GroupBy is an event field, such as caller_address, counted separately per value
+	GroupBy  string `json:"group_by,omitempty" bson:"group_by,omitempty"`
+	Severity string `json:"severity" bson:"severity"`
+	// Notifiers names the configured notifiers to deliver to; empty means all
+	Notifiers []string `json:"notifiers,omitempty" bson:"notifiers,omitempty"`
+	// CooldownSeconds suppresses repeat firings for the same group
+	CooldownSeconds int       `json:"cooldown_seconds,omitempty" bson:"cooldown_seconds,omitempty"`
+	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// RuleCondition compares an event field, named as in the event JSON, with a value.
+// Numeric values may use exponents, e.g. "1000e18" for 1000 tokens in wei.
+type RuleCondition struct {
+	Field string `json:"field" bson:"field"`
+	Op    string `json:"op" bson:"op"`
+	Value string `json:"value" bson:"value"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// API key scopes
+const (
+	ScopeIngest = "ingest"
+	ScopeRead   = "read"
+	ScopeAdmin  = "admin"
+)
+
+// APIKey is a credential allowed to call protected endpoints. Only the SHA-256
+// hash of the secret is stored; the secret itself is returned once at creation.
+type APIKey struct {
+	ID      string `json:"id" bson:"_id"`
+	Name    string `json:"name" bson:"name"`
+	KeyHash string `json:"-" bson:"key_hash"`
+	// SigningKey is the key request signatures are checked with, encrypted
+	// with the server's signing key encryption key
+	SigningKey string     `json:"-" bson:"signing_key,omitempty"`
+	Scopes     []string   `json:"scopes" bson:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants the given scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key has not been revoked
+func (k *APIKey) Active() bool {
+	return k.RevokedAt == nil
+}
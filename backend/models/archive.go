@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Archive records one archive file of events moved out of MongoDB. The file
+// holds the events as gzip-compressed NDJSON, oldest first.
+type Archive struct {
+	// ID is the file's object name in the archive storage
+	ID string `json:"id" bson:"_id"`
+	// EventName is the event type archived by the policy, empty for every event type
+	EventName string `json:"event_name,omitempty" bson:"event_name,omitempty"`
+	// FirstEventAt and LastEventAt are the created_at of the oldest and newest archived event
+	FirstEventAt time.Time `json:"first_event_at" bson:"first_event_at"`
+	LastEventAt  time.Time `json:"last_event_at" bson:"last_event_at"`
+	Events       int64     `json:"events" bson:"events"`
+	Bytes        int64     `json:"bytes" bson:"bytes"`
+	// SHA256 is the hex digest of the compressed file
+	SHA256     string    `json:"sha256" bson:"sha256"`
+	ArchivedAt time.Time `json:"archived_at" bson:"archived_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditContractOperation = "contract.operation"
+	AuditConfigReload      = "config.reload"
+	AuditAPIKeyCreate      = "api_key.create"
+	AuditAPIKeyRevoke      = "api_key.revoke"
+	AuditEventReplay       = "events.replay"
+	AuditEventArchive      = "events.archive"
+	AuditEventRestore      = "events.restore"
+	AuditAlertRuleCreate   = "alert_rule.create"
+	AuditAlertRuleUpdate   = "alert_rule.update"
+	AuditAlertRuleDelete   = "alert_rule.delete"
+)
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry records one privileged action. Entries form a hash chain: each
+// entry's hash covers its own fields and the previous entry's hash, so
+// editing or removing an entry breaks every hash after it.
+type AuditEntry struct {
+	Sequence uint64    `json:"sequence" bson:"_id"`
+	Time     time.Time `json:"time" bson:"time"`
+	Action   string    `json:"action" bson:"action"`
+	// Actor is the API key that performed the action, or "anonymous" when auth is disabled
+	Actor    string `json:"actor" bson:"actor"`
+	ClientIP string `json:"client_ip,omitempty" bson:"client_ip,omitempty"`
+	// Target names what the action changed, e.g. "80002/Router.allowlistSender"
+	Target string `json:"target,omitempty" bson:"target,omitempty"`
+	// Payload is the request as JSON, stored verbatim so the hash stays reproducible
+	Payload         json.RawMessage `json:"payload,omitempty" bson:"payload,omitempty"`
+	TransactionHash string          `json:"transaction_hash,omitempty" bson:"transaction_hash,omitempty"`
+	Outcome         string          `json:"outcome" bson:"outcome"`
+	Error           string          `json:"error,omitempty" bson:"error,omitempty"`
+	PrevHash        string          `json:"prev_hash" bson:"prev_hash"`
+	Hash            string          `json:"hash" bson:"hash"`
+}
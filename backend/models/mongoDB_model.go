@@ -1,12 +1,14 @@
 package models
 
 import (
+	"strconv"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type EventData struct {
 	ID               string    `json:"id" bson:"id"`
-	ChainID               string    `json:"ChainId" bson:"ChainId"`
+	ChainID               string    `json:"ChainId" bson:"chain_id"`
 	ContractAddress  string    `json:"contract_address" bson:"contract_address"`
 	EventName        string    `json:"event_name" bson:"event_name"`
 	CallerAddress    string    `json:"caller_address" bson:"caller_address"`
@@ -29,4 +31,41 @@ type EventData struct {
 	 // Additional fields for MessageReceived event
 	 SourceChainSelector      uint64    `bson:"source_chain_selector,omitempty" json:"source_chain_selector,omitempty"`
 	 Sender                   string    `bson:"sender,omitempty" json:"sender,omitempty"`
+	// RunID tags events generated by a load test run so they can be verified afterwards
+	RunID                    string    `bson:"run_id,omitempty" json:"run_id,omitempty"`
+	// LogIndex is the log's position in its block, which tells apart events of
+	// the same name emitted by one transaction
+	LogIndex                 uint      `bson:"log_index" json:"log_index"`
+	// EventKey identifies the event as "<chain ID>:<transaction hash>:<event name>:<log index>"
+	EventKey                 string    `bson:"event_key,omitempty" json:"-"`
+	// AmountValue and FeesValue hold Amount and Fees as Decimal128 so MongoDB can sum and range-filter them
+	AmountValue              *primitive.Decimal128 `bson:"amount_value,omitempty" json:"-"`
+	FeesValue                *primitive.Decimal128 `bson:"fees_value,omitempty" json:"-"`
+	// AmountFormatted and FeesFormatted are Amount and Fees in whole tokens, filled in for API responses
+	AmountFormatted          string    `bson:"-" json:"amount_formatted,omitempty"`
+	FeesFormatted            string    `bson:"-" json:"fees_formatted,omitempty"`
+}
+
+// DecimalAmount parses a base-unit amount into a Decimal128. It returns nil if
+// the amount is empty, malformed or too precise for Decimal128.
+func DecimalAmount(amount string) *primitive.Decimal128 {
+	if amount == "" {
+		return nil
+	}
+	value, err := primitive.ParseDecimal128(amount)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// Key returns the event's EventKey
+func (e *EventData) Key() string {
+	return e.ChainID + ":" + e.TransactionHash + ":" + e.EventName + ":" + strconv.FormatUint(uint64(e.LogIndex), 10)
+}
+
+// SetAmountValues fills AmountValue and FeesValue from Amount and Fees
+func (e *EventData) SetAmountValues() {
+	e.AmountValue = DecimalAmount(e.Amount)
+	e.FeesValue = DecimalAmount(e.Fees)
 }
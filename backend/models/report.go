@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Report periods
+const (
+	ReportDaily  = "daily"
+	ReportWeekly = "weekly"
+)
+
+// Report summarises the cross-chain transfers sent during one period. A
+// transfer is a MessageSent; it is delivered once its MessageReceived is stored.
+type Report struct {
+	// ID is the period and its start date, e.g. "daily:2024-09-30"
+	ID          string    `json:"id" bson:"_id"`
+	Period      string    `json:"period" bson:"period"`
+	Start       time.Time `json:"start" bson:"start"`
+	End         time.Time `json:"end" bson:"end"`
+	GeneratedAt time.Time `json:"generated_at" bson:"generated_at"`
+	// Complete is false while the period is still running
+	Complete     bool `json:"complete" bson:"complete"`
+	ReportTotals `bson:",inline"`
+	Chains       []ChainReport `json:"chains" bson:"chains"`
+	Lanes        []LaneReport  `json:"lanes" bson:"lanes"`
+}
+
+// ReportTotals are the figures reported for the whole period, a chain or a lane.
+// Volume and fees are in base units.
+type ReportTotals struct {
+	Transfers   int64  `json:"transfers" bson:"transfers"`
+	Delivered   int64  `json:"delivered" bson:"delivered"`
+	UniqueUsers int64  `json:"unique_users" bson:"unique_users"`
+	Volume      string `json:"volume" bson:"volume"`
+	FeesPaid    string `json:"fees_paid" bson:"fees_paid"`
+	// MedianLatencySeconds is the median time from sent to received of the delivered transfers
+	MedianLatencySeconds *float64 `json:"median_latency_seconds" bson:"median_latency_seconds"`
+}
+
+// ChainReport covers the transfers sent from one chain
+type ChainReport struct {
+	ChainID      string `json:"chain_id" bson:"chain_id"`
+	ReportTotals `bson:",inline"`
+}
+
+// LaneReport covers the transfers sent from one chain to another
+type LaneReport struct {
+	SourceChainID            string `json:"source_chain_id" bson:"source_chain_id"`
+	DestinationChainID       string `json:"destination_chain_id,omitempty" bson:"destination_chain_id,omitempty"`
+	DestinationChainSelector uint64 `json:"destination_chain_selector,string" bson:"destination_chain_selector"`
+	ReportTotals             `bson:",inline"`
+}
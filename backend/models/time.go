@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TimeLayout is the fixed-width UTC layout used for created_at and updated_at.
+// Every value has the same length, so string comparisons order them correctly.
+const TimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// FormatTime formats t in TimeLayout after converting it to UTC
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Transaction states
+const (
+	TxPending   = "pending"
+	TxConfirmed = "confirmed"
+	// TxReverted transactions were mined but failed
+	TxReverted = "reverted"
+	// TxFailed transactions were rejected by the node or had their nonce
+	// taken by another transaction
+	TxFailed = "failed"
+	// TxStuck transactions used up their attempts without being mined. They
+	// keep their nonce, so later transactions wait behind them, and are still
+	// confirmed if one of their attempts is mined.
+	TxStuck = "stuck"
+)
+
+// Transaction is a transaction sent by the backend's signer, tracked until it
+// is final. Every replacement is kept as an attempt; whichever attempt is
+// mined decides the outcome.
+type Transaction struct {
+	ID          string      `json:"id" bson:"_id"`
+	ChainID     string      `json:"chain_id" bson:"chain_id"`
+	From        string      `json:"from" bson:"from"`
+	To          string      `json:"to" bson:"to"`
+	Nonce       uint64      `json:"nonce" bson:"nonce"`
+	Value       string      `json:"value" bson:"value"`
+	Data        string      `json:"data" bson:"data"`
+	GasLimit    uint64      `json:"gas_limit" bson:"gas_limit"`
+	Purpose     string      `json:"purpose,omitempty" bson:"purpose,omitempty"`
+	Status      string      `json:"status" bson:"status"`
+	Attempts    []TxAttempt `json:"attempts" bson:"attempts"`
+	MinedHash   string      `json:"mined_hash,omitempty" bson:"mined_hash,omitempty"`
+	BlockNumber uint64      `json:"block_number,omitempty" bson:"block_number,omitempty"`
+	GasUsed     uint64      `json:"gas_used,omitempty" bson:"gas_used,omitempty"`
+	Error       string      `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time   `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" bson:"updated_at"`
+}
+
+// TxAttempt is one signed version of a transaction. Fees are in wei.
+type TxAttempt struct {
+	Hash                 string    `json:"hash" bson:"hash"`
+	MaxFeePerGas         string    `json:"max_fee_per_gas" bson:"max_fee_per_gas"`
+	MaxPriorityFeePerGas string    `json:"max_priority_fee_per_gas" bson:"max_priority_fee_per_gas"`
+	SentAt               time.Time `json:"sent_at" bson:"sent_at"`
+	Error                string    `json:"error,omitempty" bson:"error,omitempty"`
+	// Unconfirmed is set when sending failed without the node rejecting the
+	// transaction, e.g. on a timeout, so it may still be mined
+	Unconfirmed bool `json:"unconfirmed,omitempty" bson:"unconfirmed,omitempty"`
+}
+
+// LastAttempt returns the most recent attempt, or nil if none was sent
+func (t *Transaction) LastAttempt() *TxAttempt {
+	if len(t.Attempts) == 0 {
+		return nil
+	}
+	return &t.Attempts[len(t.Attempts)-1]
+}
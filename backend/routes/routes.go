@@ -1,43 +1,180 @@
 package routes
 
 import (
+    "backend/config"
     "backend/controllers"
+    "backend/database"
+    "backend/middleware"
+    "backend/models"
+    "backend/services"
     "github.com/gin-gonic/gin"
      "github.com/gin-contrib/cors"
 )
 
-// SetupRouter sets up the main router for the API
-func SetupRouter() *gin.Engine {
+// Dependencies holds the services injected into the route handlers
+type Dependencies struct {
+    Events      database.EventStore
+    Health      *services.HealthService
+    Auth        *services.AuthService
+    RateLimiter *services.RateLimiter
+    // Transactions holds the per-chain transaction engines
+    Transactions *services.TxEngines
+    Quotes      *services.QuoteService
+    Calls       *services.ContractCaller
+    ContractState *services.ContractStateService
+    // Audit records privileged actions; Replayer backfills events from the chain
+    Audit       *services.AuditLog
+    Replayer    *services.EventReplayer
+    AllowlistDrift *services.AllowlistDriftDetector
+    StuckTransfers *services.StuckTransferDetector
+    Alerts      *services.AlertEngine
+    Reports     *services.ReportGenerator
+    // TokenDecimals formats event amounts in whole tokens
+    TokenDecimals *services.TokenDecimals
+    // Indexes is nil when the server runs without MongoDB
+    Indexes     *database.IndexManager
+    Archiver    *services.EventArchiver
+}
+
+// SetupRouter sets up the main router for the API of the default tenant
+func SetupRouter(deps Dependencies) *gin.Engine {
+    return SetupTenantRouter(map[string]Dependencies{config.DefaultTenant: deps})
+}
+
+// SetupTenantRouter sets up the main router serving every tenant's API under
+// /api/tenants/<id>. The default tenant is also served under /api.
+func SetupTenantRouter(tenants map[string]Dependencies) *gin.Engine {
     // Create a new default Gin engine
     router := gin.Default()
 
     // Configure CORS
-    config := cors.DefaultConfig()
-    config.AllowOrigins = []string{"http://localhost:3000"} // Add your frontend URL here
-    config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-    config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+    corsConfig := cors.DefaultConfig()
+    corsConfig.AllowOrigins = []string{"http://localhost:3000"} // Add your frontend URL here
+    corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+    corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization",
+        services.HeaderAPIKey, services.HeaderKeyID, services.HeaderTimestamp, services.HeaderNonce, services.HeaderSignature}
 
     // Use CORS middleware
-    router.Use(cors.New(config))
-
-    // Group all API routes under "/api"
-    apiRoutes := router.Group("/api")
-    {
-        // Event routes
-        apiRoutes.POST("/events/mint", controllers.HandleMintEvent)
-        apiRoutes.POST("/events/burn", controllers.HandleBurnEvent)
-        apiRoutes.POST("/events/tokens-released", controllers.HandleTokensReleasedEvent)
-        apiRoutes.POST("/events/tokens-locked", controllers.HandleTokensLockedEvent)
-        apiRoutes.POST("/events/message-sent", controllers.HandleMessageSentEvent)
-        apiRoutes.POST("/events/message-received", controllers.HandleMessageReceivedEvent)
-        apiRoutes.GET("/events/:callerAddress/last", controllers.GetLastEventData)
-        apiRoutes.GET("/metrics", controllers.GetPerformanceMetrics)
+    router.Use(cors.New(corsConfig))
 
-        // New contract routes
+    // Liveness and readiness probes for the load balancer
+    // Readiness covers every tenant
+    health := &controllers.HealthController{}
+    for _, tenant := range config.TenantIDs() {
+        if deps, exists := tenants[tenant]; exists {
+            health.Health = append(health.Health, deps.Health)
+        }
+    }
+    router.GET("/healthz", health.Healthz)
+    router.GET("/readyz", health.Readyz)
 
-         apiRoutes.GET("/contract/:chainID/:index", controllers.GetContractData)
+    for tenant, deps := range tenants {
+        registerAPIRoutes(router, config.ForTenant(tenant).APIPath(), tenant, deps)
+        if tenant == config.DefaultTenant {
+            registerAPIRoutes(router, "/api/tenants/" + tenant, tenant, deps)
+        }
     }
 
     // Return the configured router
     return router
+}
+
+// registerAPIRoutes mounts a tenant's API at path. Requests are rate limited by
+// IP before their credentials are checked, so invalid keys are throttled too,
+// and then per key once authenticated.
+func registerAPIRoutes(router *gin.Engine, path string, tenant string, deps Dependencies) {
+    apiRoutes := router.Group(path,
+        middleware.RateLimitByIP(deps.RateLimiter),
+        middleware.OptionalAPIKey(deps.Auth),
+        middleware.RateLimitByKey(deps.RateLimiter))
+    {
+        events := controllers.NewEventController(tenant, deps.Events, deps.TokenDecimals)
+
+        // Event ingestion routes require a key with the ingest scope
+        ingestRoutes := apiRoutes.Group("/events", middleware.RequireAPIKey(deps.Auth, models.ScopeIngest))
+        ingestRoutes.POST("/mint", events.HandleMintEvent)
+        ingestRoutes.POST("/burn", events.HandleBurnEvent)
+        ingestRoutes.POST("/tokens-released", events.HandleTokensReleasedEvent)
+        ingestRoutes.POST("/tokens-locked", events.HandleTokensLockedEvent)
+        ingestRoutes.POST("/message-sent", events.HandleMessageSentEvent)
+        ingestRoutes.POST("/message-received", events.HandleMessageReceivedEvent)
+
+        // Event read routes stay public, except the bulk export, which needs a key
+        // with the read scope
+        apiRoutes.GET("/events", events.ListEvents)
+        apiRoutes.GET("/events/stats", events.GetEventStats)
+        apiRoutes.GET("/events/export", middleware.RequireAPIKey(deps.Auth, models.ScopeRead), events.ExportEvents)
+        apiRoutes.GET("/events/:callerAddress/last", events.GetLastEventData)
+        apiRoutes.GET("/metrics", events.GetPerformanceMetrics)
+
+        // New contract routes
+
+         contracts := &controllers.ContractController{State: deps.ContractState, Tenant: tenant}
+         apiRoutes.GET("/contract/:chainID/:index", contracts.GetContractData)
+
+        // Read-only calls run through the backend's RPC connections
+        calls := &controllers.ContractCallController{Caller: deps.Calls}
+        apiRoutes.GET("/contract/:chainID/:index/call/:method", calls.CallContract)
+
+        // CCIP fee quotes, read from the source chain
+        quotes := &controllers.QuoteController{Quotes: deps.Quotes}
+        apiRoutes.GET("/quote", quotes.GetQuote)
+
+        // Transfers with no destination leg after their lane's timeout
+        transfers := &controllers.TransferController{Stuck: deps.StuckTransfers}
+        apiRoutes.GET("/transfers/stuck", transfers.ListStuckTransfers)
+
+        // Daily and weekly volume and fee reports
+        reports := &controllers.ReportController{Reports: deps.Reports}
+        apiRoutes.GET("/reports/:period", reports.GetReports)
+
+        // Admin routes
+        adminRoutes := apiRoutes.Group("/admin", middleware.RequireAPIKey(deps.Auth, models.ScopeAdmin))
+        apiKeys := &controllers.APIKeyController{Auth: deps.Auth, Audit: deps.Audit}
+        adminRoutes.POST("/keys", apiKeys.CreateAPIKey)
+        adminRoutes.GET("/keys", apiKeys.ListAPIKeys)
+        adminRoutes.DELETE("/keys/:id", apiKeys.RevokeAPIKey)
+
+        rateLimits := &controllers.RateLimitController{Limiter: deps.RateLimiter}
+        adminRoutes.GET("/keys/:id/usage", rateLimits.GetKeyUsage)
+        adminRoutes.GET("/rate-limits", rateLimits.ListUsage)
+
+        transactions := &controllers.TransactionController{Transactions: deps.Transactions}
+        adminRoutes.GET("/transactions", transactions.ListTransactions)
+        adminRoutes.GET("/transactions/:id", transactions.GetTransaction)
+
+        contractAdmin := &controllers.ContractAdminController{Admin: &services.ContractAdmin{Engines: deps.Transactions, Tenant: tenant}, Audit: deps.Audit}
+        adminRoutes.POST("/contracts/:chainID/:index/:operation", contractAdmin.ExecuteOperation)
+
+        maintenance := &controllers.MaintenanceController{Replayer: deps.Replayer, Audit: deps.Audit, Tenant: tenant}
+        adminRoutes.POST("/config/reload", maintenance.ReloadConfig)
+        adminRoutes.POST("/events/replay", maintenance.ReplayEvents)
+
+        drift := &controllers.AllowlistDriftController{Drift: deps.AllowlistDrift}
+        adminRoutes.GET("/allowlist/drift", drift.GetDrift)
+        adminRoutes.POST("/allowlist/drift/check", drift.CheckDrift)
+
+        alerts := &controllers.AlertController{Engine: deps.Alerts, Audit: deps.Audit}
+        adminRoutes.POST("/alert-rules", alerts.CreateRule)
+        adminRoutes.GET("/alert-rules", alerts.ListRules)
+        adminRoutes.GET("/alert-rules/:id", alerts.GetRule)
+        adminRoutes.PUT("/alert-rules/:id", alerts.UpdateRule)
+        adminRoutes.DELETE("/alert-rules/:id", alerts.DeleteRule)
+        adminRoutes.GET("/alerts", alerts.ListAlerts)
+
+        adminRoutes.POST("/reports/:period/regenerate", reports.RegenerateReport)
+
+        archives := &controllers.ArchiveController{Archiver: deps.Archiver, Audit: deps.Audit}
+        adminRoutes.GET("/archives", archives.ListArchives)
+        adminRoutes.POST("/archives/run", archives.RunArchive)
+        adminRoutes.POST("/archives/restore", archives.RestoreEvents)
+
+        indexes := &controllers.IndexController{Indexes: deps.Indexes}
+        adminRoutes.GET("/indexes", indexes.GetIndexes)
+
+        audit := &controllers.AuditController{Audit: deps.Audit}
+        adminRoutes.GET("/audit", audit.ListAudit)
+        adminRoutes.GET("/audit/export", audit.ExportAudit)
+        adminRoutes.GET("/audit/verify", audit.VerifyAudit)
+    }
 }
\ No newline at end of file
@@ -3,23 +3,37 @@ package main
 import (
 	"flag"
 	"log"
-	"backend/config"
 	"backend/server/mainserver"
-	"backend/server/testserver"
+	"backend/server/mockserver"
 )
 
 func main() {
-	if err := config.Init(); err != nil {
-		log.Fatalf("Failed to initialize config: %v", err)
-}
-	serverType := flag.String("server", "main", "Specify which server to start (main or test)")
+	serverType := flag.String("server", "main", "Specify which server to start (main or mock)")
+	migrate := flag.String("migrate", "", "Run a database migration command (status, up or down) instead of a server")
+	dryRun := flag.Bool("dry-run", false, "With -migrate up or down, report what would change without changing it")
+	steps := flag.Int("steps", 1, "With -migrate down, how many applied migrations to revert")
+	archive := flag.String("archive", "", "Run an event archive command (list, run or restore) instead of a server")
+	eventName := flag.String("event-name", "", "With -archive list or restore, only select this event type")
+	from := flag.String("from", "", "With -archive list or restore, select events created at or after this RFC 3339 time")
+	to := flag.String("to", "", "With -archive list or restore, select events created before this RFC 3339 time")
+	tenant := flag.String("tenant", "default", "With -migrate or -archive, the tenant whose database and archives are used")
 	flag.Parse()
 
+	if *migrate != "" {
+		mainserver.RunMigrations(*tenant, *migrate, *dryRun, *steps)
+		return
+	}
+	if *archive != "" {
+		mainserver.RunArchiveCommand(*tenant, *archive, *eventName, *from, *to)
+		return
+	}
+
 	switch *serverType {
 	case "main":
 		mainserver.RunMainServer()
-	case "test":
-		testserver.RunTestServer()
+	case "mock", "test":
+		// "test" is kept for the old test server, which mock mode replaces
+		mockserver.RunMockServer()
 	default:
 		log.Fatal("Invalid server type specified")
 	}
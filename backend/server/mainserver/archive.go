@@ -0,0 +1,66 @@
+package mainserver
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/services"
+)
+
+// RunArchiveCommand runs an archive command against a tenant's database and
+// archives and prints the outcome as JSON. The command is "list", "run" or
+// "restore"; list and restore select the events of eventName created in
+// [from, to), given as RFC 3339 timestamps. Restore needs both bounds.
+func RunArchiveCommand(tenant, command, eventName, from, to string) {
+	if command != "list" && command != "run" && command != "restore" {
+		log.Fatalf("Unknown archive command %q, expected list, run or restore", command)
+	}
+	filter := database.ArchiveFilter{EventName: eventName}
+	var err error
+	if from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			log.Fatal("-from must be an RFC 3339 timestamp")
+		}
+	}
+	if to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			log.Fatal("-to must be an RFC 3339 timestamp")
+		}
+	}
+
+	if err := config.Init(); err != nil {
+		log.Fatalf("Failed to initialize config: %v", err)
+	}
+	storage, err := services.NewArchiveStorage(config.GetRetentionConfig().Archive.ForTenant(tenant))
+	if err != nil {
+		log.Fatalf("Failed to configure archive storage: %v", err)
+	}
+	database.ConnectToMongoDB()
+	db := tenantDatabase(tenant)
+	archiver := services.NewEventArchiver(database.NewMongoEventStore(db), database.NewMongoArchiveStore(db), storage)
+	ctx := context.Background()
+
+	var output interface{}
+	switch command {
+	case "list":
+		output, err = archiver.List(ctx, filter)
+	case "run":
+		output, err = archiver.Archive(ctx)
+	case "restore":
+		output, err = archiver.Restore(ctx, filter)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(output); encodeErr != nil {
+		log.Printf("Error printing archive output: %v", encodeErr)
+	}
+	if err != nil {
+		log.Fatalf("Archive %s failed: %v", command, err)
+	}
+}
@@ -2,12 +2,14 @@ package mainserver
 
 
 import (
+    "context"
     "log"
     "backend/config"
     "backend/routes"
     "backend/services"
     "backend/database"
     "github.com/zsais/go-gin-prometheus"
+    "go.mongodb.org/mongo-driver/mongo"
 )
 
 func RunMainServer() {
@@ -16,12 +18,16 @@ func RunMainServer() {
         log.Fatalf("Failed to initialize config: %v", err)
     }
 
-    go services.StartContractEventMonitor("80002", "Token")
-
     database.ConnectToMongoDB()
 
+    // Every tenant gets its own database, chain clients and background services
+    tenants := make(map[string]routes.Dependencies)
+    for _, tenant := range config.TenantIDs() {
+        tenants[tenant] = startTenant(context.Background(), tenant)
+    }
+
     // Setup and run the HTTP server
-    r := routes.SetupRouter()
+    r := routes.SetupTenantRouter(tenants)
 
     // Add prometheus middleware
     p := ginprometheus.NewPrometheus("gin")
@@ -32,3 +38,81 @@ func RunMainServer() {
         log.Fatalf("Failed to run main server: %v", err)
     }
 }
+
+// startTenant migrates a tenant's database, starts its event monitors and
+// background jobs and returns the dependencies its routes are served with
+func startTenant(ctx context.Context, tenant string) routes.Dependencies {
+    registry := config.ForTenant(tenant)
+    for _, monitor := range registry.Monitors {
+        go services.StartContractEventMonitor(tenant, monitor.ChainID, monitor.ContractType)
+    }
+
+    clients := services.NewTenantChainClients(tenant)
+
+    db := tenantDatabase(tenant)
+    if _, err := database.NewMigrator(db, database.EventMigrations).Up(ctx, false); err != nil {
+        log.Fatalf("Failed to migrate the database of tenant %s: %v", tenant, err)
+    }
+    indexes := database.NewIndexManager(db)
+    if err := indexes.Ensure(ctx); err != nil {
+        log.Fatalf("Failed to create indexes for tenant %s: %v", tenant, err)
+    }
+
+    events := database.NewMongoEventStore(db)
+    transactions := services.StartTxEngines(ctx, clients, database.NewMongoTxStore(db))
+
+    drift := services.NewAllowlistDriftDetector(clients)
+    go drift.Run(ctx)
+
+    // Alerts go to the shared notifiers, but each tenant only lists its own
+    dispatcher, err := services.NewAlertDispatcher(config.GetAlertConfig().Notifiers)
+    if err != nil {
+        log.Fatalf("Failed to configure alert notifiers: %v", err)
+    }
+    dispatcher.Tenant = tenant
+    alerts := services.NewAlertEngine(database.NewMongoAlertRuleStore(db), dispatcher)
+    alerts.Tenant = tenant
+    go alerts.Run(ctx)
+    services.OnDecodedEvent(tenant, alerts.Observe)
+
+    stuckTransfers := services.NewStuckTransferDetector(events, database.NewMongoStuckAlertStore(db), clients, dispatcher)
+    go stuckTransfers.Run(ctx)
+
+    reports := &services.ReportGenerator{Store: database.NewMongoReportStore(db), Tenant: tenant}
+    go reports.Run(ctx)
+
+    archiveStorage, err := services.NewArchiveStorage(config.GetRetentionConfig().Archive.ForTenant(tenant))
+    if err != nil {
+        log.Fatalf("Failed to configure archive storage for tenant %s: %v", tenant, err)
+    }
+    archiver := services.NewEventArchiver(events, database.NewMongoArchiveStore(db), archiveStorage)
+    go archiver.Run(ctx)
+
+    return routes.Dependencies{
+        Events:      services.ObserveInsertedEvents(events, alerts.Observe),
+        Health:      services.NewHealthService(events.Ping, clients),
+        Auth:        services.NewTenantAuthService(tenant, database.NewMongoAPIKeyStore(db), database.NewMongoNonceStore(db)),
+        RateLimiter: services.NewRateLimiter(config.GetRateLimitConfig()),
+        Transactions: transactions,
+        Quotes:      services.NewQuoteService(clients),
+        Calls:       services.NewContractCaller(clients),
+        ContractState: services.NewContractStateService(clients),
+        Audit:       services.NewAuditLog(database.NewMongoAuditStore(db)),
+        Replayer:    &services.EventReplayer{Clients: clients, Events: events},
+        AllowlistDrift: drift,
+        StuckTransfers: stuckTransfers,
+        Alerts:      alerts,
+        Reports:     reports,
+        TokenDecimals: services.NewTokenDecimals(clients),
+        Indexes:     indexes,
+        Archiver:    archiver,
+    }
+}
+
+// tenantDatabase returns the database of a configured tenant
+func tenantDatabase(tenant string) *mongo.Database {
+    if !config.TenantExists(tenant) {
+        log.Fatalf("Unknown tenant %s", tenant)
+    }
+    return database.GetTenantDatabase(config.ForTenant(tenant).Database)
+}
\ No newline at end of file
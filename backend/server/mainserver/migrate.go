@@ -0,0 +1,56 @@
+package mainserver
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+
+	"backend/config"
+	"backend/database"
+)
+
+// RunMigrations runs a migration command against a tenant's database and
+// prints the outcome as JSON. The command is "status", "up" or "down"; down
+// reverts the last steps migrations. With dryRun set, up and down only report
+// how many documents each migration would change.
+func RunMigrations(tenant, command string, dryRun bool, steps int) {
+	if command != "status" && command != "up" && command != "down" {
+		log.Fatalf("Unknown migration command %q, expected status, up or down", command)
+	}
+	if command == "down" && steps < 1 {
+		log.Fatal("-steps must be at least 1")
+	}
+
+	database.ConnectToMongoDB()
+	db := database.GetDatabase()
+	if tenant != config.DefaultTenant {
+		// Other tenants' databases are named in the configuration
+		if err := config.Init(); err != nil {
+			log.Fatalf("Failed to initialize config: %v", err)
+		}
+		db = tenantDatabase(tenant)
+	}
+	migrator := database.NewMigrator(db, database.EventMigrations)
+	ctx := context.Background()
+
+	var output interface{}
+	var err error
+	switch command {
+	case "status":
+		output, err = migrator.Status(ctx)
+	case "up":
+		output, err = migrator.Up(ctx, dryRun)
+	case "down":
+		output, err = migrator.Down(ctx, steps, dryRun)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(output); encodeErr != nil {
+		log.Printf("Error printing migration output: %v", encodeErr)
+	}
+	if err != nil {
+		log.Fatalf("Migration %s failed: %v", command, err)
+	}
+}
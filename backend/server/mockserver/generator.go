@@ -0,0 +1,283 @@
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+	"backend/services"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// generatorFixture is the layout of generator.json
+type generatorFixture struct {
+	IntervalSeconds int                              `json:"interval_seconds"`
+	Wallets         []string                         `json:"wallets"`
+	Chains          map[string]generatorChainFixture `json:"chains"`
+}
+
+type generatorChainFixture struct {
+	CCIPChainSelector uint64 `json:"ccip_chain_selector"`
+	LinkTokenAddress  string `json:"link_token_address"`
+	StartBlock        uint64 `json:"start_block"`
+}
+
+// generatorChain tracks the synthetic head of one chain
+type generatorChain struct {
+	id       string
+	selector uint64
+	link     common.Address
+	block    uint64
+}
+
+// delivery is a cross-chain message waiting to arrive on its destination chain
+type delivery struct {
+	due    time.Time
+	events []models.EventData
+}
+
+// Generator emits realistic Mint, Burn, lock and cross-chain message events
+// into a store on a timer. Messages sent on one chain are delivered to the
+// destination chain a couple of ticks later.
+type Generator struct {
+	Store    database.EventStore
+	Interval time.Duration
+
+	wallets []common.Address
+	chains  []*generatorChain
+	pending []delivery
+	rng     *rand.Rand
+}
+
+// LoadGenerator creates a generator from a fixture file. Only chains present in
+// the loaded config are used.
+func LoadGenerator(path string, store database.EventStore) (*Generator, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture generatorFixture
+	if err := json.Unmarshal(contents, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if len(fixture.Wallets) == 0 {
+		return nil, fmt.Errorf("%s lists no wallets", path)
+	}
+
+	generator := &Generator{
+		Store:    store,
+		Interval: time.Duration(fixture.IntervalSeconds) * time.Second,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if generator.Interval <= 0 {
+		generator.Interval = 5 * time.Second
+	}
+	for _, wallet := range fixture.Wallets {
+		if !common.IsHexAddress(wallet) {
+			return nil, fmt.Errorf("invalid wallet address %q in %s", wallet, path)
+		}
+		generator.wallets = append(generator.wallets, common.HexToAddress(wallet))
+	}
+	for _, chainID := range config.ChainIDs() {
+		chain, exists := fixture.Chains[chainID]
+		if !exists {
+			continue
+		}
+		generator.chains = append(generator.chains, &generatorChain{
+			id:       chainID,
+			selector: chain.CCIPChainSelector,
+			link:     common.HexToAddress(chain.LinkTokenAddress),
+			block:    chain.StartBlock,
+		})
+	}
+	if len(generator.chains) == 0 {
+		return nil, fmt.Errorf("%s describes none of the configured chains", path)
+	}
+	return generator, nil
+}
+
+// Run emits events until the context is cancelled
+func (g *Generator) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+
+	log.Printf("Generating synthetic events every %v on chains %v", g.Interval, g.chainIDs())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			g.Tick(ctx, now)
+		}
+	}
+}
+
+// Tick delivers any due messages and emits one new scenario
+func (g *Generator) Tick(ctx context.Context, now time.Time) {
+	remaining := g.pending[:0]
+	for _, pending := range g.pending {
+		if now.Before(pending.due) {
+			remaining = append(remaining, pending)
+			continue
+		}
+		g.store(ctx, pending.events, now)
+	}
+	g.pending = remaining
+
+	scenarios := []func() []models.EventData{g.mint, g.burn, g.lock}
+	if len(g.chains) > 1 {
+		scenarios = append(scenarios, func() []models.EventData { return g.message(now) })
+	}
+	g.store(ctx, scenarios[g.rng.Intn(len(scenarios))](), now)
+}
+
+// mint is a Token.mint to a wallet
+func (g *Generator) mint() []models.EventData {
+	chain, wallet := g.chain(), g.wallet()
+	event := g.event(chain, "Mint", "Token", wallet, g.amount())
+	event.ToFromUser = wallet.Hex()
+	return []models.EventData{event}
+}
+
+// burn is a Token.burn from a wallet
+func (g *Generator) burn() []models.EventData {
+	return []models.EventData{g.event(g.chain(), "Burn", "Token", g.wallet(), g.amount())}
+}
+
+// lock is Vault.lockTokenInVault: the vault burns its tokens and records the lock
+func (g *Generator) lock() []models.EventData {
+	chain, wallet, amount := g.chain(), g.wallet(), g.amount()
+	vault := contractAddress(chain.id, "Vault")
+
+	burn := g.event(chain, "Burn", "Token", vault, amount)
+	locked := g.event(chain, "TokensLocked", "Vault", wallet, amount)
+	sameTransaction(&burn, &locked)
+	return []models.EventData{burn, locked}
+}
+
+// message is a Messenger send paid in LINK; the matching MessageReceived and
+// release Mint are queued for the destination chain
+func (g *Generator) message(now time.Time) []models.EventData {
+	source := g.chain()
+	destination := g.chain()
+	for destination == source {
+		destination = g.chain()
+	}
+	client, amount := g.wallet(), g.amount()
+	messageID := g.hash()
+
+	sent := g.event(source, "MessageSent", "Router", client, amount)
+	sent.MessageID = messageID.Hex()
+	sent.DestinationChainSelector = destination.selector
+	sent.Receiver = contractAddress(destination.id, "Router").Hex()
+	sent.Text = "bridge"
+	sent.Client = client.Hex()
+	sent.FeeToken = source.link.Hex()
+	sent.Fees = new(big.Int).Mul(big.NewInt(int64(50+g.rng.Intn(50))), big.NewInt(1e15)).String()
+
+	released := g.event(destination, "Mint", "Token", client, amount)
+	released.ToFromUser = client.Hex()
+	received := g.event(destination, "MessageReceived", "Router", client, amount)
+	received.MessageID = sent.MessageID
+	received.SourceChainSelector = source.selector
+	received.Sender = contractAddress(source.id, "Router").Hex()
+	received.Text = sent.Text
+	received.Client = sent.Client
+	sameTransaction(&released, &received)
+
+	g.pending = append(g.pending, delivery{
+		due:    now.Add(time.Duration(2+g.rng.Intn(3)) * g.Interval),
+		events: []models.EventData{released, received},
+	})
+	return []models.EventData{sent}
+}
+
+// event builds an event as the monitor would, in a new block and transaction
+func (g *Generator) event(chain *generatorChain, eventName, contractType string, caller common.Address, amount string) models.EventData {
+	chain.block += 1 + uint64(g.rng.Intn(5))
+	txHash := g.hash()
+	return models.EventData{
+		ID:              fmt.Sprintf("%x", txHash),
+		ChainID:         chain.id,
+		ContractAddress: contractAddress(chain.id, contractType).Hex(),
+		EventName:       eventName,
+		CallerAddress:   caller.Hex(),
+		BlockNumber:     chain.block,
+		TransactionHash: txHash.Hex(),
+		Amount:          amount,
+	}
+}
+
+// store stamps and validates events before inserting them
+func (g *Generator) store(ctx context.Context, events []models.EventData, now time.Time) {
+	for i := range events {
+		event := &events[i]
+		event.Timestamp = models.FormatTime(now)
+		event.CreatedAt = models.FormatTime(now)
+		event.UpdatedAt = models.FormatTime(now)
+
+		if fieldErrors := services.ValidateEventData(config.ForTenant(config.DefaultTenant), *event); len(fieldErrors) > 0 {
+			log.Printf("Dropping invalid synthetic %s event: %+v", event.EventName, fieldErrors)
+			continue
+		}
+		if err := g.Store.InsertEvent(ctx, event); err != nil {
+			log.Printf("Failed to store synthetic %s event: %v", event.EventName, err)
+			continue
+		}
+		log.Printf("Generated %s on chain %s (tx %s)", event.EventName, event.ChainID, event.TransactionHash)
+	}
+}
+
+func (g *Generator) chain() *generatorChain {
+	return g.chains[g.rng.Intn(len(g.chains))]
+}
+
+func (g *Generator) wallet() common.Address {
+	return g.wallets[g.rng.Intn(len(g.wallets))]
+}
+
+// amount returns a whole number of 18-decimal tokens between 1 and 1000
+func (g *Generator) amount() string {
+	tokens := big.NewInt(int64(1 + g.rng.Intn(1000)))
+	return new(big.Int).Mul(tokens, big.NewInt(1e18)).String()
+}
+
+func (g *Generator) hash() common.Hash {
+	var hash common.Hash
+	g.rng.Read(hash[:])
+	return hash
+}
+
+func (g *Generator) chainIDs() []string {
+	ids := make([]string, 0, len(g.chains))
+	for _, chain := range g.chains {
+		ids = append(ids, chain.id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sameTransaction makes the second event part of the first one's transaction
+func sameTransaction(first, second *models.EventData) {
+	second.ID = first.ID
+	second.TransactionHash = first.TransactionHash
+	second.BlockNumber = first.BlockNumber
+	second.LogIndex = first.LogIndex + 1
+}
+
+// contractAddress looks up a fixture contract; the config is validated by seeding
+func contractAddress(chainID, contractType string) common.Address {
+	address, _ := config.GetContractAddress(chainID, contractType)
+	return common.HexToAddress(address)
+}
@@ -0,0 +1,157 @@
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+	"backend/routes"
+	"backend/services"
+)
+
+//go run server/main.go -server=mock
+
+// FixturesDirEnvVar overrides where the mock server reads its fixture files from
+const FixturesDirEnvVar = "MOCK_FIXTURES_DIR"
+
+// RunMockServer serves the full API from memory, seeded from fixture files and
+// fed by a synthetic event generator, so the frontend works without RPC
+// endpoints or MongoDB
+func RunMockServer() {
+	dir, err := fixturesDir()
+	if err != nil {
+		log.Fatalf("Failed to locate mock fixtures: %v", err)
+	}
+
+	if err := config.InitFromFile(filepath.Join(dir, "config.json")); err != nil {
+		log.Fatalf("Failed to initialize mock config: %v", err)
+	}
+
+	events := database.NewMemoryEventStore()
+	seeded, err := seedEvents(context.Background(), events, filepath.Join(dir, "events.json"))
+	if err != nil {
+		log.Fatalf("Failed to seed mock events: %v", err)
+	}
+	log.Printf("Seeded %d events from %s", seeded, dir)
+
+	dispatcher, err := services.NewAlertDispatcher(config.GetAlertConfig().Notifiers)
+	if err != nil {
+		log.Fatalf("Failed to configure alert notifiers: %v", err)
+	}
+	alerts := services.NewAlertEngine(database.NewMemoryAlertRuleStore(), dispatcher)
+	go alerts.Run(context.Background())
+	// Generated and ingested events are evaluated against the alert rules; seeded ones are not
+	observed := services.ObserveInsertedEvents(events, alerts.Observe)
+
+	generator, err := LoadGenerator(filepath.Join(dir, "generator.json"), observed)
+	if err != nil {
+		log.Fatalf("Failed to load event generator: %v", err)
+	}
+	go generator.Run(context.Background())
+
+	// Chain-reading routes only work if the fixtures config is given RPC URLs
+	clients := services.NewChainClients()
+
+	// Stuck transfers are found from stored events; their allowlist status needs RPC
+	stuckTransfers := services.NewStuckTransferDetector(events, database.NewMemoryStuckAlertStore(), clients, dispatcher)
+	go stuckTransfers.Run(context.Background())
+
+	reports := services.NewReportGenerator(database.NewMemoryReportStore(events))
+	go reports.Run(context.Background())
+
+	archiveStorage, err := services.NewArchiveStorage(config.GetRetentionConfig().Archive)
+	if err != nil {
+		log.Fatalf("Failed to configure archive storage: %v", err)
+	}
+	archiver := services.NewEventArchiver(events, database.NewMemoryArchiveStore(), archiveStorage)
+	go archiver.Run(context.Background())
+
+	r := routes.SetupRouter(routes.Dependencies{
+		Events: observed,
+		Health: &services.HealthService{
+			Ping:   events.Ping,
+			Config: config.GetHealthConfig(),
+		},
+		Auth:           services.NewAuthService(database.NewMemoryAPIKeyStore(), database.NewMemoryNonceStore()),
+		RateLimiter:    services.NewRateLimiter(config.GetRateLimitConfig()),
+		Transactions:   services.NewTxEngines(database.NewMemoryTxStore()),
+		Quotes:         services.NewQuoteService(clients),
+		Calls:          services.NewContractCaller(clients),
+		ContractState:  services.NewContractStateService(clients),
+		Audit:          services.NewAuditLog(database.NewMemoryAuditStore()),
+		Replayer:       &services.EventReplayer{Clients: clients, Events: events},
+		AllowlistDrift: services.NewAllowlistDriftDetector(clients),
+		StuckTransfers: stuckTransfers,
+		Alerts:         alerts,
+		Reports:        reports,
+		TokenDecimals:  services.NewTokenDecimals(clients),
+		Archiver:       archiver,
+	})
+
+	log.Println("Mock server is running on http://localhost" + config.ServerAddress())
+	if err := r.Run(config.ServerAddress()); err != nil {
+		log.Fatalf("Failed to run mock server: %v", err)
+	}
+}
+
+// fixturesDir finds the fixture directory from the env var or the usual working directories
+func fixturesDir() (string, error) {
+	if dir := os.Getenv(FixturesDirEnvVar); dir != "" {
+		return dir, nil
+	}
+
+	possiblePaths := []string{
+		filepath.Join("server", "mockserver", "fixtures"),
+		filepath.Join("mockserver", "fixtures"),
+		filepath.Join("fixtures"),
+		filepath.Join("backend", "server", "mockserver", "fixtures"),
+	}
+	for _, path := range possiblePaths {
+		if info, err := os.Stat(filepath.Join(path, "config.json")); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no fixtures found; set %s", FixturesDirEnvVar)
+}
+
+// seedEvents loads the fixture events into the store. Events without
+// timestamps are spread over the past hours in file order.
+func seedEvents(ctx context.Context, store database.EventStore, path string) (int, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var events []models.EventData
+	if err := json.Unmarshal(contents, &events); err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	now := time.Now()
+	for i := range events {
+		event := &events[i]
+		if event.Timestamp == "" {
+			event.Timestamp = models.FormatTime(now.Add(-time.Duration(len(events)-i) * 10 * time.Minute))
+		}
+		if event.CreatedAt == "" {
+			event.CreatedAt = event.Timestamp
+		}
+		if event.UpdatedAt == "" {
+			event.UpdatedAt = event.CreatedAt
+		}
+		if fieldErrors := services.ValidateEventData(config.ForTenant(config.DefaultTenant), *event); len(fieldErrors) > 0 {
+			return 0, fmt.Errorf("fixture event %d (%s) is invalid: %+v", i, event.EventName, fieldErrors)
+		}
+		if err := store.InsertEvent(ctx, event); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+)
+
+// ErrInvalidRule is returned when an alert rule fails validation
+var ErrInvalidRule = errors.New("invalid rule")
+
+// RuleValidationError carries the problems found with a rule, field by field
+type RuleValidationError struct {
+	Fields []models.FieldError
+}
+
+func (e *RuleValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + " " + field.Message
+	}
+	return "invalid rule: " + strings.Join(parts, "; ")
+}
+
+func (e *RuleValidationError) Unwrap() error { return ErrInvalidRule }
+
+// eventFields are the event JSON fields rules may test and group by
+var eventFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	eventType := reflect.TypeOf(models.EventData{})
+	for i := 0; i < eventType.NumField(); i++ {
+		name, _, _ := strings.Cut(eventType.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}()
+
+// maxSeenEvents bounds the set used to drop events observed twice, once when
+// the monitor decodes them and again when they are ingested
+const maxSeenEvents = 4096
+
+// AlertEngine evaluates alert rules against decoded events as they arrive
+// and delivers firing rules through the dispatcher. Windowed counts live in
+// memory and start empty when the server restarts.
+type AlertEngine struct {
+	Rules      database.AlertRuleStore
+	Dispatcher *AlertDispatcher
+	// RefreshInterval is how often rules are reloaded, picking up changes made
+	// through other servers
+	RefreshInterval time.Duration
+	// Tenant selects the chain registry rules are checked against; empty is the default tenant
+	Tenant string
+
+	queue chan models.EventData
+
+	mu        sync.Mutex
+	rules     []models.AlertRule
+	loadedAt  time.Time
+	seen      map[string]bool
+	seenOrder []string
+	windows   map[string][]time.Time
+	lastFired map[string]time.Time
+}
+
+// NewAlertEngine creates an engine that reloads its rules every 30 seconds
+func NewAlertEngine(rules database.AlertRuleStore, dispatcher *AlertDispatcher) *AlertEngine {
+	return &AlertEngine{
+		Rules:           rules,
+		Dispatcher:      dispatcher,
+		RefreshInterval: 30 * time.Second,
+		queue:           make(chan models.EventData, 1024),
+		seen:            make(map[string]bool),
+		windows:         make(map[string][]time.Time),
+		lastFired:       make(map[string]time.Time),
+	}
+}
+
+// Observe queues an event for evaluation without blocking the caller
+func (e *AlertEngine) Observe(event models.EventData) {
+	select {
+	case e.queue <- event:
+	default:
+		log.Printf("Alert queue full, dropping %s %s", event.EventName, event.TransactionHash)
+	}
+}
+
+// Run evaluates queued events until ctx is cancelled
+func (e *AlertEngine) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-e.queue:
+			e.Evaluate(ctx, event)
+		}
+	}
+}
+
+// Evaluate checks one event against every enabled rule
+func (e *AlertEngine) Evaluate(ctx context.Context, event models.EventData) {
+	rules, err := e.currentRules(ctx)
+	if err != nil {
+		log.Printf("Error loading alert rules: %v", err)
+		return
+	}
+	if !e.firstSighting(event) {
+		return
+	}
+
+	fields := eventFieldValues(event)
+	now := time.Now().UTC()
+	for _, rule := range rules {
+		if rule.Disabled || rule.EventName != event.EventName || (rule.ChainID != "" && rule.ChainID != event.ChainID) {
+			continue
+		}
+		if !matchesConditions(rule.Conditions, fields) {
+			continue
+		}
+		if alert, fire := e.record(rule, fields, now); fire {
+			alert.Details = map[string]interface{}{"rule": rule, "event": event}
+			if err := e.Dispatcher.NotifyVia(ctx, alert, rule.Notifiers); err != nil {
+				log.Printf("Error delivering alert for rule %s: %v", rule.ID, err)
+			}
+		}
+	}
+}
+
+// firstSighting reports whether the event hasn't been evaluated before
+func (e *AlertEngine) firstSighting(event models.EventData) bool {
+	key := strings.Join([]string{event.ChainID, event.ID, event.EventName, event.MessageID, event.ToFromUser, event.Amount}, "|")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.seen[key] {
+		return false
+	}
+	e.seen[key] = true
+	e.seenOrder = append(e.seenOrder, key)
+	if len(e.seenOrder) > maxSeenEvents {
+		delete(e.seen, e.seenOrder[0])
+		e.seenOrder = e.seenOrder[1:]
+	}
+	return true
+}
+
+// record counts a matching event and decides whether the rule fires
+func (e *AlertEngine) record(rule models.AlertRule, fields map[string]string, now time.Time) (Alert, bool) {
+	group := ""
+	if rule.GroupBy != "" {
+		group = fields[rule.GroupBy]
+	}
+	key := rule.ID + "|" + group
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	count := 1
+	if rule.WindowSeconds > 0 {
+		cutoff := now.Add(-time.Duration(rule.WindowSeconds) * time.Second)
+		times := append(e.windows[key], now)
+		for len(times) > 0 && times[0].Before(cutoff) {
+			times = times[1:]
+		}
+		e.windows[key] = times
+		count = len(times)
+		if count <= rule.Count {
+			return Alert{}, false
+		}
+	}
+
+	cooldown := time.Duration(rule.CooldownSeconds) * time.Second
+	if last, fired := e.lastFired[key]; fired && now.Sub(last) < cooldown {
+		return Alert{}, false
+	}
+	e.lastFired[key] = now
+
+	var message string
+	if rule.WindowSeconds > 0 {
+		message = fmt.Sprintf("%d %s events within %ds", count, rule.EventName, rule.WindowSeconds)
+		if rule.GroupBy != "" {
+			message += fmt.Sprintf(" with %s %s", rule.GroupBy, group)
+		}
+	} else {
+		message = fmt.Sprintf("%s on chain %s in %s", rule.EventName, fields["ChainId"], fields["transaction_hash"])
+		if amount := fields["amount"]; amount != "" {
+			message += " for " + amount
+		}
+	}
+	return Alert{
+		Kind:     "rule",
+		Severity: rule.Severity,
+		Title:    rule.Name,
+		Message:  message,
+		FiredAt:  now,
+	}, true
+}
+
+func (e *AlertEngine) currentRules(ctx context.Context) ([]models.AlertRule, error) {
+	e.mu.Lock()
+	if e.rules != nil && time.Since(e.loadedAt) < e.RefreshInterval {
+		rules := e.rules
+		e.mu.Unlock()
+		return rules, nil
+	}
+	e.mu.Unlock()
+
+	rules, err := e.Rules.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.rules, e.loadedAt = rules, time.Now()
+	e.mu.Unlock()
+	return rules, nil
+}
+
+// invalidate makes the next evaluation reload the rules
+func (e *AlertEngine) invalidate() {
+	e.mu.Lock()
+	e.rules = nil
+	e.mu.Unlock()
+}
+
+// CreateRule validates and stores a new rule
+func (e *AlertEngine) CreateRule(ctx context.Context, rule models.AlertRule) (*models.AlertRule, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	rule.ID, rule.CreatedAt, rule.UpdatedAt = "rule_"+id, now, now
+	if err := e.prepareRule(&rule); err != nil {
+		return nil, err
+	}
+	if err := e.Rules.CreateRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	e.invalidate()
+	return &rule, nil
+}
+
+// UpdateRule validates and replaces an existing rule
+func (e *AlertEngine) UpdateRule(ctx context.Context, id string, rule models.AlertRule) (*models.AlertRule, error) {
+	existing, err := e.Rules.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID, rule.CreatedAt, rule.UpdatedAt = id, existing.CreatedAt, time.Now().UTC()
+	if err := e.prepareRule(&rule); err != nil {
+		return nil, err
+	}
+	if err := e.Rules.UpdateRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	e.invalidate()
+	return &rule, nil
+}
+
+// DeleteRule removes a rule
+func (e *AlertEngine) DeleteRule(ctx context.Context, id string) error {
+	if err := e.Rules.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	e.invalidate()
+	return nil
+}
+
+// prepareRule applies defaults and validates the rule
+func (e *AlertEngine) prepareRule(rule *models.AlertRule) error {
+	var errs []models.FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, models.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(rule.Name) == "" {
+		add("name", "is required")
+	}
+	if rule.EventName == "" {
+		add("event_name", "is required")
+	} else if !isContractEvent(rule.EventName) {
+		add("event_name", "%s is not an event of the Token, Vault or Messenger ABI", rule.EventName)
+	}
+	if rule.ChainID != "" {
+		if _, err := config.ForTenant(e.Tenant).GetChainConfig(rule.ChainID); err != nil {
+			add("chain_id", "unknown chain ID %s", rule.ChainID)
+		}
+	}
+
+	for i, condition := range rule.Conditions {
+		field := fmt.Sprintf("conditions[%d]", i)
+		if !eventFields[condition.Field] {
+			add(field+".field", "unknown event field %q", condition.Field)
+		}
+		switch condition.Op {
+		case models.OpEq, models.OpNe:
+		case models.OpGt, models.OpGte, models.OpLt, models.OpLte:
+			if _, ok := parseNumber(condition.Value); !ok {
+				add(field+".value", "must be a number for %s", condition.Op)
+			}
+		default:
+			add(field+".op", "must be one of eq, ne, gt, gte, lt or lte")
+		}
+	}
+
+	switch {
+	case rule.WindowSeconds < 0 || rule.Count < 0 || rule.CooldownSeconds < 0:
+		add("window_seconds", "window_seconds, count and cooldown_seconds can't be negative")
+	case rule.WindowSeconds > 0 && rule.Count == 0:
+		add("count", "is required with window_seconds")
+	case rule.WindowSeconds == 0 && rule.Count > 0:
+		add("window_seconds", "is required with count")
+	}
+	if rule.GroupBy != "" {
+		if rule.WindowSeconds == 0 {
+			add("group_by", "needs window_seconds")
+		}
+		if !eventFields[rule.GroupBy] {
+			add("group_by", "unknown event field %q", rule.GroupBy)
+		}
+	}
+	if rule.WindowSeconds > 0 && rule.CooldownSeconds == 0 {
+		rule.CooldownSeconds = rule.WindowSeconds
+	}
+
+	if rule.Severity == "" {
+		rule.Severity = SeverityWarning
+	}
+	if rule.Severity != SeverityInfo && rule.Severity != SeverityWarning && rule.Severity != SeverityCritical {
+		add("severity", "must be info, warning or critical")
+	}
+	for _, name := range rule.Notifiers {
+		if !e.Dispatcher.Has(name) {
+			add("notifiers", "no notifier named %s is configured", name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &RuleValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// isContractEvent reports whether any of the bridge contracts' ABIs declares the event
+func isContractEvent(name string) bool {
+	for _, contractType := range []string{"Token", "Vault", "Router"} {
+		contractABI, err := config.GetABI(contractType)
+		if err != nil {
+			continue
+		}
+		if _, exists := contractABI.Events[name]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// eventFieldValues flattens an event into its JSON fields as strings
+func eventFieldValues(event models.EventData) map[string]string {
+	encoded, _ := json.Marshal(event)
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var raw map[string]interface{}
+	decoder.Decode(&raw)
+
+	fields := make(map[string]string, len(raw))
+	for name, value := range raw {
+		fields[name] = fmt.Sprint(value)
+	}
+	return fields
+}
+
+func matchesConditions(conditions []models.RuleCondition, fields map[string]string) bool {
+	for _, condition := range conditions {
+		if !matchesCondition(condition, fields[condition.Field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesCondition compares numerically when both sides are numbers and
+// otherwise compares strings case-insensitively, so addresses match in any case
+func matchesCondition(condition models.RuleCondition, actual string) bool {
+	left, leftOK := parseNumber(actual)
+	right, rightOK := parseNumber(condition.Value)
+	numeric := leftOK && rightOK
+
+	switch condition.Op {
+	case models.OpEq, models.OpNe:
+		equal := strings.EqualFold(actual, condition.Value)
+		if numeric {
+			equal = left.Cmp(right) == 0
+		}
+		return equal == (condition.Op == models.OpEq)
+	}
+	if !numeric {
+		return false
+	}
+	cmp := left.Cmp(right)
+	switch condition.Op {
+	case models.OpGt:
+		return cmp > 0
+	case models.OpGte:
+		return cmp >= 0
+	case models.OpLt:
+		return cmp < 0
+	case models.OpLte:
+		return cmp <= 0
+	}
+	return false
+}
+
+// parseNumber parses integers and decimals with optional exponents exactly
+// enough to compare token amounts in wei
+func parseNumber(value string) (*big.Float, bool) {
+	if value == "" {
+		return nil, false
+	}
+	number, _, err := big.ParseFloat(value, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return nil, false
+	}
+	return number, true
+}
+
+// observedEventStore passes every inserted event to an observer
+type observedEventStore struct {
+	database.EventStore
+	observe func(models.EventData)
+}
+
+// ObserveInsertedEvents wraps an event store so events inserted through it,
+// by the ingest API or the mock generator, are passed to observe
+func ObserveInsertedEvents(store database.EventStore, observe func(models.EventData)) database.EventStore {
+	return &observedEventStore{EventStore: store, observe: observe}
+}
+
+func (s *observedEventStore) InsertEvent(ctx context.Context, event *models.EventData) error {
+	if err := s.EventStore.InsertEvent(ctx, event); err != nil {
+		return err
+	}
+	s.observe(*event)
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestMatchesCondition(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     string
+		value  string
+		actual string
+		want   bool
+	}{
+		{"numeric eq", models.OpEq, "1000", "1000", true},
+		{"numeric eq with exponent", models.OpEq, "1e3", "1000", true},
+		{"numeric ne", models.OpNe, "1000", "999", true},
+		{"gt wei amount", models.OpGt, "1000e18", "1000000000000000000001", true},
+		{"gt equal amount", models.OpGt, "1000e18", "1000000000000000000000", false},
+		{"gte equal amount", models.OpGte, "1000e18", "1000000000000000000000", true},
+		{"lt", models.OpLt, "10", "9.5", true},
+		{"lte", models.OpLte, "10", "11", false},
+		{"address in another case", models.OpEq, "0xABCDEF", "0xabcdef", true},
+		{"string ne", models.OpNe, "Mint", "Burn", true},
+		{"ordering on strings", models.OpGt, "abc", "abd", false},
+		{"missing field", models.OpGt, "0", "", false},
+		{"unknown op", "between", "1", "1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := models.RuleCondition{Field: "amount", Op: tt.op, Value: tt.value}
+			if got := matchesCondition(condition, tt.actual); got != tt.want {
+				t.Fatalf("%s %s %s = %v, want %v", tt.actual, tt.op, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesConditionsOnEventFields(t *testing.T) {
+	event := models.EventData{
+		EventName:     "Mint",
+		ChainID:       "80002",
+		CallerAddress: "0xAbC0000000000000000000000000000000000001",
+		Amount:        "5000000000000000000000",
+		BlockNumber:   42,
+	}
+	fields := eventFieldValues(event)
+
+	tests := []struct {
+		name       string
+		conditions []models.RuleCondition
+		want       bool
+	}{
+		{"no conditions", nil, true},
+		{"all match", []models.RuleCondition{
+			{Field: "amount", Op: models.OpGte, Value: "1000e18"},
+			{Field: "caller_address", Op: models.OpEq, Value: "0xabc0000000000000000000000000000000000001"},
+		}, true},
+		{"one fails", []models.RuleCondition{
+			{Field: "amount", Op: models.OpGte, Value: "1000e18"},
+			{Field: "ChainId", Op: models.OpEq, Value: "11155111"},
+		}, false},
+		{"numeric field", []models.RuleCondition{{Field: "block_number", Op: models.OpGt, Value: "41"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesConditions(tt.conditions, fields); got != tt.want {
+				t.Fatalf("matchesConditions = %v, want %v (fields %v)", got, tt.want, fields)
+			}
+		})
+	}
+}
+
+func TestAlertRuleWindowAndCooldown(t *testing.T) {
+	rule := models.AlertRule{
+		ID:              "rule-1",
+		Name:            "Burst of mints",
+		EventName:       "Mint",
+		Count:           2,
+		WindowSeconds:   60,
+		GroupBy:         "caller_address",
+		CooldownSeconds: 300,
+		Severity:        "warning",
+	}
+	start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	alice := map[string]string{"caller_address": "0xa11ce"}
+	bob := map[string]string{"caller_address": "0xb0b"}
+
+	steps := []struct {
+		offset time.Duration
+		fields map[string]string
+		fires  bool
+	}{
+		{0, alice, false},
+		{10 * time.Second, alice, false},
+		{20 * time.Second, bob, false},
+		{30 * time.Second, alice, true},
+		// Still above the count but within the cooldown
+		{40 * time.Second, alice, false},
+		// Events outside the window no longer count
+		{10 * time.Minute, alice, false},
+		{10*time.Minute + time.Second, alice, false},
+		{10*time.Minute + 2*time.Second, alice, true},
+	}
+
+	engine := NewAlertEngine(nil, nil)
+	for i, step := range steps {
+		alert, fired := engine.record(rule, step.fields, start.Add(step.offset))
+		if fired != step.fires {
+			t.Fatalf("step %d fired = %v, want %v", i, fired, step.fires)
+		}
+		if fired && (alert.Title != rule.Name || alert.Severity != rule.Severity) {
+			t.Fatalf("step %d alert = %+v", i, alert)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/config"
+)
+
+// Alert severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert is a condition the backend raises for operators
+type Alert struct {
+	// Kind identifies what raised the alert, e.g. "stuck_transfer" or "rule"
+	Kind     string      `json:"kind"`
+	Severity string      `json:"severity"`
+	Title    string      `json:"title"`
+	Message  string      `json:"message"`
+	Details  interface{} `json:"details,omitempty"`
+	FiredAt  time.Time   `json:"fired_at"`
+	// Tenant is set on alerts of tenants other than the default one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// LogNotifier writes alerts to the server log
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	details, _ := json.Marshal(alert.Details)
+	log.Printf("ALERT [%s] %s: %s %s", alert.Severity, alert.Title, alert.Message, details)
+	return nil
+}
+
+// WebhookNotifier posts the alert as JSON
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, n.Client, n.URL, alert)
+}
+
+// SlackNotifier posts the alert as a Slack incoming-webhook message
+type SlackNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+var slackColors = map[string]string{
+	SeverityInfo:     "#439FE0",
+	SeverityWarning:  "warning",
+	SeverityCritical: "danger",
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	payload := map[string]interface{}{
+		"text": fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.Title),
+		"attachments": []map[string]interface{}{{
+			"color":  slackColors[alert.Severity],
+			"text":   alert.Message,
+			"footer": alert.Kind,
+			"ts":     alert.FiredAt.Unix(),
+		}},
+	}
+	return postJSON(ctx, n.Client, n.URL, payload)
+}
+
+// SMTPNotifier emails the alert
+type SMTPNotifier struct {
+	Addr string
+	// Auth is nil when the server needs no authentication
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, alert Alert) error {
+	details, _ := json.MarshalIndent(alert.Details, "", "  ")
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", n.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&body, "Subject: [%s] %s\r\n", strings.ToUpper(alert.Severity), alert.Title)
+	fmt.Fprintf(&body, "Date: %s\r\n", alert.FiredAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&body, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&body, "%s\r\n\r\n%s\r\n", alert.Message, details)
+	return smtp.SendMail(n.Addr, n.Auth, n.From, n.To, body.Bytes())
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+// FiredAlert is an alert with where it was delivered
+type FiredAlert struct {
+	Alert
+	Delivered []string          `json:"delivered"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
+
+// AlertDispatcher delivers alerts to the configured notifiers and keeps the
+// most recent ones for the admin API. It is itself a Notifier that delivers
+// to every notifier.
+type AlertDispatcher struct {
+	// Tenant labels the alerts of tenants other than the default one
+	Tenant string
+
+	names     []string
+	notifiers map[string]Notifier
+
+	mu     sync.Mutex
+	recent []FiredAlert
+}
+
+// maxRecentAlerts is how many fired alerts the dispatcher remembers
+const maxRecentAlerts = 100
+
+// NewAlertDispatcher builds the notifiers described by the config. With none
+// configured, alerts go to the log.
+func NewAlertDispatcher(configs []config.NotifierConfig) (*AlertDispatcher, error) {
+	d := &AlertDispatcher{notifiers: make(map[string]Notifier)}
+	if len(configs) == 0 {
+		configs = []config.NotifierConfig{{Name: "log", Type: "log"}}
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, nc := range configs {
+		if nc.Name == "" {
+			return nil, fmt.Errorf("every notifier needs a name")
+		}
+		if _, exists := d.notifiers[nc.Name]; exists {
+			return nil, fmt.Errorf("notifier %s is configured twice", nc.Name)
+		}
+		url := nc.URL
+		if nc.URLEnvVar != "" && os.Getenv(nc.URLEnvVar) != "" {
+			url = os.Getenv(nc.URLEnvVar)
+		}
+
+		var notifier Notifier
+		switch nc.Type {
+		case "log":
+			notifier = LogNotifier{}
+		case "webhook", "slack":
+			if url == "" {
+				return nil, fmt.Errorf("notifier %s needs a url", nc.Name)
+			}
+			if nc.Type == "webhook" {
+				notifier = &WebhookNotifier{URL: url, Client: client}
+			} else {
+				notifier = &SlackNotifier{URL: url, Client: client}
+			}
+		case "smtp":
+			if nc.SMTPHost == "" || nc.From == "" || len(nc.To) == 0 {
+				return nil, fmt.Errorf("notifier %s needs smtp_host, from and to", nc.Name)
+			}
+			port := nc.SMTPPort
+			if port == 0 {
+				port = 25
+			}
+			smtpNotifier := &SMTPNotifier{Addr: net.JoinHostPort(nc.SMTPHost, strconv.Itoa(port)), From: nc.From, To: nc.To}
+			if nc.Username != "" {
+				smtpNotifier.Auth = smtp.PlainAuth("", nc.Username, os.Getenv(nc.PasswordEnvVar), nc.SMTPHost)
+			}
+			notifier = smtpNotifier
+		default:
+			return nil, fmt.Errorf("notifier %s has unknown type %q", nc.Name, nc.Type)
+		}
+		d.names = append(d.names, nc.Name)
+		d.notifiers[nc.Name] = notifier
+	}
+	return d, nil
+}
+
+// Has reports whether a notifier with the name is configured
+func (d *AlertDispatcher) Has(name string) bool {
+	_, exists := d.notifiers[name]
+	return exists
+}
+
+// Notify delivers the alert to every notifier
+func (d *AlertDispatcher) Notify(ctx context.Context, alert Alert) error {
+	return d.NotifyVia(ctx, alert, nil)
+}
+
+// NotifyVia delivers the alert to the named notifiers, or to all of them when
+// names is empty. Every notifier is tried; the error lists those that failed.
+func (d *AlertDispatcher) NotifyVia(ctx context.Context, alert Alert, names []string) error {
+	if len(names) == 0 {
+		names = d.names
+	}
+	if d.Tenant != "" && d.Tenant != config.DefaultTenant && alert.Tenant == "" {
+		alert.Tenant = d.Tenant
+		alert.Title = "[" + d.Tenant + "] " + alert.Title
+	}
+
+	fired := FiredAlert{Alert: alert, Delivered: []string{}}
+	var failures []string
+	for _, name := range names {
+		notifier, exists := d.notifiers[name]
+		if !exists {
+			continue
+		}
+		if err := notifier.Notify(ctx, alert); err != nil {
+			if fired.Failed == nil {
+				fired.Failed = make(map[string]string)
+			}
+			fired.Failed[name] = err.Error()
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		fired.Delivered = append(fired.Delivered, name)
+	}
+
+	d.mu.Lock()
+	d.recent = append(d.recent, fired)
+	if len(d.recent) > maxRecentAlerts {
+		d.recent = d.recent[len(d.recent)-maxRecentAlerts:]
+	}
+	d.mu.Unlock()
+
+	if len(failures) > 0 {
+		return fmt.Errorf("delivery failed: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Recent returns the most recently fired alerts, newest first
+func (d *AlertDispatcher) Recent() []FiredAlert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	recent := make([]FiredAlert, len(d.recent))
+	for i, fired := range d.recent {
+		recent[len(d.recent)-1-i] = fired
+	}
+	return recent
+}
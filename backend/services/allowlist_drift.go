@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Allowlist mappings of the Messenger
+const (
+	MappingDestinationChain = "destination_chain"
+	MappingSourceChain      = "source_chain"
+	MappingSender           = "sender"
+)
+
+// Kinds of drift between a Messenger's allowlists and the declared lanes
+const (
+	DriftMissing = "missing"
+	DriftExtra   = "extra"
+)
+
+// DriftFinding is one allowlist entry on a chain's Messenger that disagrees
+// with the declared lanes
+type DriftFinding struct {
+	ChainID   string `json:"chain_id"`
+	Messenger string `json:"messenger"`
+	Mapping   string `json:"mapping"`
+	Kind      string `json:"kind"`
+	// PeerChainID is the chain the entry refers to
+	PeerChainID   string `json:"peer_chain_id"`
+	ChainSelector uint64 `json:"chain_selector,string,omitempty"`
+	Sender        string `json:"sender,omitempty"`
+	Message       string `json:"message"`
+}
+
+// DriftReport is the result of one allowlist check. Only configured chains
+// are compared, since the Messenger's mappings can't be enumerated on chain.
+type DriftReport struct {
+	CheckedAt time.Time           `json:"checked_at"`
+	Lanes     []config.LaneConfig `json:"lanes"`
+	InSync    bool                `json:"in_sync"`
+	Findings  []DriftFinding      `json:"findings"`
+	// Errors lists chains or lanes that couldn't be checked
+	Errors []string `json:"errors,omitempty"`
+}
+
+// AllowlistDriftDetector periodically reads the allowlists of every configured
+// Messenger and compares them with the lane topology declared in config
+type AllowlistDriftDetector struct {
+	Clients *ChainClients
+
+	mu     sync.RWMutex
+	report *DriftReport
+}
+
+// NewAllowlistDriftDetector creates a detector that has not run yet
+func NewAllowlistDriftDetector(clients *ChainClients) *AllowlistDriftDetector {
+	return &AllowlistDriftDetector{Clients: clients}
+}
+
+// Report returns the latest report, or nil if no check has run
+func (d *AllowlistDriftDetector) Report() *DriftReport {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.report
+}
+
+// Run checks the allowlists every configured interval until ctx is cancelled
+func (d *AllowlistDriftDetector) Run(ctx context.Context) {
+	for {
+		report := d.Check(ctx)
+		if !report.InSync {
+			log.Printf("Allowlist drift: %d findings, %d errors", len(report.Findings), len(report.Errors))
+		}
+
+		interval := time.Duration(config.GetAllowlistDriftConfig().IntervalSeconds) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// messengerPeer is a configured chain whose Messenger takes part in lanes
+type messengerPeer struct {
+	chainID   string
+	selector  uint64
+	messenger common.Address
+}
+
+// Check reads the allowlists now and stores the report
+func (d *AllowlistDriftDetector) Check(ctx context.Context) *DriftReport {
+	registry := d.Clients.Registry()
+	lanes := registry.GetLanes()
+	report := &DriftReport{CheckedAt: time.Now().UTC(), Lanes: lanes, Findings: []DriftFinding{}}
+
+	peers := make(map[string]messengerPeer)
+	var chainIDs []string
+	for _, chainID := range registry.ChainIDs() {
+		chainConfig, _ := registry.GetChainConfig(chainID)
+		messenger, err := registry.GetContractAddress(chainID, "Router")
+		if chainConfig.CCIPChainSelector == 0 || err != nil {
+			continue
+		}
+		peers[chainID] = messengerPeer{chainID: chainID, selector: chainConfig.CCIPChainSelector, messenger: common.HexToAddress(messenger)}
+		chainIDs = append(chainIDs, chainID)
+	}
+
+	declared := make(map[string]bool)
+	for _, lane := range lanes {
+		_, fromOK := peers[lane.From]
+		_, toOK := peers[lane.To]
+		if !fromOK || !toOK || lane.From == lane.To {
+			report.Errors = append(report.Errors, fmt.Sprintf("lane %s -> %s: both chains need a CCIP chain selector and a Messenger address", lane.From, lane.To))
+			continue
+		}
+		declared[lane.From+">"+lane.To] = true
+	}
+
+	messengerABI, err := config.GetABI("Router")
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		d.store(report)
+		return report
+	}
+
+	for _, chainID := range chainIDs {
+		local := peers[chainID]
+		client, err := d.Clients.Get(chainID)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("chain %s: %v", chainID, err))
+			continue
+		}
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("chain %s: failed to read block number: %v", chainID, err))
+			continue
+		}
+		opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(head)}
+		messenger := bind.NewBoundContract(local.messenger, messengerABI, client, nil, nil)
+
+		for _, peerID := range chainIDs {
+			if peerID == chainID {
+				continue
+			}
+			peer := peers[peerID]
+			checks := []struct {
+				mapping  string
+				method   string
+				arg      interface{}
+				expected bool
+			}{
+				{MappingDestinationChain, "allowlistedDestinationChains", peer.selector, declared[chainID+">"+peerID]},
+				{MappingSourceChain, "allowlistedSourceChains", peer.selector, declared[peerID+">"+chainID]},
+				{MappingSender, "allowlistedSenders", peer.messenger, declared[peerID+">"+chainID]},
+			}
+			for _, check := range checks {
+				allowed, err := callSingle[bool](opts, messenger, check.method, check.arg)
+				if err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("chain %s: %s(%s): %v", chainID, check.method, peerID, err))
+					continue
+				}
+				if allowed == check.expected {
+					continue
+				}
+				report.Findings = append(report.Findings, driftFinding(local, peer, check.mapping, check.expected))
+			}
+		}
+	}
+
+	report.InSync = len(report.Findings) == 0 && len(report.Errors) == 0
+	d.store(report)
+	return report
+}
+
+func (d *AllowlistDriftDetector) store(report *DriftReport) {
+	d.mu.Lock()
+	d.report = report
+	d.mu.Unlock()
+}
+
+// driftFinding describes an entry that should be allowlisted but isn't, or
+// is allowlisted without a declared lane
+func driftFinding(local, peer messengerPeer, mapping string, expected bool) DriftFinding {
+	finding := DriftFinding{
+		ChainID:     local.chainID,
+		Messenger:   local.messenger.Hex(),
+		Mapping:     mapping,
+		Kind:        DriftExtra,
+		PeerChainID: peer.chainID,
+	}
+	if mapping == MappingSender {
+		finding.Sender = peer.messenger.Hex()
+	} else {
+		finding.ChainSelector = peer.selector
+	}
+
+	if !expected {
+		finding.Message = fmt.Sprintf("allowlisted although no lane between %s and %s is declared", local.chainID, peer.chainID)
+		return finding
+	}
+	finding.Kind = DriftMissing
+	switch mapping {
+	case MappingDestinationChain:
+		finding.Message = fmt.Sprintf("transfers to %s revert with DestinationChainNotAllowlisted", peer.chainID)
+	case MappingSourceChain:
+		finding.Message = fmt.Sprintf("messages from %s fail on receipt with SourceChainNotAllowlisted", peer.chainID)
+	case MappingSender:
+		finding.Message = fmt.Sprintf("messages from %s's Messenger fail on receipt with SenderNotAllowlisted", peer.chainID)
+	}
+	return finding
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestAllowlistDrift(t *testing.T) {
+	messengerABI, err := config.GetABI("Router")
+	if err != nil {
+		t.Fatalf("GetABI: %v", err)
+	}
+	rpc := newFakeRPC(t)
+	t.Setenv("DRIFT_TEST_RPC_URL", rpc.URL)
+
+	// Chains 1, 2 and 3 have selectors 101, 102 and 103 and Messengers at 0x..01, 0x..02 and 0x..03
+	messengers := map[string]common.Address{}
+	chains := map[string]interface{}{}
+	for _, id := range []string{"1", "2", "3"} {
+		messengers[id] = common.HexToAddress("0x" + strings.Repeat("0", 39) + id)
+		chains[id] = map[string]interface{}{
+			"chain_id":             id,
+			"rpc_url_env_var":      "DRIFT_TEST_RPC_URL",
+			"router_contract_addr": messengers[id].Hex(),
+			"ccip_chain_selector":  100 + int(id[0]-'0'),
+		}
+	}
+	// Chain 4 has no selector, so it can't take part in lanes
+	chains["4"] = map[string]interface{}{"chain_id": "4", "rpc_url_env_var": "DRIFT_TEST_RPC_URL"}
+
+	// The declared lanes are 1 <-> 2 and 1 -> 3, which this allowlist matches.
+	// Entries read "<messenger chain> <mapping> <peer chain>".
+	inSync := []string{
+		"1 destination 2", "1 source 2", "1 sender 2", "1 destination 3",
+		"2 destination 1", "2 source 1", "2 sender 1",
+		"3 source 1", "3 sender 1",
+	}
+	lanes := []map[string]string{{"from": "1", "to": "2"}, {"from": "2", "to": "1"}, {"from": "1", "to": "3"}}
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		lanes     []map[string]string
+		// want lists findings as "<chain> <mapping> <peer> <kind>"
+		want       []string
+		wantErrors int
+	}{
+		{"in sync", inSync, lanes, nil, 0},
+		{
+			"drifted",
+			append(without(inSync, "1 destination 3", "3 sender 1"), "2 destination 3", "3 destination 2"),
+			lanes,
+			[]string{
+				"1 destination_chain 3 missing",
+				"2 destination_chain 3 extra",
+				"3 destination_chain 2 extra",
+				"3 sender 1 missing",
+			},
+			0,
+		},
+		{"undeclarable lane", inSync, append(lanes, map[string]string{"from": "1", "to": "4"}), nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTestConfig(t, map[string]interface{}{"chains": chains, "lanes": tt.lanes})
+			allowed := make(map[string]bool)
+			for _, entry := range tt.allowlist {
+				allowed[entry] = true
+			}
+
+			rpc.handle("eth_blockNumber", func([]json.RawMessage) (interface{}, error) {
+				return hexutil.Uint64(1), nil
+			})
+			rpc.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+				var call struct {
+					To    common.Address `json:"to"`
+					Input hexutil.Bytes  `json:"input"`
+				}
+				if err := json.Unmarshal(params[0], &call); err != nil {
+					return nil, err
+				}
+				method, err := messengerABI.MethodById(call.Input[:4])
+				if err != nil {
+					return nil, err
+				}
+				args, err := method.Inputs.Unpack(call.Input[4:])
+				if err != nil {
+					return nil, err
+				}
+				entry := chainOf(messengers, call.To) + " "
+				switch arg := args[0].(type) {
+				case uint64:
+					mapping := strings.TrimSuffix(strings.TrimPrefix(method.Name, "allowlisted"), "Chains")
+					entry += strings.ToLower(mapping) + " " + fmt.Sprint(arg-100)
+				case common.Address:
+					entry += "sender " + chainOf(messengers, arg)
+				}
+				if allowed[entry] {
+					return word32(1), nil
+				}
+				return word32(0), nil
+			})
+
+			report := NewAllowlistDriftDetector(NewChainClients()).Check(context.Background())
+			var got []string
+			for _, finding := range report.Findings {
+				got = append(got, fmt.Sprintf("%s %s %s %s", finding.ChainID, finding.Mapping, finding.PeerChainID, finding.Kind))
+			}
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("findings\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if len(report.Errors) != tt.wantErrors {
+				t.Fatalf("errors %v, want %d", report.Errors, tt.wantErrors)
+			}
+			if report.InSync != (len(tt.want) == 0 && tt.wantErrors == 0) {
+				t.Fatalf("in_sync = %v", report.InSync)
+			}
+		})
+	}
+}
+
+func TestDriftFindingMessages(t *testing.T) {
+	local := messengerPeer{chainID: "1", selector: 101, messenger: common.HexToAddress("0x01")}
+	peer := messengerPeer{chainID: "2", selector: 102, messenger: common.HexToAddress("0x02")}
+	tests := []struct {
+		mapping  string
+		expected bool
+		wantKind string
+		wantMsg  string
+	}{
+		{MappingDestinationChain, true, DriftMissing, "DestinationChainNotAllowlisted"},
+		{MappingSourceChain, true, DriftMissing, "SourceChainNotAllowlisted"},
+		{MappingSender, true, DriftMissing, "SenderNotAllowlisted"},
+		{MappingSender, false, DriftExtra, "no lane between 1 and 2"},
+	}
+	for _, tt := range tests {
+		finding := driftFinding(local, peer, tt.mapping, tt.expected)
+		if finding.Kind != tt.wantKind || !strings.Contains(finding.Message, tt.wantMsg) {
+			t.Errorf("%s expected=%v: %s %q", tt.mapping, tt.expected, finding.Kind, finding.Message)
+		}
+		// Sender findings name the peer Messenger, chain findings its selector
+		if (tt.mapping == MappingSender) != (finding.Sender == peer.messenger.Hex() && finding.ChainSelector == 0) {
+			t.Errorf("%s finding names sender %q and selector %d", tt.mapping, finding.Sender, finding.ChainSelector)
+		}
+	}
+}
+
+// without returns entries minus the removed ones
+func without(entries []string, removed ...string) []string {
+	var kept []string
+	for _, entry := range entries {
+		keep := true
+		for _, r := range removed {
+			keep = keep && entry != r
+		}
+		if keep {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// chainOf returns the chain whose Messenger is at address
+func chainOf(messengers map[string]common.Address, address common.Address) string {
+	for id, messenger := range messengers {
+		if messenger == address {
+			return id
+		}
+	}
+	return "?"
+}
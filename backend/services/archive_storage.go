@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"backend/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ArchiveStorage holds archive files by object name, e.g. "events/MessageSent/2025-01-02T000000Z-1.ndjson.gz"
+type ArchiveStorage interface {
+	// Put stores size bytes read from r under name
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	// Open reads the object stored under name
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// LocalArchiveStorage keeps archives as files under Directory
+type LocalArchiveStorage struct {
+	Directory string
+}
+
+func (s *LocalArchiveStorage) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	file := filepath.Join(s.Directory, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed write never leaves a partial archive
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func (s *LocalArchiveStorage) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.Directory, filepath.FromSlash(name)))
+}
+
+// S3ArchiveStorage keeps archives in a bucket of an S3-compatible store, under Prefix
+type S3ArchiveStorage struct {
+	Client *minio.Client
+	Bucket string
+	Prefix string
+}
+
+func (s *S3ArchiveStorage) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	_, err := s.Client.PutObject(ctx, s.Bucket, path.Join(s.Prefix, name), r, size, minio.PutObjectOptions{ContentType: "application/gzip"})
+	return err
+}
+
+func (s *S3ArchiveStorage) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.Client.GetObject(ctx, s.Bucket, path.Join(s.Prefix, name), minio.GetObjectOptions{})
+}
+
+// NewArchiveStorage creates the storage selected by the archive config
+func NewArchiveStorage(cfg config.ArchiveConfig) (ArchiveStorage, error) {
+	switch cfg.Type {
+	case "local":
+		return &LocalArchiveStorage{Directory: cfg.Directory}, nil
+	case "s3":
+		if cfg.Endpoint == "" || cfg.Bucket == "" {
+			return nil, fmt.Errorf("s3 archive storage needs an endpoint and a bucket")
+		}
+		client, err := minio.New(cfg.Endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(os.Getenv(cfg.AccessKeyEnvVar), os.Getenv(cfg.SecretKeyEnvVar), ""),
+			Secure: !cfg.Insecure,
+			Region: cfg.Region,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &S3ArchiveStorage{Client: client, Bucket: cfg.Bucket, Prefix: cfg.Prefix}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive storage type %q, expected local or s3", cfg.Type)
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"backend/database"
+	"backend/models"
+)
+
+// auditGenesisHash is the previous hash of the first entry in the log
+var auditGenesisHash = hex.EncodeToString(make([]byte, sha256.Size))
+
+// AuditRecord describes a privileged action to add to the audit log
+type AuditRecord struct {
+	Action   string
+	Actor    string
+	ClientIP string
+	Target   string
+	// Payload is marshalled to JSON; secrets must be removed before recording
+	Payload         interface{}
+	TransactionHash string
+	Err             error
+}
+
+// AuditVerification is the result of checking the audit log's hash chain
+type AuditVerification struct {
+	Valid   bool   `json:"valid"`
+	Entries uint64 `json:"entries"`
+	// BrokenAt is the first entry that fails verification
+	BrokenAt uint64 `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	LastHash string `json:"last_hash,omitempty"`
+}
+
+// AuditLog appends hash-chained entries to the audit store. Appends are
+// serialised so each entry links to the one before it; if another server
+// appends first, the entry is rebuilt on the new tail.
+type AuditLog struct {
+	Store database.AuditStore
+
+	mu sync.Mutex
+}
+
+// NewAuditLog creates an audit log backed by store
+func NewAuditLog(store database.AuditStore) *AuditLog {
+	return &AuditLog{Store: store}
+}
+
+// Record appends an entry for a privileged action. Failures are logged and
+// returned; the action itself has already happened by the time it is recorded.
+func (a *AuditLog) Record(ctx context.Context, record AuditRecord) (*models.AuditEntry, error) {
+	if a == nil {
+		return nil, nil
+	}
+	entry, err := a.record(ctx, record)
+	if err != nil {
+		log.Printf("Error recording %s by %s in the audit log: %v", record.Action, record.Actor, err)
+	}
+	return entry, err
+}
+
+func (a *AuditLog) record(ctx context.Context, record AuditRecord) (*models.AuditEntry, error) {
+	var payload json.RawMessage
+	if record.Payload != nil {
+		encoded, err := json.Marshal(record.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode payload: %v", err)
+		}
+		payload = encoded
+	}
+
+	entry := &models.AuditEntry{
+		Time:            time.Now().UTC().Truncate(time.Millisecond),
+		Action:          record.Action,
+		Actor:           record.Actor,
+		ClientIP:        record.ClientIP,
+		Target:          record.Target,
+		Payload:         payload,
+		TransactionHash: record.TransactionHash,
+		Outcome:         models.AuditSuccess,
+	}
+	if record.Err != nil {
+		entry.Outcome = models.AuditFailure
+		entry.Error = record.Err.Error()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for attempt := 0; attempt < 5; attempt++ {
+		last, err := a.Store.LastAudit(ctx)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			entry.Sequence, entry.PrevHash = 1, auditGenesisHash
+		case err != nil:
+			return nil, err
+		default:
+			entry.Sequence, entry.PrevHash = last.Sequence+1, last.Hash
+		}
+		if entry.Hash, err = HashAuditEntry(*entry); err != nil {
+			return nil, err
+		}
+
+		err = a.Store.AppendAudit(ctx, entry)
+		if !errors.Is(err, database.ErrConflict) {
+			return entry, err
+		}
+	}
+	return nil, fmt.Errorf("gave up after repeated sequence conflicts")
+}
+
+// HashAuditEntry returns the SHA-256 of an entry's JSON encoding with the
+// Hash field cleared. The encoding covers the previous hash, chaining entries.
+func HashAuditEntry(entry models.AuditEntry) (string, error) {
+	entry.Hash = ""
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify walks the whole log and checks that sequence numbers are contiguous,
+// each entry links to the previous hash and each hash matches its contents
+func (a *AuditLog) Verify(ctx context.Context) (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+	prevHash := auditGenesisHash
+	errBroken := errors.New("broken chain")
+
+	err := a.Store.EachAudit(ctx, database.AuditFilter{}, func(entry models.AuditEntry) error {
+		result.Entries++
+		hash, err := HashAuditEntry(entry)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.Sequence != result.Entries:
+			result.Reason = fmt.Sprintf("expected sequence %d, found %d", result.Entries, entry.Sequence)
+		case entry.PrevHash != prevHash:
+			result.Reason = "previous hash does not match the preceding entry"
+		case hash != entry.Hash:
+			result.Reason = "hash does not match the entry's contents"
+		default:
+			prevHash = entry.Hash
+			return nil
+		}
+		result.Valid = false
+		result.BrokenAt = entry.Sequence
+		return errBroken
+	})
+	if err != nil && !errors.Is(err, errBroken) {
+		return nil, err
+	}
+	if result.Valid {
+		result.LastHash = prevHash
+	}
+	return result, nil
+}
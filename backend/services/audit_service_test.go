@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/database"
+	"backend/models"
+)
+
+// tamperedAuditStore edits entries as they are read back
+type tamperedAuditStore struct {
+	database.AuditStore
+	edit func(entry *models.AuditEntry) bool
+}
+
+func (s *tamperedAuditStore) EachAudit(ctx context.Context, filter database.AuditFilter, fn func(models.AuditEntry) error) error {
+	return s.AuditStore.EachAudit(ctx, filter, func(entry models.AuditEntry) error {
+		if !s.edit(&entry) {
+			return nil
+		}
+		return fn(entry)
+	})
+}
+
+func TestAuditLogChain(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryAuditStore()
+	audit := NewAuditLog(store)
+
+	records := []AuditRecord{
+		{Action: "api_key.create", Actor: "bootstrap-admin", Payload: map[string]string{"name": "ingest"}},
+		{Action: "contract.operation", Actor: "ak_1", TransactionHash: "0xabc"},
+		{Action: "config.reload", Actor: "ak_1", Err: errors.New("invalid config")},
+	}
+	var entries []*models.AuditEntry
+	for _, record := range records {
+		entry, err := audit.Record(ctx, record)
+		if err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if entries[0].PrevHash != auditGenesisHash || entries[1].PrevHash != entries[0].Hash || entries[2].PrevHash != entries[1].Hash {
+		t.Fatal("entries are not linked by their previous hashes")
+	}
+	if entries[2].Outcome != models.AuditFailure || entries[2].Error != "invalid config" {
+		t.Fatalf("failed action recorded as %s %q", entries[2].Outcome, entries[2].Error)
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(entry *models.AuditEntry) bool
+		valid    bool
+		brokenAt uint64
+	}{
+		{"untouched", func(*models.AuditEntry) bool { return true }, true, 0},
+		{"edited actor", func(entry *models.AuditEntry) bool {
+			if entry.Sequence == 2 {
+				entry.Actor = "someone-else"
+			}
+			return true
+		}, false, 2},
+		{"rehashed edit", func(entry *models.AuditEntry) bool {
+			if entry.Sequence == 2 {
+				entry.Outcome = models.AuditFailure
+				entry.Hash, _ = HashAuditEntry(*entry)
+			}
+			return true
+		}, false, 3},
+		{"deleted entry", func(entry *models.AuditEntry) bool { return entry.Sequence != 2 }, false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verification, err := NewAuditLog(&tamperedAuditStore{AuditStore: store, edit: tt.edit}).Verify(ctx)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if verification.Valid != tt.valid || verification.BrokenAt != tt.brokenAt {
+				t.Fatalf("Verify = valid %v broken at %d (%s), want valid %v broken at %d",
+					verification.Valid, verification.BrokenAt, verification.Reason, tt.valid, tt.brokenAt)
+			}
+			if tt.valid && verification.LastHash != entries[2].Hash {
+				t.Fatalf("LastHash = %s, want %s", verification.LastHash, entries[2].Hash)
+			}
+		})
+	}
+}
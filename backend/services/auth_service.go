@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+)
+
+// Headers used by API key and signed requests
+const (
+	HeaderAPIKey    = "X-API-Key"
+	HeaderKeyID     = "X-Key-Id"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+// BootstrapAdminKeyID and BootstrapIngestKeyID identify the admin key and the
+// event monitors' ingest key supplied through the environment
+const (
+	BootstrapAdminKeyID  = "bootstrap-admin"
+	BootstrapIngestKeyID = "bootstrap-ingest"
+)
+
+// ErrUnauthorized is returned when a request carries missing or invalid credentials
+var ErrUnauthorized = errors.New("unauthorized")
+
+// AuthService issues API keys and authenticates requests that carry them.
+//
+// Plain requests send "<key id>.<secret>" in the X-API-Key header. Signed requests
+// send X-Key-Id, X-Timestamp (unix seconds), X-Nonce and X-Signature, where the
+// signature is hex(HMAC-SHA256(signing key, canonical request)) and the signing
+// key is HMAC-SHA256(secret, "request-signing"). The server stores the signing
+// key encrypted with a key only it holds, so reading the key store is not
+// enough to forge signatures.
+type AuthService struct {
+	Keys    database.APIKeyStore
+	Nonces  database.NonceStore
+	MaxSkew time.Duration
+
+	adminKeyHash  string
+	ingestKeyHash string
+	// sealer encrypts signing keys; nil when no encryption key is configured,
+	// which disables signed requests
+	sealer cipher.AEAD
+}
+
+// NewAuthService creates an auth service using the default tenant's bootstrap keys
+func NewAuthService(keys database.APIKeyStore, nonces database.NonceStore) *AuthService {
+	return NewTenantAuthService(config.DefaultTenant, keys, nonces)
+}
+
+// NewTenantAuthService creates an auth service using a tenant's bootstrap
+// admin and ingest keys. Keys and nonces come from the tenant's own stores, so
+// a key only authenticates requests to the tenant that issued it.
+func NewTenantAuthService(tenant string, keys database.APIKeyStore, nonces database.NonceStore) *AuthService {
+	authConfig := config.GetAuthConfig()
+	service := &AuthService{
+		Keys:    keys,
+		Nonces:  nonces,
+		MaxSkew: time.Duration(authConfig.SignatureMaxSkewSeconds) * time.Second,
+	}
+	registry := config.ForTenant(tenant)
+	if adminKey := os.Getenv(registry.AdminKeyEnvVar); adminKey != "" {
+		service.adminKeyHash = hashSecret(adminKey)
+	}
+	// The event monitors send this key as is, so it need not be an issued key
+	if ingestKey := os.Getenv(registry.IngestKeyEnvVar); ingestKey != "" {
+		service.ingestKeyHash = hashSecret(ingestKey)
+	}
+	if encryptionKey := os.Getenv(authConfig.SigningKeyEnvVar); encryptionKey != "" {
+		service.sealer = newSealer(encryptionKey)
+	} else {
+		log.Printf("%s is not set, so API keys can't sign requests", authConfig.SigningKeyEnvVar)
+	}
+	return service
+}
+
+// CreateKey generates a new API key and returns it along with the plaintext
+// credential, which is never stored and cannot be retrieved again
+func (s *AuthService) CreateKey(ctx context.Context, name string, scopes []string) (*models.APIKey, string, error) {
+	for _, scope := range scopes {
+		if scope != models.ScopeIngest && scope != models.ScopeRead && scope != models.ScopeAdmin {
+			return nil, "", fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("at least one scope is required")
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.APIKey{
+		ID:        "ak_" + id,
+		Name:      name,
+		KeyHash:   hashSecret(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if s.sealer != nil {
+		if key.SigningKey, err = s.seal(signingKeyFor(secret)); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := s.Keys.CreateKey(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to store API key: %v", err)
+	}
+	return key, key.ID + "." + secret, nil
+}
+
+// Authenticate validates the credentials on a request and returns the matching key
+func (s *AuthService) Authenticate(ctx context.Context, r *http.Request, body []byte) (*models.APIKey, error) {
+	if r.Header.Get(HeaderSignature) != "" {
+		return s.authenticateSignature(ctx, r, body)
+	}
+
+	credential := r.Header.Get(HeaderAPIKey)
+	if credential == "" {
+		credential = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if credential == "" {
+		return nil, fmt.Errorf("%w: missing API key", ErrUnauthorized)
+	}
+
+	if s.adminKeyHash != "" && secureEqual(hashSecret(credential), s.adminKeyHash) {
+		return bootstrapAdminKey(), nil
+	}
+	if s.ingestKeyHash != "" && secureEqual(hashSecret(credential), s.ingestKeyHash) {
+		return bootstrapIngestKey(), nil
+	}
+
+	id, secret, found := strings.Cut(credential, ".")
+	if !found {
+		return nil, fmt.Errorf("%w: malformed API key", ErrUnauthorized)
+	}
+	key, err := s.activeKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !secureEqual(hashSecret(secret), key.KeyHash) {
+		return nil, fmt.Errorf("%w: invalid API key", ErrUnauthorized)
+	}
+
+	s.touch(ctx, key)
+	return key, nil
+}
+
+func (s *AuthService) authenticateSignature(ctx context.Context, r *http.Request, body []byte) (*models.APIKey, error) {
+	id := r.Header.Get(HeaderKeyID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	if id == "" || timestamp == "" || nonce == "" {
+		return nil, fmt.Errorf("%w: signed requests require %s, %s and %s", ErrUnauthorized, HeaderKeyID, HeaderTimestamp, HeaderNonce)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid timestamp", ErrUnauthorized)
+	}
+	signedAt := time.Unix(unix, 0)
+	if skew := time.Since(signedAt); skew > s.MaxSkew || skew < -s.MaxSkew {
+		return nil, fmt.Errorf("%w: timestamp outside the allowed window", ErrUnauthorized)
+	}
+
+	key, err := s.activeKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.sealer == nil || key.SigningKey == "" {
+		return nil, fmt.Errorf("%w: API key %s can't sign requests", ErrUnauthorized, key.ID)
+	}
+	signingKey, err := s.open(key.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("stored signing key of %s can't be decrypted: %v", key.ID, err)
+	}
+	expected := computeSignature(signingKey, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	if !secureEqual(expected, r.Header.Get(HeaderSignature)) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrUnauthorized)
+	}
+
+	// Only record the nonce once the signature checks out so that unsigned
+	// garbage cannot burn nonces for legitimate clients
+	fresh, err := s.Nonces.UseNonce(ctx, key.ID, nonce, signedAt.Add(s.MaxSkew))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record nonce: %v", err)
+	}
+	if !fresh {
+		return nil, fmt.Errorf("%w: nonce has already been used", ErrUnauthorized)
+	}
+
+	s.touch(ctx, key)
+	return key, nil
+}
+
+func (s *AuthService) activeKey(ctx context.Context, id string) (*models.APIKey, error) {
+	key, err := s.Keys.GetKey(ctx, id)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown API key", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API key: %v", err)
+	}
+	if !key.Active() {
+		return nil, fmt.Errorf("%w: API key has been revoked", ErrUnauthorized)
+	}
+	return key, nil
+}
+
+func (s *AuthService) touch(ctx context.Context, key *models.APIKey) {
+	now := time.Now().UTC()
+	key.LastUsedAt = &now
+	if err := s.Keys.TouchKey(ctx, key.ID, now); err != nil {
+		// Usage tracking is best effort and must not fail the request
+		log.Printf("Failed to update last use of API key %s: %v", key.ID, err)
+	}
+}
+
+// SignRequest adds signature headers to a request using a "<key id>.<secret>" credential
+func SignRequest(r *http.Request, credential string, body []byte) error {
+	id, secret, found := strings.Cut(credential, ".")
+	if !found {
+		return fmt.Errorf("malformed API key")
+	}
+	nonce, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	r.Header.Set(HeaderKeyID, id)
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, computeSignature(signingKeyFor(secret), r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+	return nil
+}
+
+// signingKeyFor derives the request signing key from a key's secret. It differs
+// from the stored secret hash, so the hash can't be used to sign.
+func signingKeyFor(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("request-signing"))
+	return mac.Sum(nil)
+}
+
+// newSealer creates an AES-256-GCM cipher keyed by the SHA-256 of the encryption key
+func newSealer(encryptionKey string) cipher.AEAD {
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, _ := aes.NewCipher(key[:])
+	sealer, _ := cipher.NewGCM(block)
+	return sealer
+}
+
+// seal encrypts a signing key for storage as hex(nonce || ciphertext)
+func (s *AuthService) seal(signingKey []byte) (string, error) {
+	nonce := make([]byte, s.sealer.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %v", err)
+	}
+	return hex.EncodeToString(s.sealer.Seal(nonce, nonce, signingKey, nil)), nil
+}
+
+// open decrypts a signing key sealed by seal
+func (s *AuthService) open(sealed string) ([]byte, error) {
+	data, err := hex.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < s.sealer.NonceSize() {
+		return nil, errors.New("sealed signing key is too short")
+	}
+	nonce, ciphertext := data[:s.sealer.NonceSize()], data[s.sealer.NonceSize():]
+	return s.sealer.Open(nil, nonce, ciphertext, nil)
+}
+
+// computeSignature signs the canonical form of a request:
+// METHOD \n REQUEST-URI \n TIMESTAMP \n NONCE \n hex(sha256(body))
+func computeSignature(signingKey []byte, method, requestURI, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{method, requestURI, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func bootstrapAdminKey() *models.APIKey {
+	return &models.APIKey{ID: BootstrapAdminKeyID, Name: "Bootstrap admin", Scopes: []string{models.ScopeAdmin}}
+}
+
+func bootstrapIngestKey() *models.APIKey {
+	return &models.APIKey{ID: BootstrapIngestKeyID, Name: "Bootstrap ingest", Scopes: []string{models.ScopeIngest}}
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/models"
+)
+
+// signedRequest is a request along with the body it was signed over
+type signedRequest struct {
+	*http.Request
+	body []byte
+}
+
+func newTestAuthService(t *testing.T, signing bool) (*AuthService, *database.MemoryAPIKeyStore) {
+	t.Helper()
+	keys := database.NewMemoryAPIKeyStore()
+	service := &AuthService{Keys: keys, Nonces: database.NewMemoryNonceStore(), MaxSkew: 5 * time.Minute}
+	if signing {
+		service.sealer = newSealer("test-signing-key")
+	}
+	return service, keys
+}
+
+func TestAuthenticatePlainKey(t *testing.T) {
+	service, keys := newTestAuthService(t, false)
+	ctx := context.Background()
+	key, credential, err := service.CreateKey(ctx, "ingest", []string{models.ScopeIngest})
+	if err != nil {
+		t.Fatalf("CreateKey: %v", err)
+	}
+	revoked, revokedCredential, err := service.CreateKey(ctx, "revoked", []string{models.ScopeIngest})
+	if err != nil {
+		t.Fatalf("CreateKey: %v", err)
+	}
+	if err := keys.RevokeKey(ctx, revoked.ID, time.Now()); err != nil {
+		t.Fatalf("RevokeKey: %v", err)
+	}
+	id, _, _ := strings.Cut(credential, ".")
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		wantErr bool
+	}{
+		{"api key header", "X-API-Key", credential, false},
+		{"bearer token", "Authorization", "Bearer " + credential, false},
+		{"missing", "", "", true},
+		{"malformed", "X-API-Key", "no-separator", true},
+		{"wrong secret", "X-API-Key", id + ".not-the-secret", true},
+		{"unknown key", "X-API-Key", "ak_unknown.secret", true},
+		{"revoked key", "X-API-Key", revokedCredential, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/events", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			got, err := service.Authenticate(ctx, r, nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("err = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if got.ID != key.ID {
+				t.Fatalf("authenticated %s, want %s", got.ID, key.ID)
+			}
+		})
+	}
+}
+
+func TestAuthenticateBootstrapKeys(t *testing.T) {
+	service, _ := newTestAuthService(t, false)
+	service.adminKeyHash = hashSecret("admin-secret")
+	service.ingestKeyHash = hashSecret("ingest-secret")
+
+	tests := []struct {
+		credential string
+		wantID     string
+		wantScope  string
+	}{
+		{"admin-secret", BootstrapAdminKeyID, models.ScopeAdmin},
+		{"ingest-secret", BootstrapIngestKeyID, models.ScopeIngest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantID, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/events/mint", nil)
+			r.Header.Set(HeaderAPIKey, tt.credential)
+			key, err := service.Authenticate(context.Background(), r, nil)
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if key.ID != tt.wantID || len(key.Scopes) != 1 || key.Scopes[0] != tt.wantScope {
+				t.Fatalf("authenticated %s with scopes %v, want %s with %s", key.ID, key.Scopes, tt.wantID, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestAuthenticateSignedRequest(t *testing.T) {
+	service, keys := newTestAuthService(t, true)
+	ctx := context.Background()
+	key, credential, err := service.CreateKey(ctx, "signer", []string{models.ScopeIngest})
+	if err != nil {
+		t.Fatalf("CreateKey: %v", err)
+	}
+	body := []byte(`{"amount":"1000"}`)
+
+	tests := []struct {
+		name   string
+		modify func(r *signedRequest)
+		reason string
+	}{
+		{"valid", nil, ""},
+		{"tampered body", func(r *signedRequest) { r.body = []byte(`{"amount":"9999"}`) }, "invalid signature"},
+		{"tampered path", func(r *signedRequest) { r.URL.Path = "/api/events/burn" }, "invalid signature"},
+		{"stale timestamp", func(r *signedRequest) {
+			r.Header.Set(HeaderTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+		}, "outside the allowed window"},
+		{"missing nonce", func(r *signedRequest) { r.Header.Del(HeaderNonce) }, "require"},
+		{"signed with the stored hash", func(r *signedRequest) {
+			stored, _ := keys.GetKey(ctx, key.ID)
+			keyHash, _ := hex.DecodeString(stored.KeyHash)
+			r.Header.Set(HeaderSignature, computeSignature(keyHash, r.Method, r.URL.RequestURI(),
+				r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce), r.body))
+		}, "invalid signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &signedRequest{Request: httptest.NewRequest("POST", "/api/events/mint", nil), body: body}
+			if err := SignRequest(r.Request, credential, body); err != nil {
+				t.Fatalf("SignRequest: %v", err)
+			}
+			if tt.modify != nil {
+				tt.modify(r)
+			}
+			got, err := service.Authenticate(ctx, r.Request, r.body)
+			if tt.reason != "" {
+				if !errors.Is(err, ErrUnauthorized) || !strings.Contains(err.Error(), tt.reason) {
+					t.Fatalf("err = %v, want unauthorized with %q", err, tt.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if got.ID != key.ID {
+				t.Fatalf("authenticated %s, want %s", got.ID, key.ID)
+			}
+		})
+	}
+}
+
+func TestSignedRequestNonceReplay(t *testing.T) {
+	service, _ := newTestAuthService(t, true)
+	ctx := context.Background()
+	_, credential, err := service.CreateKey(ctx, "signer", []string{models.ScopeIngest})
+	if err != nil {
+		t.Fatalf("CreateKey: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/api/events", nil)
+	if err := SignRequest(r, credential, nil); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, r, nil); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, r, nil); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("replay err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestSignedRequestWithoutSigningKey(t *testing.T) {
+	// Keys created while no encryption key was configured have no signing key
+	unsealed, _ := newTestAuthService(t, false)
+	ctx := context.Background()
+	_, credential, err := unsealed.CreateKey(ctx, "plain", []string{models.ScopeIngest})
+	if err != nil {
+		t.Fatalf("CreateKey: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/api/events", nil)
+	if err := SignRequest(r, credential, nil); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	if _, err := unsealed.Authenticate(ctx, r, nil); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestSigningKeyIsNotStoredInPlaintext(t *testing.T) {
+	service, keys := newTestAuthService(t, true)
+	ctx := context.Background()
+	key, credential, err := service.CreateKey(ctx, "signer", []string{models.ScopeIngest})
+	if err != nil {
+		t.Fatalf("CreateKey: %v", err)
+	}
+	_, secret, _ := strings.Cut(credential, ".")
+
+	stored, err := keys.GetKey(ctx, key.ID)
+	if err != nil {
+		t.Fatalf("GetKey: %v", err)
+	}
+	signingKey := signingKeyFor(secret)
+	if strings.Contains(stored.SigningKey, hex.EncodeToString(signingKey)) || stored.KeyHash == hex.EncodeToString(signingKey) {
+		t.Fatal("stored key exposes the signing key")
+	}
+	opened, err := service.open(stored.SigningKey)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if string(opened) != string(signingKey) {
+		t.Fatal("sealed signing key does not round-trip")
+	}
+
+	other := &AuthService{sealer: newSealer("another-signing-key")}
+	if _, err := other.open(stored.SigningKey); err == nil {
+		t.Fatal("signing key opened with the wrong encryption key")
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"sync"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ChainClients lazily dials and caches one RPC client per configured chain
+// so request handlers don't open a new connection on every call
+type ChainClients struct {
+	// Tenant selects the chain registry; empty is the default tenant
+	Tenant string
+
+	mu      sync.Mutex
+	clients map[string]*ethclient.Client
+}
+
+// NewChainClients creates an empty client pool for the default tenant's chains
+func NewChainClients() *ChainClients {
+	return NewTenantChainClients(config.DefaultTenant)
+}
+
+// NewTenantChainClients creates an empty client pool for a tenant's chains
+func NewTenantChainClients(tenant string) *ChainClients {
+	return &ChainClients{Tenant: tenant, clients: make(map[string]*ethclient.Client)}
+}
+
+// Registry returns the chain and contract registry of the pool's tenant
+func (p *ChainClients) Registry() *config.TenantConfig {
+	return config.ForTenant(p.Tenant)
+}
+
+// Get returns the cached RPC client for a chain, dialing it on first use
+func (p *ChainClients) Get(chainID string) (*ethclient.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, exists := p.clients[chainID]; exists {
+		return client, nil
+	}
+
+	client, err := p.Registry().GetEthereumConnection(chainID)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[chainID] = client
+	return client, nil
+}
+
+// Drop closes and forgets the client for a chain so the next Get redials
+func (p *ChainClients) Drop(chainID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, exists := p.clients[chainID]; exists {
+		client.Close()
+		delete(p.clients, chainID)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"backend/config"
+	"backend/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrNoSigner is returned when an operation targets a chain without a transaction engine
+var ErrNoSigner = errors.New("no signer configured for chain")
+
+// ErrDryRunReverted is returned when an operation would revert on chain
+var ErrDryRunReverted = errors.New("dry run reverted")
+
+// AdminOperations lists the owner operations the admin API may submit for each
+// contract type
+var AdminOperations = map[string][]string{
+	"Token":  {"pause", "unpause", "setVaultAddress", "increaseTokenSupply"},
+	"Router": {"allowlistDestinationChain", "allowlistSourceChain", "allowlistSender", "withdraw", "withdrawToken"},
+}
+
+// AdminResult is the outcome of an admin operation. Transaction is nil for dry runs.
+type AdminResult struct {
+	ChainID      string              `json:"chain_id"`
+	ContractType string              `json:"contract_type"`
+	Contract     string              `json:"contract"`
+	Operation    string              `json:"operation"`
+	Signature    string              `json:"signature"`
+	From         string              `json:"from"`
+	Data         string              `json:"data"`
+	DryRun       bool                `json:"dry_run"`
+	Transaction  *models.Transaction `json:"transaction,omitempty"`
+}
+
+// ContractAdmin builds owner operations from the contract ABIs, simulates them
+// with eth_call from the chain's signer and submits them through its
+// transaction engine
+type ContractAdmin struct {
+	Engines *TxEngines
+	// Tenant selects the contract registry; empty is the default tenant
+	Tenant string
+}
+
+// Execute runs an admin operation. With dryRun set it stops after the simulation.
+func (a *ContractAdmin) Execute(ctx context.Context, chainID, contractType, operation string, args map[string]string, dryRun bool) (*AdminResult, error) {
+	if !isAdminOperation(contractType, operation) {
+		return nil, fmt.Errorf("%w: %s is not an admin operation of %s", ErrInvalidCall, operation, contractType)
+	}
+	contractABI, err := config.GetABI(contractType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+	method, exists := contractABI.Methods[operation]
+	if !exists {
+		return nil, fmt.Errorf("%w: the %s ABI has no %s function, so the deployed contract cannot perform it", ErrInvalidCall, contractType, operation)
+	}
+	addressHex, err := config.ForTenant(a.Tenant).GetContractAddress(chainID, contractType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+
+	values, err := packArgs(method, args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+	data, err := contractABI.Pack(operation, values...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+
+	engine, exists := a.Engines.Get(chainID)
+	if !exists {
+		return nil, fmt.Errorf("%w %s", ErrNoSigner, chainID)
+	}
+
+	contract := common.HexToAddress(addressHex)
+	result := &AdminResult{
+		ChainID:      chainID,
+		ContractType: contractType,
+		Contract:     contract.Hex(),
+		Operation:    operation,
+		Signature:    method.Sig,
+		From:         engine.Address().Hex(),
+		Data:         hexutil.Encode(data),
+		DryRun:       dryRun,
+	}
+
+	request := TxRequest{To: contract, Data: data, Purpose: "admin:" + contractType + "." + operation}
+	if err := engine.Simulate(ctx, request); err != nil {
+		if !isRevert(err) {
+			return result, fmt.Errorf("dry run failed: %v", err)
+		}
+		return result, fmt.Errorf("%w: %s", ErrDryRunReverted, revertReason(err, contractABI))
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	tx, err := engine.Submit(ctx, request)
+	result.Transaction = tx
+	return result, err
+}
+
+func isAdminOperation(contractType, operation string) bool {
+	for _, allowed := range AdminOperations[contractType] {
+		if allowed == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Simulate runs a transaction request with eth_call from the signer against
+// the latest block, returning the error it would revert with
+func (e *TxEngine) Simulate(ctx context.Context, request TxRequest) error {
+	_, err := e.client.CallContract(ctx, ethereum.CallMsg{From: e.from, To: &request.To, Value: request.Value, Data: request.Data}, nil)
+	return err
+}
+
+// isRevert tells a call that reverted apart from one that failed to run
+func isRevert(err error) bool {
+	var dataErr rpc.DataError
+	return errors.As(err, &dataErr) || strings.Contains(err.Error(), "execution reverted")
+}
+
+// revertReason decodes the revert data of a failed call into a readable
+// reason: Error(string) messages and the contract's custom errors
+func revertReason(err error, contractABI abi.ABI) string {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return err.Error()
+	}
+	encoded, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return err.Error()
+	}
+	data, decodeErr := hexutil.Decode(encoded)
+	if decodeErr != nil || len(data) < 4 {
+		return err.Error()
+	}
+
+	if reason, unpackErr := abi.UnpackRevert(data); unpackErr == nil {
+		return reason
+	}
+	for name, abiError := range contractABI.Errors {
+		if !bytes.Equal(abiError.ID[:4], data[:4]) {
+			continue
+		}
+		values, unpackErr := abiError.Unpack(data)
+		if unpackErr != nil {
+			return name
+		}
+		args, _ := values.([]interface{})
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = fmt.Sprint(arg)
+		}
+		return name + "(" + strings.Join(parts, ", ") + ")"
+	}
+	return err.Error()
+}
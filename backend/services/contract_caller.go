@@ -0,0 +1,399 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxCachedCalls bounds the call result cache
+const maxCachedCalls = 4096
+
+// ErrInvalidCall is returned when a call names an unknown or state-changing
+// function or its arguments don't match the ABI
+var ErrInvalidCall = errors.New("invalid call")
+
+// CallOutput is one decoded return value
+type CallOutput struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// CallResult is the decoded result of a read-only contract call
+type CallResult struct {
+	ChainID      string       `json:"chain_id"`
+	ContractType string       `json:"contract_type"`
+	Address      string       `json:"address"`
+	Method       string       `json:"method"`
+	Signature    string       `json:"signature"`
+	BlockTag     string       `json:"block_tag"`
+	BlockNumber  uint64       `json:"block_number,omitempty"`
+	Cached       bool         `json:"cached"`
+	Outputs      []CallOutput `json:"outputs"`
+}
+
+// ContractCaller runs view and pure functions of the configured contracts
+// through the backend's RPC connections. Results at a fixed block never
+// change, so they are cached per block; calls against the pending block are not.
+type ContractCaller struct {
+	Clients *ChainClients
+
+	mu    sync.Mutex
+	cache map[string]*list.Element
+	order *list.List
+}
+
+type cachedCall struct {
+	key    string
+	result CallResult
+}
+
+// NewContractCaller creates a caller with an empty result cache
+func NewContractCaller(clients *ChainClients) *ContractCaller {
+	return &ContractCaller{Clients: clients, cache: make(map[string]*list.Element), order: list.New()}
+}
+
+// Call runs a read-only function. Arguments are keyed by the ABI input name,
+// or arg<i> for unnamed inputs. The block tag is latest, pending, safe,
+// finalized, earliest or a block number; empty means latest.
+func (c *ContractCaller) Call(ctx context.Context, chainID, contractType, methodName string, args map[string]string, blockTag string) (*CallResult, error) {
+	contractABI, err := config.GetABI(contractType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+	method, exists := contractABI.Methods[methodName]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s has no function %s", ErrInvalidCall, contractType, methodName)
+	}
+	if !method.IsConstant() {
+		return nil, fmt.Errorf("%w: %s is not a view or pure function", ErrInvalidCall, methodName)
+	}
+	address, err := c.Clients.Registry().GetContractAddress(chainID, contractType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+
+	values, err := packArgs(method, args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+	input, err := contractABI.Pack(methodName, values...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCall, err)
+	}
+
+	if blockTag == "" {
+		blockTag = "latest"
+	}
+	client, err := c.Clients.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CallResult{
+		ChainID:      chainID,
+		ContractType: contractType,
+		Address:      common.HexToAddress(address).Hex(),
+		Method:       methodName,
+		Signature:    method.Sig,
+		BlockTag:     blockTag,
+	}
+	msg := ethereum.CallMsg{To: ptrAddress(common.HexToAddress(address)), Data: input}
+
+	var output []byte
+	cacheKey := ""
+	if blockTag == "pending" {
+		if output, err = client.PendingCallContract(ctx, msg); err != nil {
+			return nil, err
+		}
+	} else {
+		if result.BlockNumber, err = resolveBlockTag(ctx, client, blockTag); err != nil {
+			return nil, err
+		}
+		cacheKey = fmt.Sprintf("%s|%s|%d|%x", chainID, result.Address, result.BlockNumber, input)
+		if cached, found := c.cached(cacheKey); found {
+			cached.BlockTag = blockTag
+			cached.Cached = true
+			return &cached, nil
+		}
+		if output, err = client.CallContract(ctx, msg, new(big.Int).SetUint64(result.BlockNumber)); err != nil {
+			return nil, err
+		}
+	}
+
+	unpacked, err := method.Outputs.Unpack(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s output: %v", methodName, err)
+	}
+	result.Outputs = make([]CallOutput, len(unpacked))
+	for i, value := range unpacked {
+		name := method.Outputs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("output%d", i)
+		}
+		result.Outputs[i] = CallOutput{Name: name, Type: method.Outputs[i].Type.String(), Value: jsonValue(value)}
+	}
+	if cacheKey != "" {
+		c.store(cacheKey, *result)
+	}
+	return result, nil
+}
+
+// blockReader is the part of an RPC client needed to resolve block tags
+type blockReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// resolveBlockTag turns a block tag into a block number so results can be cached by it
+func resolveBlockTag(ctx context.Context, client blockReader, tag string) (uint64, error) {
+	var number rpc.BlockNumber
+	switch tag {
+	case "latest":
+		return client.BlockNumber(ctx)
+	case "safe":
+		number = rpc.SafeBlockNumber
+	case "finalized":
+		number = rpc.FinalizedBlockNumber
+	case "earliest":
+		return 0, nil
+	default:
+		parsed, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			if parsed, err = strconv.ParseUint(tag, 10, 64); err != nil {
+				return 0, fmt.Errorf("%w: block must be latest, pending, safe, finalized, earliest or a block number", ErrInvalidCall)
+			}
+		}
+		return parsed, nil
+	}
+
+	header, err := client.HeaderByNumber(ctx, big.NewInt(number.Int64()))
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Uint64(), nil
+}
+
+func (c *ContractCaller) cached(key string) (CallResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.cache[key]
+	if !exists {
+		return CallResult{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cachedCall).result, true
+}
+
+// store caches a result, evicting the least recently used one when full
+func (c *ContractCaller) store(key string, result CallResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.cache[key]; exists {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.cache[key] = c.order.PushFront(&cachedCall{key: key, result: result})
+	if c.order.Len() > maxCachedCalls {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.cache, oldest.Value.(*cachedCall).key)
+	}
+}
+
+// packArgs converts the string arguments of a request to the Go values the
+// ABI encoder expects for each input
+func packArgs(method abi.Method, args map[string]string) ([]interface{}, error) {
+	known := make(map[string]bool, len(method.Inputs))
+	values := make([]interface{}, len(method.Inputs))
+	for i, input := range method.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		known[name] = true
+
+		raw, exists := args[name]
+		if !exists {
+			return nil, fmt.Errorf("missing argument %s (%s)", name, input.Type)
+		}
+		value, err := parseArg(input.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", name, err)
+		}
+		values[i] = value
+	}
+
+	var unknown []string
+	for name := range args {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown arguments: %s", strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// parseArg reads one argument. Arrays are written as JSON arrays.
+func parseArg(t abi.Type, raw string) (interface{}, error) {
+	switch t.T {
+	case abi.SliceTy, abi.ArrayTy:
+		var items []json.RawMessage
+		if err := json.Unmarshal([]byte(raw), &items); err != nil {
+			return nil, fmt.Errorf("must be a JSON array")
+		}
+		if t.T == abi.ArrayTy && len(items) != t.Size {
+			return nil, fmt.Errorf("must have %d elements", t.Size)
+		}
+		value := reflect.New(t.GetType()).Elem()
+		if t.T == abi.SliceTy {
+			value = reflect.MakeSlice(t.GetType(), len(items), len(items))
+		}
+		for i, item := range items {
+			var element string
+			if err := json.Unmarshal(item, &element); err != nil {
+				element = string(item)
+			}
+			parsed, err := parseArg(*t.Elem, element)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			value.Index(i).Set(reflect.ValueOf(parsed))
+		}
+		return value.Interface(), nil
+	case abi.AddressTy:
+		if !common.IsHexAddress(raw) {
+			return nil, fmt.Errorf("must be an address")
+		}
+		return common.HexToAddress(raw), nil
+	case abi.BoolTy:
+		return strconv.ParseBool(raw)
+	case abi.StringTy:
+		return raw, nil
+	case abi.BytesTy:
+		return hexutil.Decode(raw)
+	case abi.FixedBytesTy:
+		decoded, err := hexutil.Decode(raw)
+		if err != nil {
+			return nil, err
+		}
+		if len(decoded) != t.Size {
+			return nil, fmt.Errorf("must be %d bytes", t.Size)
+		}
+		value := reflect.New(t.GetType()).Elem()
+		reflect.Copy(value, reflect.ValueOf(decoded))
+		return value.Interface(), nil
+	case abi.IntTy, abi.UintTy:
+		return parseInteger(t, raw)
+	}
+	return nil, fmt.Errorf("type %s is not supported", t)
+}
+
+// parseInteger reads a decimal or 0x-prefixed integer into the Go type the ABI
+// encoder uses for its size: *big.Int above 64 bits, a sized int otherwise
+func parseInteger(t abi.Type, raw string) (interface{}, error) {
+	number, ok := new(big.Int).SetString(raw, 0)
+	if !ok {
+		return nil, fmt.Errorf("must be an integer")
+	}
+	if t.T == abi.UintTy && number.Sign() < 0 {
+		return nil, fmt.Errorf("must not be negative")
+	}
+	bits := number.BitLen()
+	if t.T == abi.IntTy {
+		bits++
+	}
+	if bits > t.Size {
+		return nil, fmt.Errorf("does not fit in %s", t)
+	}
+
+	goType := t.GetType()
+	if goType == reflect.TypeOf(&big.Int{}) {
+		return number, nil
+	}
+	value := reflect.New(goType).Elem()
+	if t.T == abi.UintTy {
+		value.SetUint(number.Uint64())
+	} else {
+		value.SetInt(number.Int64())
+	}
+	return value.Interface(), nil
+}
+
+// jsonValue converts a decoded ABI value to something that marshals without
+// losing precision: integers larger than 53 bits become decimal strings and
+// byte arrays become hex
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			bytes := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(bytes), rv)
+			return "0x" + hex.EncodeToString(bytes)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = jsonValue(rv.Index(i).Interface())
+		}
+		return items
+	case reflect.Struct:
+		fields := make(map[string]interface{}, rv.NumField())
+		for i := 0; i < rv.NumField(); i++ {
+			fields[abiFieldName(rv.Type().Field(i))] = jsonValue(rv.Field(i).Interface())
+		}
+		return fields
+	}
+	return value
+}
+
+// abiFieldName recovers the ABI component name of a decoded tuple field
+func abiFieldName(field reflect.StructField) string {
+	if tag := field.Tag.Get("json"); tag != "" {
+		return tag
+	}
+	return field.Name
+}
+
+func ptrAddress(address common.Address) *common.Address {
+	return &address
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// fakeRPC is a JSON-RPC endpoint answering the methods in handlers and
+// counting the calls to each
+type fakeRPC struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]func(params []json.RawMessage) (interface{}, error)
+	calls    map[string]int
+}
+
+func newFakeRPC(t *testing.T) *fakeRPC {
+	t.Helper()
+	f := &fakeRPC{handlers: make(map[string]func([]json.RawMessage) (interface{}, error)), calls: make(map[string]int)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f.mu.Lock()
+		f.calls[request.Method]++
+		handler, exists := f.handlers[request.Method]
+		f.mu.Unlock()
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		if !exists {
+			response["error"] = map[string]interface{}{"code": -32601, "message": "method not found: " + request.Method}
+		} else if result, err := handler(request.Params); err != nil {
+			response["error"] = map[string]interface{}{"code": 3, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeRPC) handle(method string, handler func(params []json.RawMessage) (interface{}, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[method] = handler
+}
+
+func (f *fakeRPC) callCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// loadTestConfig loads a configuration with one chain, 1337, whose RPC is url
+// and whose contracts are at the given addresses
+func loadTestConfig(t *testing.T, url string, chain map[string]interface{}) {
+	t.Helper()
+	chain["chain_id"] = "1337"
+	chain["rpc_url_env_var"] = "SERVICES_TEST_RPC_URL"
+	t.Setenv("SERVICES_TEST_RPC_URL", url)
+	writeTestConfig(t, map[string]interface{}{"chains": map[string]interface{}{"1337": chain}})
+}
+
+// writeTestConfig loads the given configuration
+func writeTestConfig(t *testing.T, configuration map[string]interface{}) {
+	t.Helper()
+	contents, err := json.Marshal(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitFromFile(path); err != nil {
+		t.Fatalf("InitFromFile: %v", err)
+	}
+}
+
+// word32 encodes n as a 32-byte ABI word
+func word32(n uint64) string {
+	return fmt.Sprintf("0x%064x", n)
+}
+
+func TestContractCallerCachesPerBlock(t *testing.T) {
+	rpc := newFakeRPC(t)
+	loadTestConfig(t, rpc.URL, map[string]interface{}{"token_contract_addr": "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"})
+
+	var head atomic.Uint64
+	rpc.handle("eth_blockNumber", func([]json.RawMessage) (interface{}, error) {
+		return hexutil.Uint64(head.Load()), nil
+	})
+	// The supply equals the block the call was made at, so stale results show
+	rpc.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+		var block string
+		if err := json.Unmarshal(params[1], &block); err != nil {
+			return nil, err
+		}
+		if block == "pending" {
+			return word32(head.Load() + 1), nil
+		}
+		number, err := hexutil.DecodeUint64(block)
+		if err != nil {
+			return nil, err
+		}
+		return word32(number), nil
+	})
+
+	caller := NewContractCaller(NewChainClients())
+	tests := []struct {
+		name       string
+		head       uint64
+		block      string
+		wantSupply uint64
+		wantCached bool
+		wantCalls  int
+	}{
+		{"first call", 10, "", 10, false, 1},
+		{"same block", 10, "latest", 10, true, 1},
+		{"new block", 11, "latest", 11, false, 2},
+		{"earlier block still cached", 11, "10", 10, true, 2},
+		{"pending is never cached", 11, "pending", 12, false, 3},
+		{"pending again", 11, "pending", 12, false, 4},
+	}
+	for _, tt := range tests {
+		head.Store(tt.head)
+		result, err := caller.Call(context.Background(), "1337", "Token", "totalSupply", nil, tt.block)
+		if err != nil {
+			t.Fatalf("%s: Call: %v", tt.name, err)
+		}
+		supply := fmt.Sprint(result.Outputs[0].Value)
+		if supply != fmt.Sprint(tt.wantSupply) || result.Cached != tt.wantCached {
+			t.Fatalf("%s: supply %s cached %v, want %d cached %v", tt.name, supply, result.Cached, tt.wantSupply, tt.wantCached)
+		}
+		if calls := rpc.callCount("eth_call"); calls != tt.wantCalls {
+			t.Fatalf("%s: %d eth_calls, want %d", tt.name, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestContractCallerEvictsLeastRecentlyUsed(t *testing.T) {
+	caller := NewContractCaller(nil)
+	for i := 0; i < maxCachedCalls; i++ {
+		caller.store(fmt.Sprint(i), CallResult{BlockNumber: uint64(i)})
+	}
+	// Reading the oldest entry makes the second oldest the next to go
+	if _, found := caller.cached("0"); !found {
+		t.Fatal("entry 0 missing before the cache was full")
+	}
+	caller.store("new", CallResult{})
+
+	if _, found := caller.cached("1"); found {
+		t.Fatal("least recently used entry 1 was kept")
+	}
+	for _, key := range []string{"0", "2", "new"} {
+		if _, found := caller.cached(key); !found {
+			t.Fatalf("entry %s was evicted", key)
+		}
+	}
+	if caller.order.Len() != maxCachedCalls || len(caller.cache) != maxCachedCalls {
+		t.Fatalf("cache holds %d/%d entries, want %d", caller.order.Len(), len(caller.cache), maxCachedCalls)
+	}
+}
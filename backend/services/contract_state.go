@@ -0,0 +1,346 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ContractState is a snapshot of a contract's on-chain state at one block.
+// Fields that don't apply to the contract type are omitted; reads that failed
+// are listed in Errors rather than failing the whole snapshot.
+type ContractState struct {
+	BlockNumber     uint64 `json:"blockNumber"`
+	Owner           string `json:"owner,omitempty"`
+	Paused          *bool  `json:"paused,omitempty"`
+	TotalSupply     string `json:"totalSupply,omitempty"`
+	AvailableSupply string `json:"availableSupply,omitempty"`
+	// VaultBalance is the token balance held by the chain's vault
+	VaultBalance string `json:"vaultBalance,omitempty"`
+	// LinkBalance is the Messenger's LINK balance, which pays CCIP fees
+	LinkBalance string            `json:"linkBalance,omitempty"`
+	Allowlist   []AllowlistStatus `json:"allowlist,omitempty"`
+	Deployment  *DeploymentInfo   `json:"deployment,omitempty"`
+	Errors      []string          `json:"errors,omitempty"`
+}
+
+// AllowlistStatus tells whether the Messenger accepts messages to and from
+// another configured chain, and from that chain's Messenger
+type AllowlistStatus struct {
+	ChainID           string `json:"chainId"`
+	ChainSelector     uint64 `json:"chainSelector,string"`
+	Destination       bool   `json:"destination"`
+	Source            bool   `json:"source"`
+	Sender            string `json:"sender,omitempty"`
+	SenderAllowlisted bool   `json:"senderAllowlisted"`
+}
+
+// DeploymentInfo describes the code deployed at a contract address and
+// whether it implements every function of the ABI the backend serves
+type DeploymentInfo struct {
+	// Block is the block the contract was deployed in; 0 if it couldn't be found
+	Block        uint64 `json:"block,omitempty"`
+	BytecodeHash string `json:"bytecodeHash"`
+	CodeSize     int    `json:"codeSize"`
+	ABIMatches   bool   `json:"abiMatches"`
+	// MissingFunctions are ABI functions whose selectors don't appear in the bytecode
+	MissingFunctions []string `json:"missingFunctions,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+type cachedState struct {
+	state   ContractState
+	expires time.Time
+}
+
+// Deployment block searches that fail, typically because the node has pruned
+// old state, are retried after a delay that doubles up to the maximum
+const (
+	deploymentRetryDelay    = time.Minute
+	maxDeploymentRetryDelay = time.Hour
+)
+
+// deploymentFailure remembers a failed deployment block search
+type deploymentFailure struct {
+	err      string
+	failures int
+	retryAt  time.Time
+}
+
+// ContractStateService reads live state for the contract details API. Snapshots
+// are cached for TTL; deployment blocks never change and are cached for good.
+// Failed deployment block searches are cached too, with a backoff, so a node
+// without archive state isn't asked for a full search on every request.
+type ContractStateService struct {
+	Clients *ChainClients
+	TTL     time.Duration
+
+	mu                 sync.Mutex
+	states             map[string]cachedState
+	deployments        map[string]uint64
+	deploymentFailures map[string]deploymentFailure
+}
+
+// NewContractStateService creates a service that caches snapshots for 10 seconds
+func NewContractStateService(clients *ChainClients) *ContractStateService {
+	return &ContractStateService{
+		Clients:            clients,
+		TTL:                10 * time.Second,
+		states:             make(map[string]cachedState),
+		deployments:        make(map[string]uint64),
+		deploymentFailures: make(map[string]deploymentFailure),
+	}
+}
+
+// State returns the live state of a configured contract
+func (s *ContractStateService) State(ctx context.Context, chainID, contractType string) (*ContractState, error) {
+	key := chainID + "/" + contractType
+	s.mu.Lock()
+	cached, exists := s.states[key]
+	s.mu.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		state := cached.state
+		return &state, nil
+	}
+
+	contractABI, err := config.GetABI(contractType)
+	if err != nil {
+		return nil, err
+	}
+	addressHex, err := s.Clients.Registry().GetContractAddress(chainID, contractType)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.Clients.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block number: %v", err)
+	}
+
+	address := common.HexToAddress(addressHex)
+	state := &ContractState{BlockNumber: head}
+	reader := &stateReader{
+		opts:     &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(head)},
+		client:   client,
+		contract: bind.NewBoundContract(address, contractABI, client, nil, nil),
+		state:    state,
+	}
+
+	if owner, ok := read[common.Address](reader, "owner"); ok {
+		state.Owner = owner.Hex()
+	}
+	switch contractType {
+	case "Token":
+		if paused, ok := read[bool](reader, "paused"); ok {
+			state.Paused = &paused
+		}
+		if supply, ok := read[*big.Int](reader, "totalSupply"); ok {
+			state.TotalSupply = supply.String()
+		}
+		if supply, ok := read[*big.Int](reader, "availableSupply"); ok {
+			state.AvailableSupply = supply.String()
+		}
+		s.readVaultBalance(reader, chainID)
+	case "Vault":
+		s.readVaultBalance(reader, chainID)
+	case "Router":
+		s.readLinkBalance(reader, chainID, address)
+		s.readAllowlist(reader, chainID)
+	}
+
+	state.Deployment = s.deployment(ctx, chainID, address, contractABI, head)
+
+	s.mu.Lock()
+	s.states[key] = cachedState{state: *state, expires: time.Now().Add(s.TTL)}
+	s.mu.Unlock()
+	return state, nil
+}
+
+// stateReader runs the calls of one snapshot at the same block and collects their errors
+type stateReader struct {
+	opts     *bind.CallOpts
+	client   bind.ContractCaller
+	contract *bind.BoundContract
+	state    *ContractState
+}
+
+func read[T any](r *stateReader, method string, args ...interface{}) (T, bool) {
+	return readFrom[T](r, r.contract, method, args...)
+}
+
+func readFrom[T any](r *stateReader, contract *bind.BoundContract, method string, args ...interface{}) (T, bool) {
+	value, err := callSingle[T](r.opts, contract, method, args...)
+	if err != nil {
+		r.state.Errors = append(r.state.Errors, fmt.Sprintf("%s: %v", method, err))
+		return value, false
+	}
+	return value, true
+}
+
+// readVaultBalance reads how many tokens the chain's vault holds
+func (s *ContractStateService) readVaultBalance(r *stateReader, chainID string) {
+	tokenAddress, err := s.Clients.Registry().GetContractAddress(chainID, "Token")
+	if err != nil {
+		r.state.Errors = append(r.state.Errors, fmt.Sprintf("vault balance: %v", err))
+		return
+	}
+	vaultAddress, err := s.Clients.Registry().GetContractAddress(chainID, "Vault")
+	if err != nil {
+		r.state.Errors = append(r.state.Errors, fmt.Sprintf("vault balance: %v", err))
+		return
+	}
+	token := bind.NewBoundContract(common.HexToAddress(tokenAddress), parsedERC20ABI, r.client, nil, nil)
+	if balance, ok := readFrom[*big.Int](r, token, "balanceOf", common.HexToAddress(vaultAddress)); ok {
+		r.state.VaultBalance = balance.String()
+	}
+}
+
+// readLinkBalance reads the Messenger's LINK balance
+func (s *ContractStateService) readLinkBalance(r *stateReader, chainID string, messenger common.Address) {
+	chainConfig, err := s.Clients.Registry().GetChainConfig(chainID)
+	if err != nil || chainConfig.LinkTokenAddr == "" {
+		r.state.Errors = append(r.state.Errors, fmt.Sprintf("LINK balance: chain %s has no LINK token configured", chainID))
+		return
+	}
+	link := bind.NewBoundContract(common.HexToAddress(chainConfig.LinkTokenAddr), parsedERC20ABI, r.client, nil, nil)
+	if balance, ok := readFrom[*big.Int](r, link, "balanceOf", messenger); ok {
+		r.state.LinkBalance = balance.String()
+	}
+}
+
+// readAllowlist checks the Messenger's allowlists against every other
+// configured chain. The mappings can't be enumerated on chain, so only
+// configured chains and their Messengers are checked.
+func (s *ContractStateService) readAllowlist(r *stateReader, chainID string) {
+	registry := s.Clients.Registry()
+	for _, otherID := range registry.ChainIDs() {
+		other, err := registry.GetChainConfig(otherID)
+		if otherID == chainID || err != nil || other.CCIPChainSelector == 0 {
+			continue
+		}
+
+		status := AllowlistStatus{ChainID: otherID, ChainSelector: other.CCIPChainSelector}
+		status.Destination, _ = read[bool](r, "allowlistedDestinationChains", other.CCIPChainSelector)
+		status.Source, _ = read[bool](r, "allowlistedSourceChains", other.CCIPChainSelector)
+		if sender, err := registry.GetContractAddress(otherID, "Router"); err == nil {
+			status.Sender = common.HexToAddress(sender).Hex()
+			status.SenderAllowlisted, _ = read[bool](r, "allowlistedSenders", common.HexToAddress(sender))
+		}
+		r.state.Allowlist = append(r.state.Allowlist, status)
+	}
+}
+
+// deployment hashes the deployed bytecode, checks it against the ABI and
+// finds the block the contract was deployed in
+func (s *ContractStateService) deployment(ctx context.Context, chainID string, address common.Address, contractABI abi.ABI, head uint64) *DeploymentInfo {
+	client, err := s.Clients.Get(chainID)
+	if err != nil {
+		return &DeploymentInfo{Error: err.Error()}
+	}
+	code, err := client.CodeAt(ctx, address, new(big.Int).SetUint64(head))
+	if err != nil {
+		return &DeploymentInfo{Error: fmt.Sprintf("failed to read code: %v", err)}
+	}
+
+	info := &DeploymentInfo{
+		BytecodeHash: crypto.Keccak256Hash(code).Hex(),
+		CodeSize:     len(code),
+	}
+	if len(code) == 0 {
+		info.Error = "no contract is deployed at this address"
+		return info
+	}
+
+	info.MissingFunctions = missingFunctions(code, contractABI)
+	info.ABIMatches = len(info.MissingFunctions) == 0
+
+	key := chainID + "/" + address.Hex() + "/" + info.BytecodeHash
+	s.mu.Lock()
+	block, found := s.deployments[key]
+	failure, failed := s.deploymentFailures[key]
+	s.mu.Unlock()
+	if found {
+		info.Block = block
+		return info
+	}
+	if failed && time.Now().Before(failure.retryAt) {
+		info.Error = failure.err
+		return info
+	}
+
+	block, err = findDeploymentBlock(ctx, client, address, head)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		delay := deploymentRetryDelay << failure.failures
+		if delay > maxDeploymentRetryDelay || delay <= 0 {
+			delay = maxDeploymentRetryDelay
+		}
+		info.Error = fmt.Sprintf("failed to find deployment block: %v", err)
+		s.deploymentFailures[key] = deploymentFailure{err: info.Error, failures: failure.failures + 1, retryAt: time.Now().Add(delay)}
+		return info
+	}
+	delete(s.deploymentFailures, key)
+	s.deployments[key] = block
+	info.Block = block
+	return info
+}
+
+// codeReader is the part of an RPC client needed to inspect deployed code
+type codeReader interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// findDeploymentBlock binary searches for the first block with code at the
+// address. Reading code at old blocks needs an archive node.
+func findDeploymentBlock(ctx context.Context, client codeReader, address common.Address, head uint64) (uint64, error) {
+	low, high := uint64(0), head
+	for low < high {
+		mid := low + (high-low)/2
+		code, err := client.CodeAt(ctx, address, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, err
+		}
+		if len(code) > 0 {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
+// missingFunctions lists ABI functions whose 4-byte selector is never pushed
+// by the bytecode. Solidity's dispatcher compares calldata against each
+// selector with a PUSH, so a missing selector means the ABI doesn't match.
+// Selectors with leading zero bytes are pushed with a shorter PUSH.
+func missingFunctions(code []byte, contractABI abi.ABI) []string {
+	var missing []string
+	for _, method := range contractABI.Methods {
+		selector := bytes.TrimLeft(method.ID, "\x00")
+		if len(selector) == 0 {
+			continue
+		}
+		needle := append([]byte{0x5f + byte(len(selector))}, selector...)
+		if !bytes.Contains(code, needle) {
+			missing = append(missing, method.Sig+" "+hexutil.Encode(method.ID))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
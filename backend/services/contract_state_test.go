@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// deployedCode is code that dispatches every selector of the ABI, as
+// Solidity's PUSH4 <selector> comparisons do
+func deployedCode(contractABI abi.ABI) []byte {
+	var code []byte
+	for _, method := range contractABI.Methods {
+		code = append(code, 0x63)
+		code = append(code, method.ID...)
+	}
+	return code
+}
+
+// codeAtBlocks is a codeReader whose contract appears at deployedAt
+type codeAtBlocks struct {
+	deployedAt uint64
+	err        error
+	reads      int
+}
+
+func (c *codeAtBlocks) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	c.reads++
+	if c.err != nil {
+		return nil, c.err
+	}
+	if blockNumber.Uint64() < c.deployedAt {
+		return nil, nil
+	}
+	return []byte{0x60, 0x80}, nil
+}
+
+func TestFindDeploymentBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		deployedAt uint64
+		head       uint64
+		err        error
+	}{
+		{"genesis", 0, 100, nil},
+		{"middle", 37, 100, nil},
+		{"head", 100, 100, nil},
+		{"pruned state", 37, 100, errors.New("missing trie node")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &codeAtBlocks{deployedAt: tt.deployedAt, err: tt.err}
+			block, err := findDeploymentBlock(context.Background(), client, common.Address{}, tt.head)
+			if tt.err != nil {
+				if err == nil {
+					t.Fatalf("found block %d, want the read error", block)
+				}
+				return
+			}
+			if err != nil || block != tt.deployedAt {
+				t.Fatalf("found block %d, %v, want %d", block, err, tt.deployedAt)
+			}
+			// A binary search over 101 blocks needs at most 7 reads
+			if client.reads > 7 {
+				t.Fatalf("read code %d times", client.reads)
+			}
+		})
+	}
+}
+
+func TestMissingFunctions(t *testing.T) {
+	transfer := abi.NewMethod("transfer", "transfer", abi.Function, "nonpayable", false, false, nil, nil)
+	paused := abi.NewMethod("paused", "paused", abi.Function, "view", false, false, nil, nil)
+	// A selector with a leading zero byte is dispatched with a PUSH3
+	leadingZero := abi.Method{Name: "zero", Sig: "zero()", ID: []byte{0x00, 0x12, 0x34, 0x56}}
+	contractABI := abi.ABI{Methods: map[string]abi.Method{"transfer": transfer, "paused": paused, "zero": leadingZero}}
+
+	tests := []struct {
+		name string
+		code []byte
+		want []string
+	}{
+		{"every selector", append(append([]byte{0x63}, transfer.ID...), append([]byte{0x63}, paused.ID...)...), nil},
+		{"missing paused", append([]byte{0x63}, transfer.ID...), []string{"paused() " + hexutil.Encode(paused.ID)}},
+		// The selector bytes alone, e.g. in data, don't count as dispatch
+		{"selector without PUSH4", append([]byte{0x00}, paused.ID...), []string{"paused() " + hexutil.Encode(paused.ID), "transfer() " + hexutil.Encode(transfer.ID)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := append(append([]byte{}, tt.code...), 0x62, 0x12, 0x34, 0x56)
+			got := missingFunctions(code, contractABI)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("missing %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// Solidity never pushes the zero byte, so a PUSH4 of it doesn't count
+	if got := missingFunctions([]byte{0x63, 0x00, 0x12, 0x34, 0x56}, abi.ABI{Methods: map[string]abi.Method{"zero": leadingZero}}); len(got) != 1 {
+		t.Fatalf("missing %v, want zero()", got)
+	}
+}
+
+func TestContractStateToken(t *testing.T) {
+	const (
+		tokenAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		vaultAddress = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+		owner        = "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB"
+		deployedAt   = 5
+	)
+	rpc := newFakeRPC(t)
+	loadTestConfig(t, rpc.URL, map[string]interface{}{"token_contract_addr": tokenAddress, "vault_contract_addr": vaultAddress})
+	tokenABI, err := config.GetABI("Token")
+	if err != nil {
+		t.Fatalf("GetABI: %v", err)
+	}
+	code := deployedCode(tokenABI)
+
+	rpc.handle("eth_blockNumber", func([]json.RawMessage) (interface{}, error) {
+		return hexutil.Uint64(20), nil
+	})
+	rpc.handle("eth_getCode", func(params []json.RawMessage) (interface{}, error) {
+		var block string
+		json.Unmarshal(params[1], &block)
+		if number, _ := hexutil.DecodeUint64(block); number < deployedAt {
+			return "0x", nil
+		}
+		return hexutil.Bytes(code), nil
+	})
+	results := map[string]string{
+		"owner":       "0x" + strings.Repeat("0", 24) + strings.ToLower(owner[2:]),
+		"paused":      word32(1),
+		"totalSupply": word32(1_000_000),
+		"balanceOf":   word32(250),
+	}
+	rpc.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+		var call struct {
+			Input hexutil.Bytes `json:"input"`
+		}
+		if err := json.Unmarshal(params[0], &call); err != nil {
+			return nil, err
+		}
+		method, err := tokenABI.MethodById(call.Input[:4])
+		if err != nil {
+			return nil, err
+		}
+		if result, exists := results[method.Name]; exists {
+			return result, nil
+		}
+		return nil, fmt.Errorf("execution reverted")
+	})
+
+	service := NewContractStateService(NewChainClients())
+	state, err := service.State(context.Background(), "1337", "Token")
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if state.BlockNumber != 20 || state.Owner != owner || state.Paused == nil || !*state.Paused ||
+		state.TotalSupply != "1000000" || state.VaultBalance != "250" || state.AvailableSupply != "" {
+		t.Fatalf("state %+v", state)
+	}
+	// The failed read is reported without failing the snapshot
+	if len(state.Errors) != 1 || !strings.HasPrefix(state.Errors[0], "availableSupply:") {
+		t.Fatalf("errors %v, want the availableSupply read", state.Errors)
+	}
+	deployment := state.Deployment
+	if deployment == nil || deployment.Block != deployedAt || !deployment.ABIMatches || deployment.CodeSize != len(code) ||
+		deployment.BytecodeHash != crypto.Keccak256Hash(code).Hex() {
+		t.Fatalf("deployment %+v", deployment)
+	}
+
+	// Snapshots are served from the cache until the TTL passes
+	calls := rpc.callCount("eth_call")
+	if _, err := service.State(context.Background(), "1337", "Token"); err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if rpc.callCount("eth_call") != calls {
+		t.Fatal("cached snapshot was read again")
+	}
+}
+
+func TestContractStateDeploymentBackoff(t *testing.T) {
+	const tokenAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	rpc := newFakeRPC(t)
+	loadTestConfig(t, rpc.URL, map[string]interface{}{"token_contract_addr": tokenAddress})
+
+	var pruned atomic.Bool
+	pruned.Store(true)
+	rpc.handle("eth_getCode", func(params []json.RawMessage) (interface{}, error) {
+		var block string
+		json.Unmarshal(params[1], &block)
+		if number, _ := hexutil.DecodeUint64(block); number < 100 && pruned.Load() {
+			return nil, fmt.Errorf("missing trie node")
+		}
+		return "0x6080", nil
+	})
+
+	service := NewContractStateService(NewChainClients())
+	address := common.HexToAddress(tokenAddress)
+	deploy := func() *DeploymentInfo {
+		return service.deployment(context.Background(), "1337", address, abi.ABI{}, 100)
+	}
+
+	if info := deploy(); info.Block != 0 || !strings.Contains(info.Error, "missing trie node") {
+		t.Fatalf("deployment %+v, want the search error", info)
+	}
+	reads := rpc.callCount("eth_getCode")
+	// Within the retry delay the failure is served without searching again
+	if info := deploy(); info.Error == "" || rpc.callCount("eth_getCode") != reads+1 {
+		t.Fatalf("deployment %+v after %d code reads, want the cached error after one", info, rpc.callCount("eth_getCode")-reads)
+	}
+	key := "1337/" + address.Hex() + "/" + crypto.Keccak256Hash([]byte{0x60, 0x80}).Hex()
+	if failure := service.deploymentFailures[key]; failure.failures != 1 || time.Until(failure.retryAt) > deploymentRetryDelay {
+		t.Fatalf("failure %+v, want one with a %v delay", failure, deploymentRetryDelay)
+	}
+
+	// Once the delay has passed, a successful search is cached for good
+	pruned.Store(false)
+	failure := service.deploymentFailures[key]
+	failure.retryAt = time.Now()
+	service.deploymentFailures[key] = failure
+	if info := deploy(); info.Error != "" || info.Block != 0 {
+		t.Fatalf("deployment %+v, want block 0", info)
+	}
+	if _, failed := service.deploymentFailures[key]; failed || len(service.deployments) != 1 {
+		t.Fatalf("failures %v and deployments %v after a successful search", service.deploymentFailures, service.deployments)
+	}
+}
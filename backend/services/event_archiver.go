@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+)
+
+// ErrInvalidRestore is returned when a restore request can't be run as given
+var ErrInvalidRestore = errors.New("invalid restore")
+
+// archiveBatchSize is how many events are written to one archive file. Events
+// sharing the last created_at are kept in the same file, so it may hold a few more.
+const archiveBatchSize = 10000
+
+// archiveTimeLayout formats times in archive object names
+const archiveTimeLayout = "20060102T150405Z"
+
+// errArchiveBatchFull stops reading events once a batch is complete
+var errArchiveBatchFull = errors.New("archive batch full")
+
+// RestoreResult lists the archives a restore read and counts the events it stored
+type RestoreResult struct {
+	Archives []string `json:"archives"`
+	Events   int64    `json:"events"`
+}
+
+// EventArchiver applies the retention policies: it writes events past their
+// policy's age to gzip-compressed NDJSON archive files, records the files in
+// the archive manifest and then removes the events from the event store.
+type EventArchiver struct {
+	Events   database.EventStore
+	Archives database.ArchiveStore
+	Storage  ArchiveStorage
+
+	// mu runs one archive or restore at a time
+	mu sync.Mutex
+}
+
+// NewEventArchiver creates an archiver moving events from events to storage
+func NewEventArchiver(events database.EventStore, archives database.ArchiveStore, storage ArchiveStorage) *EventArchiver {
+	return &EventArchiver{Events: events, Archives: archives, Storage: storage}
+}
+
+// Run applies the retention policies each configured interval until ctx is cancelled
+func (a *EventArchiver) Run(ctx context.Context) {
+	for {
+		if len(config.GetRetentionConfig().Policies) > 0 {
+			if _, err := a.Archive(ctx); err != nil {
+				log.Printf("Error archiving events: %v", err)
+			}
+		}
+
+		interval := time.Duration(config.GetRetentionConfig().IntervalMinutes) * time.Minute
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Archive applies every retention policy now and returns the archives written
+func (a *EventArchiver) Archive(ctx context.Context) ([]models.Archive, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now().UTC()
+	archives := []models.Archive{}
+	for _, policy := range config.GetRetentionConfig().Policies {
+		if policy.MaxAgeDays < 1 {
+			log.Printf("Skipping the retention policy for %q: max_age_days must be at least 1", policy.EventName)
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -policy.MaxAgeDays)
+		for {
+			archive, err := a.archiveBatch(ctx, policy.EventName, cutoff, now)
+			if err != nil {
+				return archives, err
+			}
+			if archive == nil {
+				break
+			}
+			archives = append(archives, *archive)
+		}
+	}
+	return archives, nil
+}
+
+// archiveBatch archives the oldest batch of events created before cutoff,
+// returning nil when there are none
+func (a *EventArchiver) archiveBatch(ctx context.Context, eventName string, cutoff, now time.Time) (*models.Archive, error) {
+	file, err := os.CreateTemp("", "events-*.ndjson.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	digest := sha256.New()
+	compressed := gzip.NewWriter(io.MultiWriter(file, digest))
+	encoder := json.NewEncoder(compressed)
+
+	var keys []string
+	var first, last string
+	err = a.Events.EachEvent(ctx, database.EventFilter{EventName: eventName, To: cutoff}, func(event models.EventData) error {
+		if len(keys) >= archiveBatchSize && event.CreatedAt != last {
+			return errArchiveBatchFull
+		}
+		if len(keys) == 0 {
+			first = event.CreatedAt
+		}
+		last = event.CreatedAt
+		keys = append(keys, event.Key())
+		return encoder.Encode(event)
+	})
+	if err != nil && !errors.Is(err, errArchiveBatchFull) {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if err := compressed.Close(); err != nil {
+		return nil, err
+	}
+
+	firstAt, err := time.Parse(models.TimeLayout, first)
+	if err != nil {
+		return nil, fmt.Errorf("event created_at %q can't be read: %v", first, err)
+	}
+	lastAt, err := time.Parse(models.TimeLayout, last)
+	if err != nil {
+		return nil, fmt.Errorf("event created_at %q can't be read: %v", last, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	directory := eventName
+	if directory == "" {
+		directory = "all"
+	}
+	archive := &models.Archive{
+		ID:           fmt.Sprintf("events/%s/%s-%s-%d.ndjson.gz", directory, firstAt.Format(archiveTimeLayout), lastAt.Format(archiveTimeLayout), now.UnixNano()),
+		EventName:    eventName,
+		FirstEventAt: firstAt,
+		LastEventAt:  lastAt,
+		Events:       int64(len(keys)),
+		Bytes:        info.Size(),
+		SHA256:       hex.EncodeToString(digest.Sum(nil)),
+		ArchivedAt:   now,
+	}
+	if err := a.Storage.Put(ctx, archive.ID, file, info.Size()); err != nil {
+		return nil, fmt.Errorf("writing archive %s: %v", archive.ID, err)
+	}
+	if err := a.Archives.AddArchive(ctx, archive); err != nil {
+		return nil, fmt.Errorf("recording archive %s: %v", archive.ID, err)
+	}
+
+	// Only the archived events are removed: the keys limit the delete to them,
+	// and the time range to their copies created in the archived range
+	deleted, err := a.Events.DeleteEvents(ctx, database.EventFilter{
+		EventName: eventName,
+		From:      firstAt,
+		To:        lastAt.Add(time.Nanosecond),
+	}, keys)
+	if err != nil {
+		return nil, fmt.Errorf("removing the events archived in %s: %v", archive.ID, err)
+	}
+	if deleted == 0 {
+		return nil, fmt.Errorf("none of the events archived in %s could be removed", archive.ID)
+	}
+	if deleted != archive.Events {
+		log.Printf("Archived %d events in %s but removed %d; the others changed while archiving", archive.Events, archive.ID, deleted)
+	}
+	log.Printf("Archived %d events in %s", archive.Events, archive.ID)
+	return archive, nil
+}
+
+// List returns the archives holding events matching the filter
+func (a *EventArchiver) List(ctx context.Context, filter database.ArchiveFilter) ([]models.Archive, error) {
+	return a.Archives.ListArchives(ctx, filter)
+}
+
+// Restore stores again the archived events created in the filter's range.
+// Events are upserted, so restoring a range twice doesn't duplicate them.
+// The archives are kept.
+func (a *EventArchiver) Restore(ctx context.Context, filter database.ArchiveFilter) (*RestoreResult, error) {
+	if filter.From.IsZero() || filter.To.IsZero() {
+		return nil, fmt.Errorf("%w: from and to are required", ErrInvalidRestore)
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidRestore)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	archives, err := a.Archives.ListArchives(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{Archives: []string{}}
+	for _, archive := range archives {
+		restored, err := a.restoreArchive(ctx, archive, filter)
+		result.Events += restored
+		if err != nil {
+			return result, fmt.Errorf("restoring %s: %v", archive.ID, err)
+		}
+		result.Archives = append(result.Archives, archive.ID)
+	}
+	log.Printf("Restored %d events from %d archives", result.Events, len(result.Archives))
+	return result, nil
+}
+
+// restoreArchive verifies an archive's checksum and upserts its events in the filter's range
+func (a *EventArchiver) restoreArchive(ctx context.Context, archive models.Archive, filter database.ArchiveFilter) (int64, error) {
+	file, err := a.download(ctx, archive)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	decompressed, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return 0, err
+	}
+	defer decompressed.Close()
+
+	from, to := models.FormatTime(filter.From), models.FormatTime(filter.To)
+	decoder := json.NewDecoder(decompressed)
+	var restored int64
+	for {
+		var event models.EventData
+		err := decoder.Decode(&event)
+		if err == io.EOF {
+			return restored, nil
+		}
+		if err != nil {
+			return restored, err
+		}
+		if (filter.EventName != "" && event.EventName != filter.EventName) || event.CreatedAt < from || event.CreatedAt >= to {
+			continue
+		}
+		if err := a.Events.UpsertEvent(ctx, &event); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+}
+
+// download copies an archive to a temporary file, checking its size and checksum
+func (a *EventArchiver) download(ctx context.Context, archive models.Archive) (*os.File, error) {
+	object, err := a.Storage.Open(ctx, archive.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	file, err := os.CreateTemp("", "restore-*.ndjson.gz")
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, digest), object)
+	if err == nil && size != archive.Bytes {
+		err = fmt.Errorf("archive is %d bytes, the manifest records %d", size, archive.Bytes)
+	}
+	if err == nil && hex.EncodeToString(digest.Sum(nil)) != archive.SHA256 {
+		err = errors.New("archive checksum doesn't match the manifest")
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/models"
+)
+
+func TestEventArchiveRoundTrip(t *testing.T) {
+	writeTestConfig(t, map[string]interface{}{
+		"retention": map[string]interface{}{"policies": []map[string]interface{}{{"event_name": "Mint", "max_age_days": 30}}},
+	})
+	ctx := context.Background()
+	now := time.Now().UTC()
+	events := database.NewMemoryEventStore()
+	storage := &LocalArchiveStorage{Directory: t.TempDir()}
+	archiver := NewEventArchiver(events, database.NewMemoryArchiveStore(), storage)
+
+	stored := map[string]models.EventData{}
+	add := func(name string, ageDays int) {
+		event := models.EventData{
+			EventName:       name,
+			ChainID:         "80002",
+			TransactionHash: fmt.Sprintf("0x%064x", len(stored)+1),
+			LogIndex:        uint(len(stored)),
+			Amount:          "1000000000000000000",
+			CreatedAt:       models.FormatTime(now.AddDate(0, 0, -ageDays)),
+		}
+		if err := events.InsertEvent(ctx, &event); err != nil {
+			t.Fatalf("InsertEvent: %v", err)
+		}
+		stored[event.Key()] = event
+	}
+	add("Mint", 60)
+	add("Mint", 50)
+	add("Mint", 40)
+	add("Mint", 1)
+	// Burns have no policy, so they are kept however old
+	add("Burn", 90)
+
+	archives, err := archiver.Archive(ctx)
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if len(archives) != 1 || archives[0].Events != 3 {
+		t.Fatalf("archives %+v, want one with 3 events", archives)
+	}
+	archive := archives[0]
+	if remaining, _ := events.FindEvents(ctx, database.EventFilter{}); len(remaining) != 2 {
+		t.Fatalf("%d events remain, want the recent Mint and the Burn", len(remaining))
+	}
+
+	// The archive is gzip-compressed NDJSON matching its manifest entry
+	path := filepath.Join(storage.Directory, filepath.FromSlash(archive.ID))
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	sum := sha256.Sum256(contents)
+	if int64(len(contents)) != archive.Bytes || hex.EncodeToString(sum[:]) != archive.SHA256 {
+		t.Fatal("archive size or checksum doesn't match the manifest")
+	}
+	archived := readArchive(t, path)
+	if len(archived) != 3 {
+		t.Fatalf("archive holds %d events, want 3", len(archived))
+	}
+	for i, event := range archived {
+		if want := withoutStoredFields(stored[event.Key()]); event != want {
+			t.Fatalf("archived event %d is %+v, want %+v", i, event, want)
+		}
+		if i > 0 && event.CreatedAt < archived[i-1].CreatedAt {
+			t.Fatal("archived events are not oldest first")
+		}
+	}
+
+	// Restoring a range twice stores its events once, unchanged
+	filter := database.ArchiveFilter{From: now.AddDate(0, 0, -55), To: now}
+	for i := 0; i < 2; i++ {
+		result, err := archiver.Restore(ctx, filter)
+		if err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		if result.Events != 2 || len(result.Archives) != 1 || result.Archives[0] != archive.ID {
+			t.Fatalf("restore %d: %+v, want 2 events from %s", i+1, result, archive.ID)
+		}
+		restored, _ := events.FindEvents(ctx, database.EventFilter{EventName: "Mint"})
+		if len(restored) != 3 {
+			t.Fatalf("restore %d: %d Mints stored, want the recent one and 2 restored", i+1, len(restored))
+		}
+		for _, event := range restored {
+			if got, want := withoutStoredFields(event), withoutStoredFields(stored[event.Key()]); got != want {
+				t.Fatalf("restored %+v, want %+v", got, want)
+			}
+		}
+	}
+
+	// A damaged archive is refused rather than partially restored
+	if err := os.WriteFile(path, append(contents[:len(contents)-1], contents[len(contents)-1]^0xff), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archiver.Restore(ctx, filter); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("Restore of a damaged archive err = %v, want a checksum error", err)
+	}
+	if _, err := archiver.Restore(ctx, database.ArchiveFilter{To: now}); !errors.Is(err, ErrInvalidRestore) {
+		t.Fatalf("Restore without from err = %v, want ErrInvalidRestore", err)
+	}
+}
+
+// withoutStoredFields clears the fields the store derives on insert, which
+// archives leave out
+func withoutStoredFields(event models.EventData) models.EventData {
+	event.EventKey, event.AmountValue, event.FeesValue = "", nil, nil
+	return event
+}
+
+// readArchive decodes the events of an archive file
+func readArchive(t *testing.T, path string) []models.EventData {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	decompressed, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		t.Fatalf("archive is not gzip: %v", err)
+	}
+
+	var events []models.EventData
+	scanner := bufio.NewScanner(decompressed)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var event models.EventData
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("archive line is not JSON: %v", err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
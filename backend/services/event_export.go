@@ -0,0 +1,232 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"backend/models"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+// Event export formats
+const (
+	ExportCSV     = "csv"
+	ExportNDJSON  = "ndjson"
+	ExportParquet = "parquet"
+)
+
+// feeDecimals are the decimals of the fee tokens, LINK and the native gas tokens
+const feeDecimals = 18
+
+// parquetRowGroupSize is how many rows go into each Parquet row group; the
+// exporter buffers at most this many rows before writing them out
+const parquetRowGroupSize = 10000
+
+// ExportContentTypes maps each export format to its Content-Type
+var ExportContentTypes = map[string]string{
+	ExportCSV:     "text/csv; charset=utf-8",
+	ExportNDJSON:  "application/x-ndjson",
+	ExportParquet: "application/vnd.apache.parquet",
+}
+
+// ExportRow is one exported event. The raw base-unit amounts are kept and the
+// amounts normalised to token decimals are added beside them.
+type ExportRow struct {
+	ID                       string `json:"id" parquet:"id"`
+	ChainID                  string `json:"chain_id" parquet:"chain_id"`
+	ContractAddress          string `json:"contract_address" parquet:"contract_address"`
+	EventName                string `json:"event_name" parquet:"event_name"`
+	CallerAddress            string `json:"caller_address" parquet:"caller_address"`
+	BlockNumber              uint64 `json:"block_number" parquet:"block_number"`
+	TransactionHash          string `json:"transaction_hash" parquet:"transaction_hash"`
+	LogIndex                 uint   `json:"log_index" parquet:"log_index"`
+	Timestamp                string `json:"timestamp" parquet:"timestamp"`
+	CreatedAt                string `json:"created_at" parquet:"created_at"`
+	UpdatedAt                string `json:"updated_at" parquet:"updated_at"`
+	ToFromUser               string `json:"to_from_user" parquet:"to_from_user"`
+	Amount                   string `json:"amount" parquet:"amount"`
+	AmountNormalized         string `json:"amount_normalized" parquet:"amount_normalized"`
+	MessageID                string `json:"message_id" parquet:"message_id"`
+	DestinationChainSelector uint64 `json:"destination_chain_selector" parquet:"destination_chain_selector"`
+	Receiver                 string `json:"receiver" parquet:"receiver"`
+	Text                     string `json:"text" parquet:"text"`
+	Client                   string `json:"client" parquet:"client"`
+	FeeToken                 string `json:"fee_token" parquet:"fee_token"`
+	Fees                     string `json:"fees" parquet:"fees"`
+	FeesNormalized           string `json:"fees_normalized" parquet:"fees_normalized"`
+	SourceChainSelector      uint64 `json:"source_chain_selector" parquet:"source_chain_selector"`
+	Sender                   string `json:"sender" parquet:"sender"`
+	RunID                    string `json:"run_id" parquet:"run_id"`
+}
+
+// exportColumns is the CSV header, in ExportRow field order
+var exportColumns = []string{
+	"id", "chain_id", "contract_address", "event_name", "caller_address", "block_number",
+	"transaction_hash", "log_index", "timestamp", "created_at", "updated_at", "to_from_user", "amount",
+	"amount_normalized", "message_id", "destination_chain_selector", "receiver", "text",
+	"client", "fee_token", "fees", "fees_normalized", "source_chain_selector", "sender", "run_id",
+}
+
+// NewExportRow converts a stored event into an export row. The event's
+// formatted amounts are expected to be filled in by TokenDecimals.Format.
+func NewExportRow(event models.EventData) ExportRow {
+	return ExportRow{
+		ID:                       event.ID,
+		ChainID:                  event.ChainID,
+		ContractAddress:          event.ContractAddress,
+		EventName:                event.EventName,
+		CallerAddress:            event.CallerAddress,
+		BlockNumber:              event.BlockNumber,
+		TransactionHash:          event.TransactionHash,
+		LogIndex:                 event.LogIndex,
+		Timestamp:                event.Timestamp,
+		CreatedAt:                event.CreatedAt,
+		UpdatedAt:                event.UpdatedAt,
+		ToFromUser:               event.ToFromUser,
+		Amount:                   event.Amount,
+		AmountNormalized:         event.AmountFormatted,
+		MessageID:                event.MessageID,
+		DestinationChainSelector: event.DestinationChainSelector,
+		Receiver:                 event.Receiver,
+		Text:                     event.Text,
+		Client:                   event.Client,
+		FeeToken:                 event.FeeToken,
+		Fees:                     event.Fees,
+		FeesNormalized:           event.FeesFormatted,
+		SourceChainSelector:      event.SourceChainSelector,
+		Sender:                   event.Sender,
+		RunID:                    event.RunID,
+	}
+}
+
+func (r ExportRow) csvRecord() []string {
+	return []string{
+		r.ID, r.ChainID, r.ContractAddress, r.EventName, r.CallerAddress, strconv.FormatUint(r.BlockNumber, 10),
+		r.TransactionHash, strconv.FormatUint(uint64(r.LogIndex), 10), r.Timestamp, r.CreatedAt, r.UpdatedAt, r.ToFromUser, r.Amount,
+		r.AmountNormalized, r.MessageID, formatSelector(r.DestinationChainSelector), r.Receiver, r.Text,
+		r.Client, r.FeeToken, r.Fees, r.FeesNormalized, formatSelector(r.SourceChainSelector), r.Sender, r.RunID,
+	}
+}
+
+func formatSelector(selector uint64) string {
+	if selector == 0 {
+		return ""
+	}
+	return strconv.FormatUint(selector, 10)
+}
+
+// FormatTokenAmount renders a base-unit amount as a decimal token amount,
+// e.g. "1500000000000000000" with 18 decimals becomes "1.5". Empty or
+// unparsable amounts give an empty string.
+func FormatTokenAmount(amount string, decimals int) string {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return ""
+	}
+	negative := value.Sign() < 0
+	digits := new(big.Int).Abs(value).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	whole, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	formatted := whole
+	if fraction != "" {
+		formatted += "." + fraction
+	}
+	if negative {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// EventExporter writes events to a stream in one export format. Close must be
+// called once all events are written to flush the output.
+type EventExporter interface {
+	Write(event models.EventData) error
+	Close() error
+}
+
+// NewEventExporter creates an exporter writing the format to w
+func NewEventExporter(format string, w io.Writer) (EventExporter, error) {
+	switch format {
+	case ExportCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExporter{writer: writer}, nil
+	case ExportNDJSON:
+		return &ndjsonExporter{encoder: json.NewEncoder(w)}, nil
+	case ExportParquet:
+		writer := parquet.NewGenericWriter[ExportRow](w,
+			parquet.Compression(&snappy.Codec{}),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize))
+		return &parquetExporter{writer: writer}, nil
+	default:
+		return nil, fmt.Errorf("format must be one of %s, %s or %s", ExportCSV, ExportNDJSON, ExportParquet)
+	}
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func (e *csvExporter) Write(event models.EventData) error {
+	return e.writer.Write(NewExportRow(event).csvRecord())
+}
+
+func (e *csvExporter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) Write(event models.EventData) error {
+	return e.encoder.Encode(NewExportRow(event))
+}
+
+func (e *ndjsonExporter) Close() error {
+	return nil
+}
+
+type parquetExporter struct {
+	writer *parquet.GenericWriter[ExportRow]
+	rows   []ExportRow
+}
+
+func (e *parquetExporter) Write(event models.EventData) error {
+	e.rows = append(e.rows, NewExportRow(event))
+	if len(e.rows) < parquetRowGroupSize {
+		return nil
+	}
+	return e.flush()
+}
+
+// flush writes the buffered rows out as a row group
+func (e *parquetExporter) flush() error {
+	if _, err := e.writer.Write(e.rows); err != nil {
+		return err
+	}
+	e.rows = e.rows[:0]
+	return e.writer.Flush()
+}
+
+func (e *parquetExporter) Close() error {
+	if len(e.rows) > 0 {
+		if _, err := e.writer.Write(e.rows); err != nil {
+			return err
+		}
+	}
+	return e.writer.Close()
+}
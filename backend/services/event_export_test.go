@@ -0,0 +1,28 @@
+package services
+
+import "testing"
+
+func TestFormatTokenAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     string
+	}{
+		{"1500000000000000000", 18, "1.5"},
+		{"1000000000000000000", 18, "1"},
+		{"1", 18, "0.000000000000000001"},
+		{"0", 18, "0"},
+		{"123456", 0, "123456"},
+		{"123456", 2, "1234.56"},
+		{"100", 2, "1"},
+		{"-2500000", 6, "-2.5"},
+		{"99", 6, "0.000099"},
+		{"", 18, ""},
+		{"12abc", 18, ""},
+	}
+	for _, tt := range tests {
+		if got := FormatTokenAmount(tt.amount, tt.decimals); got != tt.want {
+			t.Errorf("FormatTokenAmount(%q, %d) = %q, want %q", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
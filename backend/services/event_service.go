@@ -8,6 +8,9 @@ import (
 	"log"
 	"math/big"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"backend/config"
@@ -17,32 +20,35 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 
-// StartContractEventMonitor initializes and runs the Ethereum event monitoring service
-func StartContractEventMonitor(chainID string, contractType string) {
-	go monitorEvents(chainID, contractType)
+// StartContractEventMonitor initializes and runs the Ethereum event monitoring
+// service for a contract in a tenant's registry
+func StartContractEventMonitor(tenant string, chainID string, contractType string) {
+	supervisor.setState(tenant, chainID, contractType, MonitorConnecting, nil)
+	go monitorEvents(tenant, chainID, contractType)
 }
 
 // monitorEvents attempts to connect to the Ethereum node and listen for events
-func monitorEvents(chainID string, contractType string) {
+func monitorEvents(tenant string, chainID string, contractType string) {
 	maxRetries := 5
 	retryDelay := 5 * time.Second
+	registry := config.ForTenant(tenant)
 
 	for attempt := 0; ; attempt++ {
 			// Attempt to connect to Ethereum node
-			client, err := config.GetEthereumWebSocketConnection(chainID)
+			client, err := registry.GetEthereumWebSocketConnection(chainID)
 			if err != nil {
-					handleConnectionError(err, attempt, maxRetries, retryDelay)
+					handleConnectionError(err, tenant, chainID, contractType, attempt, maxRetries, retryDelay)
 					continue
 			}
 
 			// Get contract details and start listening for events
-			contractAddress, err := config.GetContractAddress(chainID, contractType)
+			contractAddress, err := registry.GetContractAddress(chainID, contractType)
 			if err != nil {
 					log.Println(err)
+					supervisor.setState(tenant, chainID, contractType, MonitorRetrying, err)
 					time.Sleep(retryDelay)
 					continue
 			}
@@ -50,13 +56,15 @@ func monitorEvents(chainID string, contractType string) {
 			contractABI, err := config.GetABI(contractType)
 			if err != nil {
 					log.Printf("Error loading ABI for contract type '%s': %v", contractType, err)
+					supervisor.setState(tenant, chainID, contractType, MonitorRetrying, err)
 					time.Sleep(retryDelay)
 					continue
 			}
 
-			err = listenForEvents(client, common.HexToAddress(contractAddress), contractABI, chainID)
+			err = listenForEvents(context.Background(), client, common.HexToAddress(contractAddress), contractABI, tenant, chainID, contractType)
 			if err != nil {
 					log.Printf("Error listening for events: %v", err)
+					supervisor.setState(tenant, chainID, contractType, MonitorRetrying, err)
 					client.Close()
 					time.Sleep(retryDelay)
 					continue
@@ -67,58 +75,141 @@ func monitorEvents(chainID string, contractType string) {
 }
 
 
-// handleConnectionError logs the error and exits if max retries are reached
-func handleConnectionError(err error, attempt, maxRetries int, retryDelay time.Duration) {
+// failedRetryDelay is how often a monitor that has used up its retries tries to connect again
+const failedRetryDelay = time.Minute
+
+// handleConnectionError logs the error and waits before the next attempt. Once
+// max retries are reached the monitor is reported failed, so readiness shows
+// it, and keeps trying at a slower pace instead of stopping the server.
+func handleConnectionError(err error, tenant, chainID, contractType string, attempt, maxRetries int, retryDelay time.Duration) {
 	log.Printf("Attempt %d failed: %v", attempt+1, err)
 	if attempt >= maxRetries {
-		log.Fatalf("Max retries reached. Unable to establish connection.")
+		if attempt == maxRetries {
+			log.Printf("Max retries reached for the %s monitor on chain %s, retrying every %s", contractType, chainID, failedRetryDelay)
+		}
+		supervisor.setState(tenant, chainID, contractType, MonitorFailed, err)
+		time.Sleep(failedRetryDelay)
+		return
 	}
+	supervisor.setState(tenant, chainID, contractType, MonitorRetrying, err)
 	time.Sleep(retryDelay)
 }
 
 
 // listenForEvents sets up a subscription to filter logs for the contract
-func listenForEvents(client *ethclient.Client, contractAddress common.Address, contractABI abi.ABI,chainID string) error {
+func listenForEvents(ctx context.Context, client ethereum.LogFilterer, contractAddress common.Address, contractABI abi.ABI, tenant string, chainID string, contractType string) error {
 	query := ethereum.FilterQuery{
 		Addresses: []common.Address{contractAddress},
 	}
 
+	clock := newBlockClock(client)
 	logs := make(chan types.Log)
-	sub, err := client.SubscribeFilterLogs(context.Background(), query, logs)
+	sub, err := client.SubscribeFilterLogs(ctx, query, logs)
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to logs: %v", err)
 	}
 	defer sub.Unsubscribe()
 
+	supervisor.setState(tenant, chainID, contractType, MonitorRunning, nil)
+
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case err := <-sub.Err():
 			return fmt.Errorf("subscription error: %v", err)
 		case vLog := <-logs:
-			go processLog(vLog, contractABI,chainID)
+			supervisor.recordEvent(tenant, chainID, contractType)
+			go processLog(vLog, contractABI, tenant, chainID, clock)
 		}
 	}
 }
 
+// maxCachedBlockTimes bounds a monitor's cache of block times
+const maxCachedBlockTimes = 256
+
+// blockClock reads the times of the blocks a monitor's logs come from, so
+// events are timestamped when they happened rather than when they arrived
+type blockClock struct {
+	headers ChainHeadReader
+
+	mu    sync.Mutex
+	times map[uint64]time.Time
+}
+
+// newBlockClock creates a clock reading headers through client, if it can
+func newBlockClock(client ethereum.LogFilterer) *blockClock {
+	headers, _ := client.(ChainHeadReader)
+	return &blockClock{headers: headers, times: make(map[uint64]time.Time)}
+}
+
+// Time returns the time of a block. It returns false when the block can't be
+// read, leaving the event timestamped with the time it was received.
+func (c *blockClock) Time(number uint64) (time.Time, bool) {
+	if c == nil || c.headers == nil {
+		return time.Time{}, false
+	}
+	c.mu.Lock()
+	blockTime, cached := c.times[number]
+	c.mu.Unlock()
+	if cached {
+		return blockTime, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	header, err := c.headers.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		log.Printf("Failed to read the time of block %d: %v", number, err)
+		return time.Time{}, false
+	}
+	blockTime = time.Unix(int64(header.Time), 0).UTC()
+
+	c.mu.Lock()
+	if len(c.times) >= maxCachedBlockTimes {
+		c.times = make(map[uint64]time.Time)
+	}
+	c.times[number] = blockTime
+	c.mu.Unlock()
+	return blockTime, true
+}
+
+// WatchContractEvents runs the monitor pipeline for one contract of a tenant on
+// an existing client until ctx is cancelled. It lets the pipeline be driven by a
+// client other than the configured websocket endpoint, such as a simulated chain.
+func WatchContractEvents(ctx context.Context, client ethereum.LogFilterer, contractAddress common.Address, tenant string, chainID string, contractType string) error {
+	contractABI, err := config.GetABI(contractType)
+	if err != nil {
+		return fmt.Errorf("error loading ABI for contract type '%s': %v", contractType, err)
+	}
+
+	supervisor.setState(tenant, chainID, contractType, MonitorConnecting, nil)
+	return listenForEvents(ctx, client, contractAddress, contractABI, tenant, chainID, contractType)
+}
+
 // processLog handles a single log entry according to the contract ABI
-func processLog(vLog types.Log, contractABI abi.ABI,chainID string) {
+func processLog(vLog types.Log, contractABI abi.ABI, tenant string, chainID string, clock *blockClock) {
 	event, err := contractABI.EventByID(vLog.Topics[0])
 	if err != nil {
 		log.Printf("Failed to get event: %v", err)
 		return
 	}
 
-	callerAddress := getCallerAddress(event, vLog)
-	log.Printf("Caller Address: %s", callerAddress.String())
-
 	processedInputs := processEventInputs(event, vLog)
 	log.Printf("Processed Event Inputs: %+v", processedInputs)
 
+	callerAddress := getCallerAddress(event, vLog, processedInputs)
+	log.Printf("Caller Address: %s", callerAddress.String())
+
 	eventData := createEventData(vLog, event, callerAddress, processedInputs,chainID)
+	if blockTime, ok := clock.Time(vLog.BlockNumber); ok {
+		eventData.Timestamp = formatTimestamp(blockTime)
+	}
 	logEventData(eventData)
+	notifyDecodedEvent(tenant, eventData)
 
 	if eventData.EventName != "Transfer" {
-		sendEventDataToAPI(eventData)
+		sendEventDataToAPI(tenant, eventData)
 	} else {
 		log.Println("Transfer event detected. Skipping API call.")
 	}
@@ -126,8 +217,29 @@ func processLog(vLog types.Log, contractABI abi.ABI,chainID string) {
 	log.Println("--------------------")
 }
 
+var (
+	decodedEventObserversMu sync.RWMutex
+	decodedEventObservers   = make(map[string][]func(models.EventData))
+)
+
+// OnDecodedEvent registers fn to receive every event the tenant's monitors
+// decode, including those that are never ingested, such as ownership transfers
+func OnDecodedEvent(tenant string, fn func(models.EventData)) {
+	decodedEventObserversMu.Lock()
+	defer decodedEventObserversMu.Unlock()
+	decodedEventObservers[tenant] = append(decodedEventObservers[tenant], fn)
+}
+
+func notifyDecodedEvent(tenant string, eventData models.EventData) {
+	decodedEventObserversMu.RLock()
+	defer decodedEventObserversMu.RUnlock()
+	for _, fn := range decodedEventObservers[tenant] {
+		fn(eventData)
+	}
+}
+
 // getCallerAddress extracts the caller's address from the log
-func getCallerAddress(event *abi.Event, vLog types.Log) common.Address {
+func getCallerAddress(event *abi.Event, vLog types.Log, processedInputs map[string]interface{}) common.Address {
 	if len(event.Inputs) > 0 && event.Inputs[0].Name == "from" {
 		fromString := string(vLog.Data)
 		if len(fromString) == 42 {
@@ -135,6 +247,14 @@ func getCallerAddress(event *abi.Event, vLog types.Log) common.Address {
 		}
 	}
 
+	// Messenger events are indexed by message ID, so the user is the client field
+	if client, ok := processedInputs["client"].(string); ok {
+		return common.HexToAddress(client)
+	}
+
+	if len(vLog.Topics) < 2 {
+		return common.Address{}
+	}
 	topic := vLog.Topics[1]
 	if bytes.Equal(topic[:], common.LeftPadBytes([]byte{0x12}, 32)[:]) {
 		return common.BytesToAddress(vLog.TxHash.Bytes()[:20])
@@ -152,6 +272,7 @@ func createEventData(vLog types.Log, event *abi.Event, callerAddress common.Addr
 		ContractAddress:  vLog.Address.Hex(),
 		BlockNumber:      vLog.BlockNumber,
 		TransactionHash:  vLog.TxHash.Hex(),
+		LogIndex:         vLog.Index,
 		Timestamp:        formatTimestamp(time.Now().UTC()),
 		CreatedAt:        formatTimestamp(time.Now().UTC()),
 		UpdatedAt:        formatTimestamp(time.Now().UTC()),
@@ -163,14 +284,42 @@ func createEventData(vLog types.Log, event *abi.Event, callerAddress common.Addr
 	if to, ok := processedInputs["to"].(string); ok {
 		eventData.ToFromUser = to
 	}
-	
+
+	// MessageSent and MessageReceived fields
+	if messageID, ok := processedInputs["messageId"].(string); ok {
+		eventData.MessageID = messageID
+	}
+	if selector, ok := processedInputs["destinationChainSelector"].(string); ok {
+		eventData.DestinationChainSelector, _ = strconv.ParseUint(selector, 10, 64)
+	}
+	if selector, ok := processedInputs["sourceChainSelector"].(string); ok {
+		eventData.SourceChainSelector, _ = strconv.ParseUint(selector, 10, 64)
+	}
+	if receiver, ok := processedInputs["receiver"].(string); ok {
+		eventData.Receiver = receiver
+	}
+	if text, ok := processedInputs["text"].(string); ok {
+		eventData.Text = text
+	}
+	if client, ok := processedInputs["client"].(string); ok {
+		eventData.Client = client
+	}
+	if feeToken, ok := processedInputs["feeToken"].(string); ok {
+		eventData.FeeToken = feeToken
+	}
+	if fees, ok := processedInputs["fees"].(string); ok {
+		eventData.Fees = fees
+	}
+	if sender, ok := processedInputs["sender"].(string); ok {
+		eventData.Sender = sender
+	}
 
 	return eventData
 }
 
 // Helper function to format timestamp
 func formatTimestamp(t time.Time) string {
-	return t.Format("2006-01-02 15:04:05 MST")
+	return models.FormatTime(t)
 }
 
 
@@ -183,8 +332,8 @@ func logEventData(eventData models.EventData) {
 	log.Printf("Sending event data to API: %+v", eventData)
 }
 
-// sendEventDataToAPI sends the event data to the specified API endpoint
-func sendEventDataToAPI(data models.EventData) {
+// sendEventDataToAPI sends the event data to the tenant's ingest endpoint
+func sendEventDataToAPI(tenant string, data models.EventData) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		log.Printf("Failed to marshal event data: %v", err)
@@ -194,24 +343,33 @@ func sendEventDataToAPI(data models.EventData) {
 	var endpoint string
 	switch data.EventName {
 	case "Mint":
-		endpoint = "/api/events/mint"
+		endpoint = "/events/mint"
 	case "Burn":
-		endpoint = "/api/events/burn"
+		endpoint = "/events/burn"
 	case "TokensReleased":
-		endpoint = "/api/events/tokens-released"
+		endpoint = "/events/tokens-released"
 	case "TokensLocked":
-		endpoint = "/api/events/tokens-locked"
+		endpoint = "/events/tokens-locked"
 	case "MessageSent":
-		endpoint = "/api/events/message-sent"
+		endpoint = "/events/message-sent"
 	case "MessageReceived":
-		endpoint = "/api/events/message-received"
+		endpoint = "/events/message-received"
 	default:
 		log.Printf("Unknown event type: %s", data.EventName)
 		return
 	}
 
-	url := fmt.Sprintf("http://localhost:8080%s", endpoint)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	registry := config.ForTenant(tenant)
+	url := config.EventAPIURL() + registry.APIPath() + endpoint
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		log.Printf("Failed to build API request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderAPIKey, os.Getenv(registry.IngestKeyEnvVar))
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("Failed to send data to API: %v", err)
 		return
@@ -229,10 +387,12 @@ func sendEventDataToAPI(data models.EventData) {
 func processEventInputs(event *abi.Event, vLog types.Log) map[string]interface{} {
 	result := make(map[string]interface{})
 
-	// Process indexed inputs
-	for i, input := range event.Inputs {
-		if input.Indexed && i+1 < len(vLog.Topics) {
-			result[input.Name] = processIndexedInput(input, vLog.Topics[i+1])
+	// Process indexed inputs; topic 0 is the event signature
+	topicIndex := 1
+	for _, input := range event.Inputs {
+		if input.Indexed && topicIndex < len(vLog.Topics) {
+			result[input.Name] = processIndexedInput(input, vLog.Topics[topicIndex])
+			topicIndex++
 		}
 	}
 
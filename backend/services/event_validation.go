@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"backend/config"
+	"backend/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	hash32Pattern  = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+	decimalPattern = regexp.MustCompile(`^[0-9]+$`)
+	runIDPattern   = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+)
+
+// eventContractTypes maps each ingested event to the contract that emits it
+var eventContractTypes = map[string]string{
+	"Mint":            "Token",
+	"Burn":            "Token",
+	"TokensLocked":    "Vault",
+	"TokensReleased":  "Vault",
+	"MessageSent":     "Router",
+	"MessageReceived": "Router",
+}
+
+// ContractTypeForEvent returns the contract type that emits the named event
+func ContractTypeForEvent(eventName string) (string, bool) {
+	contractType, exists := eventContractTypes[eventName]
+	return contractType, exists
+}
+
+// ValidateEventData checks an ingested event field by field against a tenant's
+// chain and contract registry and returns every problem found
+func ValidateEventData(registry *config.TenantConfig, eventData models.EventData) []models.FieldError {
+	var errs []models.FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, models.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	chainKnown := false
+	if eventData.ChainID == "" {
+		add("ChainId", "is required")
+	} else if _, err := registry.GetChainConfig(eventData.ChainID); err != nil {
+		add("ChainId", "unknown chain ID %s", eventData.ChainID)
+	} else {
+		chainKnown = true
+	}
+
+	if eventData.TransactionHash == "" {
+		add("transaction_hash", "is required")
+	} else if !hash32Pattern.MatchString(eventData.TransactionHash) {
+		add("transaction_hash", "must be a 0x-prefixed 32-byte hex string")
+	}
+
+	if msg := checkAddress(eventData.CallerAddress, true); msg != "" {
+		add("caller_address", msg)
+	}
+
+	if msg := checkAddress(eventData.ContractAddress, true); msg != "" {
+		add("contract_address", msg)
+	} else if chainKnown {
+		if msg := checkRegisteredContract(registry, eventData); msg != "" {
+			add("contract_address", msg)
+		}
+	}
+
+	if eventData.Amount == "" {
+		add("amount", "is required")
+	} else if !decimalPattern.MatchString(eventData.Amount) {
+		add("amount", "must be a non-negative decimal integer")
+	}
+	if eventData.Fees != "" && !decimalPattern.MatchString(eventData.Fees) {
+		add("fees", "must be a non-negative decimal integer")
+	}
+
+	if eventData.MessageID != "" && !hash32Pattern.MatchString(eventData.MessageID) {
+		add("message_id", "must be a 0x-prefixed 32-byte hex string")
+	}
+
+	if eventData.RunID != "" && !runIDPattern.MatchString(eventData.RunID) {
+		add("run_id", "must be 1-64 letters, digits, dots, dashes or underscores")
+	}
+
+	optionalAddresses := []struct {
+		field string
+		value string
+	}{
+		{"to_from_user", eventData.ToFromUser},
+		{"receiver", eventData.Receiver},
+		{"client", eventData.Client},
+		{"fee_token", eventData.FeeToken},
+		{"sender", eventData.Sender},
+	}
+	for _, address := range optionalAddresses {
+		if msg := checkAddress(address.value, false); msg != "" {
+			add(address.field, msg)
+		}
+	}
+
+	return errs
+}
+
+// checkAddress returns a problem description, or "" if the address is a valid EIP-55 checksummed address
+func checkAddress(address string, required bool) string {
+	if address == "" {
+		if required {
+			return "is required"
+		}
+		return ""
+	}
+	if !common.IsHexAddress(address) || !strings.HasPrefix(address, "0x") {
+		return "must be a 0x-prefixed 20-byte hex address"
+	}
+	if common.HexToAddress(address).Hex() != address {
+		return "must be an EIP-55 checksummed address"
+	}
+	return ""
+}
+
+// checkRegisteredContract verifies the contract address is the one registered for the chain and event
+func checkRegisteredContract(registry *config.TenantConfig, eventData models.EventData) string {
+	contractType, exists := ContractTypeForEvent(eventData.EventName)
+	if !exists {
+		return fmt.Sprintf("unsupported event %s", eventData.EventName)
+	}
+
+	registered, err := registry.GetContractAddress(eventData.ChainID, contractType)
+	if err != nil {
+		return fmt.Sprintf("no %s contract registered for chain %s", contractType, eventData.ChainID)
+	}
+	if common.HexToAddress(registered) != common.HexToAddress(eventData.ContractAddress) {
+		return fmt.Sprintf("does not match the %s contract registered for chain %s", contractType, eventData.ChainID)
+	}
+	return ""
+}
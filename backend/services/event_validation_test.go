@@ -0,0 +1,85 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"backend/config"
+	"backend/models"
+)
+
+const (
+	testTokenAddress  = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	testVaultAddress  = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+	testCallerAddress = "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB"
+	testTxHash        = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+)
+
+func TestValidateEventData(t *testing.T) {
+	registry := &config.TenantConfig{
+		ID: config.DefaultTenant,
+		Chains: map[string]*config.ChainConfig{
+			"80002": {ChainID: "80002", TokenContractAddr: testTokenAddress, VaultContractAddr: testVaultAddress},
+			// No Vault is registered on 11155111
+			"11155111": {ChainID: "11155111", TokenContractAddr: testTokenAddress},
+		},
+	}
+	valid := models.EventData{
+		EventName:       "Mint",
+		ChainID:         "80002",
+		TransactionHash: testTxHash,
+		CallerAddress:   testCallerAddress,
+		ContractAddress: testTokenAddress,
+		Amount:          "1000000000000000000",
+		Fees:            "0",
+		MessageID:       testTxHash,
+		RunID:           "load-1.a_b",
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(*models.EventData)
+		wantField string
+		wantMsg   string
+	}{
+		{"valid", func(e *models.EventData) {}, "", ""},
+		{"missing chain", func(e *models.EventData) { e.ChainID = "" }, "ChainId", "is required"},
+		// The contract can't be checked on an unknown chain, so only the chain is reported
+		{"unknown chain", func(e *models.EventData) { e.ChainID = "1" }, "ChainId", "unknown chain ID 1"},
+		{"missing transaction hash", func(e *models.EventData) { e.TransactionHash = "" }, "transaction_hash", "is required"},
+		{"short transaction hash", func(e *models.EventData) { e.TransactionHash = testTxHash[:64] }, "transaction_hash", "32-byte hex"},
+		{"unprefixed transaction hash", func(e *models.EventData) { e.TransactionHash = "0X" + testTxHash[2:] }, "transaction_hash", "32-byte hex"},
+		{"non-hex message ID", func(e *models.EventData) { e.MessageID = "0x" + strings.Repeat("zz", 32) }, "message_id", "32-byte hex"},
+		{"lowercase caller", func(e *models.EventData) { e.CallerAddress = strings.ToLower(testCallerAddress) }, "caller_address", "EIP-55"},
+		{"unprefixed caller", func(e *models.EventData) { e.CallerAddress = testCallerAddress[2:] }, "caller_address", "20-byte hex"},
+		{"short caller", func(e *models.EventData) { e.CallerAddress = testCallerAddress[:40] }, "caller_address", "20-byte hex"},
+		{"uppercase prefix receiver", func(e *models.EventData) { e.Receiver = strings.ToUpper(testCallerAddress[:2]) + testCallerAddress[2:] }, "receiver", "20-byte hex"},
+		{"lowercase client", func(e *models.EventData) { e.Client = strings.ToLower(testVaultAddress) }, "client", "EIP-55"},
+		{"missing amount", func(e *models.EventData) { e.Amount = "" }, "amount", "is required"},
+		{"fractional amount", func(e *models.EventData) { e.Amount = "1.5" }, "amount", "decimal integer"},
+		{"negative amount", func(e *models.EventData) { e.Amount = "-1" }, "amount", "decimal integer"},
+		{"exponent fees", func(e *models.EventData) { e.Fees = "1e18" }, "fees", "decimal integer"},
+		{"bad run ID", func(e *models.EventData) { e.RunID = "run 1" }, "run_id", "letters, digits"},
+		{"other contract", func(e *models.EventData) { e.ContractAddress = testVaultAddress }, "contract_address", "does not match the Token contract"},
+		{"unregistered contract", func(e *models.EventData) {
+			e.EventName, e.ChainID, e.ContractAddress = "TokensLocked", "11155111", testVaultAddress
+		}, "contract_address", "no Vault contract registered for chain 11155111"},
+		{"unsupported event", func(e *models.EventData) { e.EventName = "Approval" }, "contract_address", "unsupported event Approval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := valid
+			tt.modify(&event)
+			errs := ValidateEventData(registry, event)
+			if tt.wantField == "" {
+				if len(errs) != 0 {
+					t.Fatalf("got errors %+v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField || !strings.Contains(errs[0].Message, tt.wantMsg) {
+				t.Fatalf("got errors %+v, want one on %s containing %q", errs, tt.wantField, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Component health states, from best to worst
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// ComponentHealth is the result of checking a single dependency
+type ComponentHealth struct {
+	Name      string                 `json:"name"`
+	Status    string                 `json:"status"`
+	Critical  bool                   `json:"critical"`
+	LatencyMs int64                  `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	// Tenant is set on components of tenants other than the default one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// HealthReport aggregates all component checks for /readyz
+type HealthReport struct {
+	Ready      bool              `json:"ready"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// ChainHeadReader is the subset of an RPC client needed to check head freshness
+type ChainHeadReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// HealthService runs the readiness checks for every dependency of a tenant
+type HealthService struct {
+	// Tenant owns the checked database, chains and monitors; empty is the default tenant
+	Tenant string
+	// Ping checks the database connection; nil skips the database check
+	Ping func(ctx context.Context) error
+	// Heads returns an RPC client for a chain; nil skips the chain checks
+	Heads func(chainID string) (ChainHeadReader, error)
+	// ChainIDs lists the chains to check
+	ChainIDs []string
+	// Monitors returns the supervisor's view of the event monitors
+	Monitors func() []MonitorStatus
+	Config   config.HealthConfig
+}
+
+// NewHealthService creates a health service for the chains of the clients' tenant
+func NewHealthService(ping func(ctx context.Context) error, clients *ChainClients) *HealthService {
+	return &HealthService{
+		Tenant: clients.Tenant,
+		Ping:   ping,
+		Heads: func(chainID string) (ChainHeadReader, error) {
+			return clients.Get(chainID)
+		},
+		ChainIDs: clients.Registry().ChainIDs(),
+		Monitors: MonitorStatuses,
+		Config:   config.GetHealthConfig(),
+	}
+}
+
+// Readiness checks every component concurrently and decides whether the server is ready
+func (h *HealthService) Readiness(ctx context.Context) HealthReport {
+	timeout := time.Duration(h.Config.CheckTimeoutSeconds) * time.Second
+
+	var checks []func(context.Context) ComponentHealth
+	if h.Ping != nil {
+		checks = append(checks, h.checkDatabase)
+	}
+	if h.Heads != nil {
+		for _, chainID := range h.ChainIDs {
+			chainID := chainID
+			checks = append(checks, func(ctx context.Context) ComponentHealth {
+				return h.checkChainHead(ctx, chainID)
+			})
+		}
+	}
+
+	components := make([]ComponentHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check func(context.Context) ComponentHealth) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			result := check(checkCtx)
+			result.LatencyMs = time.Since(start).Milliseconds()
+			components[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	tenant := h.Tenant
+	if tenant == "" {
+		tenant = config.DefaultTenant
+	}
+	if h.Monitors != nil {
+		for _, monitor := range h.Monitors() {
+			if monitor.Tenant == tenant {
+				components = append(components, checkMonitor(monitor))
+			}
+		}
+	}
+
+	report := HealthReport{Ready: true, CheckedAt: time.Now().UTC(), Components: components}
+	for i := range report.Components {
+		component := &report.Components[i]
+		component.Critical = h.isCritical(component.Name)
+		if tenant != config.DefaultTenant {
+			component.Tenant = tenant
+		}
+		if component.Critical && component.Status != StatusUp {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// CombinedReadiness checks every tenant's components concurrently. The server
+// is ready only when every tenant's critical components are up.
+func CombinedReadiness(ctx context.Context, tenants []*HealthService) HealthReport {
+	reports := make([]HealthReport, len(tenants))
+	var wg sync.WaitGroup
+	for i, health := range tenants {
+		wg.Add(1)
+		go func(i int, health *HealthService) {
+			defer wg.Done()
+			reports[i] = health.Readiness(ctx)
+		}(i, health)
+	}
+	wg.Wait()
+
+	combined := HealthReport{Ready: true, CheckedAt: time.Now().UTC(), Components: []ComponentHealth{}}
+	for _, report := range reports {
+		combined.Ready = combined.Ready && report.Ready
+		combined.Components = append(combined.Components, report.Components...)
+	}
+	return combined
+}
+
+func (h *HealthService) checkDatabase(ctx context.Context) ComponentHealth {
+	component := ComponentHealth{Name: "mongo", Status: StatusUp}
+	if err := h.Ping(ctx); err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	return component
+}
+
+func (h *HealthService) checkChainHead(ctx context.Context, chainID string) ComponentHealth {
+	component := ComponentHealth{Name: "chain:" + chainID, Status: StatusUp}
+
+	client, err := h.Heads(chainID)
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+		return component
+	}
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = fmt.Sprintf("failed to fetch latest header: %v", err)
+		return component
+	}
+
+	headTime := time.Unix(int64(header.Time), 0).UTC()
+	age := time.Since(headTime)
+	maxAge := time.Duration(h.Config.HeadMaxAgeSeconds) * time.Second
+
+	component.Details = map[string]interface{}{
+		"head_block":       header.Number.Uint64(),
+		"head_time":        headTime,
+		"head_age_seconds": int64(age.Seconds()),
+		"max_age_seconds":  h.Config.HeadMaxAgeSeconds,
+	}
+	if age > maxAge {
+		component.Status = StatusDegraded
+		component.Error = fmt.Sprintf("head block is %s old", age.Round(time.Second))
+	}
+	return component
+}
+
+func checkMonitor(monitor MonitorStatus) ComponentHealth {
+	component := ComponentHealth{
+		Name:  monitor.Name(),
+		Error: monitor.LastError,
+		Details: map[string]interface{}{
+			"state":    monitor.State,
+			"attempts": monitor.Attempts,
+			"since":    monitor.Since,
+		},
+	}
+	if monitor.LastEventAt != nil {
+		component.Details["last_event_at"] = *monitor.LastEventAt
+	}
+
+	switch monitor.State {
+	case MonitorRunning:
+		component.Status = StatusUp
+	case MonitorConnecting, MonitorRetrying:
+		component.Status = StatusDegraded
+	default:
+		component.Status = StatusDown
+	}
+	return component
+}
+
+// isCritical reports whether a component matches one of the configured critical patterns.
+// A trailing "*" matches any suffix, so "monitor:*" covers every monitor.
+func (h *HealthService) isCritical(name string) bool {
+	for _, pattern := range h.Config.CriticalComponents {
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+		if pattern == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ccipMessageGasLimit matches the gas limit the Messenger sets in _buildCCIPMessage
+const ccipMessageGasLimit = 400_000
+
+// evmExtraArgsV1Tag is bytes4(keccak256("CCIP EVMExtraArgsV1")), which
+// Client._argsToBytes prefixes to the encoded EVMExtraArgsV1
+var evmExtraArgsV1Tag = []byte{0x97, 0xa6, 0x57, 0xc9}
+
+// ccipRouterABI covers the IRouterClient function used for quotes
+const ccipRouterABI = `[{"type":"function","name":"getFee","stateMutability":"view",
+	"inputs":[{"name":"destinationChainSelector","type":"uint64"},{"name":"message","type":"tuple","components":[
+		{"name":"receiver","type":"bytes"},{"name":"data","type":"bytes"},
+		{"name":"tokenAmounts","type":"tuple[]","components":[{"name":"token","type":"address"},{"name":"amount","type":"uint256"}]},
+		{"name":"feeToken","type":"address"},{"name":"extraArgs","type":"bytes"}]}],
+	"outputs":[{"name":"fee","type":"uint256"}]}]`
+
+// erc20ABI covers the ERC-20 functions read from tokens the repo has no ABI for
+const erc20ABI = `[{"type":"function","name":"balanceOf","stateMutability":"view",
+	"inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}]`
+
+var (
+	parsedRouterABI = mustParseABI(ccipRouterABI)
+	parsedERC20ABI  = mustParseABI(erc20ABI)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// evm2AnyMessage mirrors Client.EVM2AnyMessage for ABI encoding
+type evm2AnyMessage struct {
+	Receiver     []byte
+	Data         []byte
+	TokenAmounts []evmTokenAmount
+	FeeToken     common.Address
+	ExtraArgs    []byte
+}
+
+// evmTokenAmount mirrors Client.EVMTokenAmount
+type evmTokenAmount struct {
+	Token  common.Address
+	Amount *big.Int
+}
+
+// ErrUnsupportedLane is returned when the configuration cannot price a
+// transfer between two chains
+var ErrUnsupportedLane = errors.New("unsupported lane")
+
+// QuoteRequest describes a transfer to price
+type QuoteRequest struct {
+	FromChainID string
+	ToChainID   string
+	Amount      *big.Int
+	Receiver    common.Address
+	// Text and Client fill the message payload; the fee depends on its size
+	Text   string
+	Client common.Address
+}
+
+// FeeQuote is the LINK fee CCIP charges for a transfer, with what the
+// Messenger needs to be able to pay it. Amounts are in wei.
+type FeeQuote struct {
+	FromChainID              string    `json:"from_chain_id"`
+	ToChainID                string    `json:"to_chain_id"`
+	DestinationChainSelector uint64    `json:"destination_chain_selector"`
+	Messenger                string    `json:"messenger"`
+	CCIPRouter               string    `json:"ccip_router"`
+	FeeToken                 string    `json:"fee_token"`
+	Fee                      string    `json:"fee"`
+	MessengerLinkBalance     string    `json:"messenger_link_balance"`
+	SufficientBalance        bool      `json:"sufficient_balance"`
+	DestinationAllowlisted   bool      `json:"destination_allowlisted"`
+	QuotedAt                 time.Time `json:"quoted_at"`
+	ExpiresAt                time.Time `json:"expires_at"`
+}
+
+type cachedQuote struct {
+	quote   FeeQuote
+	expires time.Time
+}
+
+// QuoteService prices cross-chain transfers by asking the source chain's CCIP
+// router for the fee of the message the Messenger would send. Quotes are
+// cached for TTL since fees only move with gas prices.
+type QuoteService struct {
+	Clients *ChainClients
+	TTL     time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedQuote
+}
+
+// NewQuoteService creates a quote service that caches quotes for 15 seconds
+func NewQuoteService(clients *ChainClients) *QuoteService {
+	return &QuoteService{Clients: clients, TTL: 15 * time.Second, cache: make(map[string]cachedQuote)}
+}
+
+// Quote returns the fee for sending a transfer from one configured chain to another
+func (s *QuoteService) Quote(ctx context.Context, request QuoteRequest) (*FeeQuote, error) {
+	key := fmt.Sprintf("%s|%s|%s|%s|%s|%s", request.FromChainID, request.ToChainID, request.Amount,
+		request.Receiver.Hex(), request.Client.Hex(), request.Text)
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	cached, exists := s.cache[key]
+	s.mu.Unlock()
+	if exists && now.Before(cached.expires) {
+		quote := cached.quote
+		return &quote, nil
+	}
+
+	quote, err := s.quote(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	quote.QuotedAt = now
+	quote.ExpiresAt = now.Add(s.TTL)
+
+	s.mu.Lock()
+	for cachedKey, entry := range s.cache {
+		if !now.Before(entry.expires) {
+			delete(s.cache, cachedKey)
+		}
+	}
+	s.cache[key] = cachedQuote{quote: *quote, expires: quote.ExpiresAt}
+	s.mu.Unlock()
+	return quote, nil
+}
+
+func (s *QuoteService) quote(ctx context.Context, request QuoteRequest) (*FeeQuote, error) {
+	if request.FromChainID == request.ToChainID {
+		return nil, fmt.Errorf("%w: source and destination chains must differ", ErrUnsupportedLane)
+	}
+	registry := s.Clients.Registry()
+	source, err := registry.GetChainConfig(request.FromChainID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedLane, err)
+	}
+	destination, err := registry.GetChainConfig(request.ToChainID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedLane, err)
+	}
+	if destination.CCIPChainSelector == 0 {
+		return nil, fmt.Errorf("%w: chain %s has no CCIP chain selector configured", ErrUnsupportedLane, request.ToChainID)
+	}
+	if source.LinkTokenAddr == "" {
+		return nil, fmt.Errorf("%w: chain %s has no LINK token configured", ErrUnsupportedLane, request.FromChainID)
+	}
+	messengerAddress, err := registry.GetContractAddress(request.FromChainID, "Router")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedLane, err)
+	}
+	messengerABI, err := config.GetABI("Router")
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.Clients.Get(request.FromChainID)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	messenger := common.HexToAddress(messengerAddress)
+	linkToken := common.HexToAddress(source.LinkTokenAddr)
+
+	router, err := callSingle[common.Address](opts, bind.NewBoundContract(messenger, messengerABI, client, nil, nil), "getRouter")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Messenger's router: %v", err)
+	}
+	allowlisted, err := callSingle[bool](opts, bind.NewBoundContract(messenger, messengerABI, client, nil, nil),
+		"allowlistedDestinationChains", destination.CCIPChainSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the destination allowlist: %v", err)
+	}
+
+	message, err := buildCCIPMessage(request, linkToken)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := callSingle[*big.Int](opts, bind.NewBoundContract(router, parsedRouterABI, client, nil, nil),
+		"getFee", destination.CCIPChainSelector, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee from CCIP router: %v", err)
+	}
+	balance, err := callSingle[*big.Int](opts, bind.NewBoundContract(linkToken, parsedERC20ABI, client, nil, nil),
+		"balanceOf", messenger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Messenger's LINK balance: %v", err)
+	}
+
+	return &FeeQuote{
+		FromChainID:              request.FromChainID,
+		ToChainID:                request.ToChainID,
+		DestinationChainSelector: destination.CCIPChainSelector,
+		Messenger:                messenger.Hex(),
+		CCIPRouter:               router.Hex(),
+		FeeToken:                 linkToken.Hex(),
+		Fee:                      fee.String(),
+		MessengerLinkBalance:     balance.String(),
+		SufficientBalance:        balance.Cmp(fee) >= 0,
+		DestinationAllowlisted:   allowlisted,
+	}, nil
+}
+
+// buildCCIPMessage encodes the message exactly as the Messenger's _buildCCIPMessage does
+func buildCCIPMessage(request QuoteRequest, feeToken common.Address) (evm2AnyMessage, error) {
+	addressType, _ := abi.NewType("address", "", nil)
+	stringType, _ := abi.NewType("string", "", nil)
+	uintType, _ := abi.NewType("uint256", "", nil)
+
+	receiver, err := abi.Arguments{{Type: addressType}}.Pack(request.Receiver)
+	if err != nil {
+		return evm2AnyMessage{}, err
+	}
+	data, err := abi.Arguments{{Type: stringType}, {Type: uintType}, {Type: addressType}}.Pack(request.Text, request.Amount, request.Client)
+	if err != nil {
+		return evm2AnyMessage{}, err
+	}
+	extraArgs, err := abi.Arguments{{Type: uintType}}.Pack(big.NewInt(ccipMessageGasLimit))
+	if err != nil {
+		return evm2AnyMessage{}, err
+	}
+
+	return evm2AnyMessage{
+		Receiver:     receiver,
+		Data:         data,
+		TokenAmounts: []evmTokenAmount{},
+		FeeToken:     feeToken,
+		ExtraArgs:    append(append([]byte{}, evmExtraArgsV1Tag...), extraArgs...),
+	}, nil
+}
+
+// callSingle calls a view function that returns one value
+func callSingle[T any](opts *bind.CallOpts, contract *bind.BoundContract, method string, args ...interface{}) (T, error) {
+	var zero T
+	var out []interface{}
+	if err := contract.Call(opts, &out, method, args...); err != nil {
+		return zero, err
+	}
+	if len(out) != 1 {
+		return zero, fmt.Errorf("%s returned %d values", method, len(out))
+	}
+	value, ok := out[0].(T)
+	if !ok {
+		return zero, fmt.Errorf("%s returned %T", method, out[0])
+	}
+	return value, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// word left-pads a hex value to a 32-byte ABI word
+func word(value string) string {
+	return strings.Repeat("0", 64-len(value)) + value
+}
+
+func TestBuildCCIPMessage(t *testing.T) {
+	receiver := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	client := common.HexToAddress("0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB")
+	feeToken := common.HexToAddress("0x0Fd9e8d3aF1aaee056EB9e802c3A762a667b1904")
+	request := QuoteRequest{Amount: big.NewInt(1_000_000), Receiver: receiver, Text: "hello", Client: client}
+
+	message, err := buildCCIPMessage(request, feeToken)
+	if err != nil {
+		t.Fatalf("buildCCIPMessage: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  []byte
+		// want is the Solidity encoding, one 32-byte word per element
+		want []string
+	}{
+		// abi.encode(_receiver)
+		{"receiver", message.Receiver, []string{word(hex.EncodeToString(receiver.Bytes()))}},
+		// abi.encode(_text, _amount, _client): the string's offset, the amount,
+		// the client, then the string's length and its padded bytes
+		{"data", message.Data, []string{
+			word("60"),
+			word("f4240"),
+			word(hex.EncodeToString(client.Bytes())),
+			word("5"),
+			hex.EncodeToString([]byte("hello")) + strings.Repeat("0", 54),
+		}},
+		// Client._argsToBytes(EVMExtraArgsV1({gasLimit: 400_000})): the tag, then the gas limit
+		{"extraArgs", message.ExtraArgs, []string{"97a657c9", word("61a80")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := hex.EncodeToString(tt.got), strings.Join(tt.want, ""); got != want {
+				t.Fatalf("encoded\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	if len(message.TokenAmounts) != 0 || message.FeeToken != feeToken {
+		t.Fatalf("token amounts %v and fee token %s, want none and %s", message.TokenAmounts, message.FeeToken, feeToken)
+	}
+	if tag := crypto.Keccak256([]byte("CCIP EVMExtraArgsV1"))[:4]; !bytes.Equal(tag, evmExtraArgsV1Tag) {
+		t.Fatalf("evmExtraArgsV1Tag = %x, want %x", evmExtraArgsV1Tag, tag)
+	}
+	// The message must fit the router's getFee signature
+	if _, err := parsedRouterABI.Pack("getFee", uint64(16015286601757825753), message); err != nil {
+		t.Fatalf("packing getFee: %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/config"
+)
+
+// bucketIdleTimeout is how long an untouched bucket is kept before it is swept
+const bucketIdleTimeout = 10 * time.Minute
+
+// authFailureRoute is the per-IP bucket spent by requests with invalid credentials
+const authFailureRoute = "auth-failures"
+
+// RateDecision is the outcome of a rate limit check
+type RateDecision struct {
+	Allowed    bool
+	Limit      config.RateLimit
+	Remaining  int
+	RetryAfter time.Duration
+	// QuotaExceeded is set when the daily quota rather than the bucket rejected the request
+	QuotaExceeded bool
+}
+
+// BucketUsage describes the current state of a single token bucket
+type BucketUsage struct {
+	Route    string  `json:"route"`
+	Tokens   float64 `json:"tokens"`
+	Burst    int     `json:"burst"`
+	Rate     float64 `json:"requests_per_second"`
+	DayCount int64   `json:"day_count"`
+	DayQuota int64   `json:"daily_quota,omitempty"`
+	Allowed  int64   `json:"allowed"`
+	Limited  int64   `json:"limited"`
+	LastSeen string  `json:"last_seen"`
+}
+
+// ClientUsage aggregates the buckets belonging to one client
+type ClientUsage struct {
+	Client  string        `json:"client"`
+	Allowed int64         `json:"allowed"`
+	Limited int64         `json:"limited"`
+	Buckets []BucketUsage `json:"buckets"`
+}
+
+type tokenBucket struct {
+	limit    config.RateLimit
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+	day      string
+	dayCount int64
+	allowed  int64
+	limited  int64
+}
+
+// RateLimiter enforces per-IP and per-API-key token buckets with route overrides
+type RateLimiter struct {
+	config config.RateLimitConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]map[string]*tokenBucket // client -> route -> bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter creates a limiter from the given configuration
+func NewRateLimiter(limits config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:  limits,
+		now:     time.Now,
+		buckets: make(map[string]map[string]*tokenBucket),
+	}
+}
+
+// Disabled reports whether rate limiting is turned off in config
+func (l *RateLimiter) Disabled() bool {
+	return l.config.Disabled
+}
+
+// Allow consumes a token for the client on the route. keyID is empty for anonymous
+// clients, which are limited by IP instead.
+func (l *RateLimiter) Allow(route, ip, keyID string) RateDecision {
+	client, limit, bucketRoute := l.resolve(route, ip, keyID)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	bucket := l.bucket(client, bucketRoute, limit, now)
+	decision := RateDecision{Limit: limit}
+
+	if limit.DailyQuota > 0 && bucket.dayCount >= limit.DailyQuota {
+		bucket.limited++
+		decision.QuotaExceeded = true
+		decision.RetryAfter = nextUTCMidnight(now).Sub(now)
+		return decision
+	}
+
+	if bucket.tokens < 1 {
+		bucket.limited++
+		missing := 1 - bucket.tokens
+		decision.RetryAfter = time.Duration(math.Ceil(missing/limit.RequestsPerSecond*1000)) * time.Millisecond
+		return decision
+	}
+
+	bucket.tokens--
+	bucket.dayCount++
+	bucket.allowed++
+	decision.Allowed = true
+	decision.Remaining = int(bucket.tokens)
+	return decision
+}
+
+// AllowCredentials reports whether the IP may present credentials. It checks,
+// without spending, the IP's bucket of failed authentications, which is limited
+// like the default per-IP limit, so guessing keys is throttled before the key
+// store is consulted.
+func (l *RateLimiter) AllowCredentials(ip string) RateDecision {
+	limit := normalizeLimit(l.config.Default.PerIP)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket := l.bucket("ip:"+ip, authFailureRoute, limit, l.now())
+	decision := RateDecision{Limit: limit, Remaining: int(bucket.tokens)}
+	if bucket.tokens < 1 {
+		bucket.limited++
+		missing := 1 - bucket.tokens
+		decision.RetryAfter = time.Duration(math.Ceil(missing/limit.RequestsPerSecond*1000)) * time.Millisecond
+		return decision
+	}
+	decision.Allowed = true
+	return decision
+}
+
+// RecordAuthFailure spends a token from the IP's bucket of failed authentications
+func (l *RateLimiter) RecordAuthFailure(ip string) {
+	limit := normalizeLimit(l.config.Default.PerIP)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket := l.bucket("ip:"+ip, authFailureRoute, limit, l.now())
+	bucket.tokens = math.Max(0, bucket.tokens-1)
+	bucket.dayCount++
+}
+
+// bucket returns the client's bucket for a route, refilled to now, creating it
+// when missing. l.mu must be held.
+func (l *RateLimiter) bucket(client, route string, limit config.RateLimit, now time.Time) *tokenBucket {
+	l.sweep(now)
+
+	routes, exists := l.buckets[client]
+	if !exists {
+		routes = make(map[string]*tokenBucket)
+		l.buckets[client] = routes
+	}
+	bucket, exists := routes[route]
+	if !exists {
+		bucket = &tokenBucket{limit: limit, tokens: float64(limit.Burst), updated: now}
+		routes[route] = bucket
+	}
+
+	bucket.refill(now)
+	bucket.lastSeen = now
+	return bucket
+}
+
+// resolve picks the bucket identity and limit for a request. Routes without an
+// override share a single "*" bucket per client.
+func (l *RateLimiter) resolve(route, ip, keyID string) (string, config.RateLimit, string) {
+	limits, exists := l.config.Routes[route]
+	bucketRoute := route
+	if !exists {
+		limits = l.config.Default
+		bucketRoute = "*"
+	}
+
+	if keyID != "" {
+		limit := limits.PerKey
+		if limit.RequestsPerSecond <= 0 {
+			limit = l.config.Default.PerKey
+		}
+		return "key:" + keyID, normalizeLimit(limit), bucketRoute
+	}
+
+	limit := limits.PerIP
+	if limit.RequestsPerSecond <= 0 {
+		limit = l.config.Default.PerIP
+	}
+	return "ip:" + ip, normalizeLimit(limit), bucketRoute
+}
+
+// Usage returns the usage of a single client such as "key:ak_123" or "ip:10.0.0.1"
+func (l *RateLimiter) Usage(client string) (ClientUsage, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	routes, exists := l.buckets[client]
+	if !exists {
+		return ClientUsage{}, false
+	}
+	return l.usage(client, routes), true
+}
+
+// AllUsage returns the usage of every tracked client
+func (l *RateLimiter) AllUsage() []ClientUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage := make([]ClientUsage, 0, len(l.buckets))
+	for client, routes := range l.buckets {
+		usage = append(usage, l.usage(client, routes))
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Client < usage[j].Client })
+	return usage
+}
+
+func (l *RateLimiter) usage(client string, routes map[string]*tokenBucket) ClientUsage {
+	now := l.now()
+	usage := ClientUsage{Client: client, Buckets: []BucketUsage{}}
+	for route, bucket := range routes {
+		bucket.refill(now)
+		usage.Allowed += bucket.allowed
+		usage.Limited += bucket.limited
+		usage.Buckets = append(usage.Buckets, BucketUsage{
+			Route:    route,
+			Tokens:   math.Floor(bucket.tokens*100) / 100,
+			Burst:    bucket.limit.Burst,
+			Rate:     bucket.limit.RequestsPerSecond,
+			DayCount: bucket.dayCount,
+			DayQuota: bucket.limit.DailyQuota,
+			Allowed:  bucket.allowed,
+			Limited:  bucket.limited,
+			LastSeen: bucket.lastSeen.UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(usage.Buckets, func(i, j int) bool { return usage.Buckets[i].Route < usage.Buckets[j].Route })
+	return usage
+}
+
+// sweep drops buckets that have been idle long enough to be full again.
+// Buckets carrying a daily quota are kept until the day rolls over.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	today := now.UTC().Format("2006-01-02")
+	for client, routes := range l.buckets {
+		for route, bucket := range routes {
+			if now.Sub(bucket.lastSeen) < bucketIdleTimeout {
+				continue
+			}
+			if bucket.limit.DailyQuota > 0 && bucket.day == today {
+				continue
+			}
+			delete(routes, route)
+		}
+		if len(routes) == 0 {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.RequestsPerSecond)
+		b.updated = now
+	}
+
+	day := now.UTC().Format("2006-01-02")
+	if b.day != day {
+		b.day = day
+		b.dayCount = 0
+	}
+}
+
+func normalizeLimit(limit config.RateLimit) config.RateLimit {
+	if limit.Burst < 1 {
+		limit.Burst = int(math.Max(1, math.Ceil(limit.RequestsPerSecond)))
+	}
+	return limit
+}
+
+func nextUTCMidnight(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"backend/config"
+)
+
+func newTestRateLimiter(now *time.Time) *RateLimiter {
+	limiter := NewRateLimiter(config.RateLimitConfig{
+		Default: config.RouteRateLimit{
+			PerIP:  config.RateLimit{RequestsPerSecond: 1, Burst: 2},
+			PerKey: config.RateLimit{RequestsPerSecond: 10, Burst: 3},
+		},
+		Routes: map[string]config.RouteRateLimit{
+			"GET /api/quote": {
+				PerIP:  config.RateLimit{RequestsPerSecond: 1, Burst: 1},
+				PerKey: config.RateLimit{RequestsPerSecond: 1, Burst: 5, DailyQuota: 2},
+			},
+		},
+	})
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		route string
+		ip    string
+		keyID string
+		// calls are made at the given offsets from the start
+		calls []time.Duration
+		want  []bool
+	}{
+		{"ip burst", "GET /api/events", "10.0.0.1", "", []time.Duration{0, 0, 0}, []bool{true, true, false}},
+		{"ip refill", "GET /api/events", "10.0.0.1", "", []time.Duration{0, 0, 0, time.Second}, []bool{true, true, false, true}},
+		{"key burst", "GET /api/events", "10.0.0.1", "ak_1", []time.Duration{0, 0, 0, 0}, []bool{true, true, true, false}},
+		{"route override", "GET /api/quote", "10.0.0.1", "", []time.Duration{0, 0}, []bool{true, false}},
+		{"daily quota", "GET /api/quote", "10.0.0.1", "ak_1", []time.Duration{0, 0, 0, time.Hour}, []bool{true, true, false, false}},
+		{"quota resets at midnight", "GET /api/quote", "10.0.0.1", "ak_1", []time.Duration{0, 0, 0, 24 * time.Hour}, []bool{true, true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+			now := start
+			limiter := newTestRateLimiter(&now)
+			for i, offset := range tt.calls {
+				now = start.Add(offset)
+				decision := limiter.Allow(tt.route, tt.ip, tt.keyID)
+				if decision.Allowed != tt.want[i] {
+					t.Fatalf("call %d allowed = %v, want %v", i, decision.Allowed, tt.want[i])
+				}
+				if !decision.Allowed && decision.RetryAfter <= 0 {
+					t.Fatalf("call %d was limited without a retry delay", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterSeparatesClients(t *testing.T) {
+	now := time.Now()
+	limiter := newTestRateLimiter(&now)
+	for i := 0; i < 2; i++ {
+		limiter.Allow("GET /api/events", "10.0.0.1", "")
+	}
+	if limiter.Allow("GET /api/events", "10.0.0.1", "").Allowed {
+		t.Fatal("exhausted IP was allowed")
+	}
+	if !limiter.Allow("GET /api/events", "10.0.0.2", "").Allowed {
+		t.Fatal("another IP shared the exhausted bucket")
+	}
+	if !limiter.Allow("GET /api/events", "10.0.0.1", "ak_1").Allowed {
+		t.Fatal("a key from the exhausted IP shared its bucket")
+	}
+}
+
+func TestRateLimiterAuthFailures(t *testing.T) {
+	now := time.Now()
+	limiter := newTestRateLimiter(&now)
+	for i := 0; i < 2; i++ {
+		if !limiter.AllowCredentials("10.0.0.1").Allowed {
+			t.Fatalf("attempt %d was limited before any failure budget was spent", i)
+		}
+		limiter.RecordAuthFailure("10.0.0.1")
+	}
+	if limiter.AllowCredentials("10.0.0.1").Allowed {
+		t.Fatal("credentials were allowed after the failure budget was spent")
+	}
+	if !limiter.AllowCredentials("10.0.0.2").Allowed {
+		t.Fatal("another IP was limited")
+	}
+	// Successful authentications don't spend the budget
+	if !limiter.Allow("GET /api/events", "10.0.0.1", "ak_1").Allowed {
+		t.Fatal("per-key bucket was affected by failed authentications")
+	}
+}
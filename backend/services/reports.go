@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+)
+
+// ErrInvalidReport is returned for an unknown report period
+var ErrInvalidReport = errors.New("invalid report")
+
+// ReportWindow returns the period containing t: a UTC day, or a UTC week
+// starting on Monday
+func ReportWindow(period string, t time.Time) (time.Time, time.Time, error) {
+	day := time.Date(t.UTC().Year(), t.UTC().Month(), t.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case models.ReportDaily:
+		return day, day.AddDate(0, 0, 1), nil
+	case models.ReportWeekly:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: period must be %s or %s", ErrInvalidReport, models.ReportDaily, models.ReportWeekly)
+	}
+}
+
+// ReportID identifies the report for the period starting at start
+func ReportID(period string, start time.Time) string {
+	return period + ":" + start.Format("2006-01-02")
+}
+
+// ReportGenerator builds the daily and weekly transfer reports from the
+// stored events and keeps them in the report store
+type ReportGenerator struct {
+	Store database.ReportStore
+	// Tenant selects the chain registry lanes are resolved with; empty is the default tenant
+	Tenant string
+}
+
+// NewReportGenerator creates a generator backed by the given store
+func NewReportGenerator(store database.ReportStore) *ReportGenerator {
+	return &ReportGenerator{Store: store}
+}
+
+// Run regenerates the current and previous reports of every period each
+// configured interval until ctx is cancelled. A previous period is only
+// regenerated until it has been reported complete.
+func (g *ReportGenerator) Run(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+		for _, period := range []string{models.ReportDaily, models.ReportWeekly} {
+			start, _, _ := ReportWindow(period, now)
+			previous, _, _ := ReportWindow(period, start.Add(-time.Nanosecond))
+			if report, err := g.Store.GetReport(ctx, ReportID(period, previous)); err != nil || !report.Complete {
+				if _, err := g.Generate(ctx, period, previous); err != nil {
+					log.Printf("Error generating %s report for %s: %v", period, previous.Format("2006-01-02"), err)
+				}
+			}
+			if _, err := g.Generate(ctx, period, now); err != nil {
+				log.Printf("Error generating %s report for %s: %v", period, start.Format("2006-01-02"), err)
+			}
+		}
+
+		interval := time.Duration(config.GetReportConfig().IntervalMinutes) * time.Minute
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Generate aggregates the period containing at and stores the report,
+// replacing any earlier one for the same period
+func (g *ReportGenerator) Generate(ctx context.Context, period string, at time.Time) (*models.Report, error) {
+	start, end, err := ReportWindow(period, at)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := g.Store.TransferStats(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	report := buildReport(config.ForTenant(g.Tenant), period, start, end, stats)
+	report.GeneratedAt = now
+	report.Complete = !now.Before(end)
+	if err := g.Store.SaveReport(ctx, report); err != nil {
+		return nil, err
+	}
+	log.Printf("Generated %s report %s: %d transfers", period, report.ID, report.Transfers)
+	return report, nil
+}
+
+// Get returns the stored report for the period containing at
+func (g *ReportGenerator) Get(ctx context.Context, period string, at time.Time) (*models.Report, error) {
+	start, _, err := ReportWindow(period, at)
+	if err != nil {
+		return nil, err
+	}
+	return g.Store.GetReport(ctx, ReportID(period, start))
+}
+
+// List returns the period's most recent stored reports, newest first
+func (g *ReportGenerator) List(ctx context.Context, period string, limit int) ([]models.Report, error) {
+	if _, _, err := ReportWindow(period, time.Now()); err != nil {
+		return nil, err
+	}
+	return g.Store.ListReports(ctx, period, limit)
+}
+
+// buildReport totals the lane statistics per lane, per source chain and overall
+func buildReport(registry *config.TenantConfig, period string, start, end time.Time, stats *database.TransferStats) *models.Report {
+	report := &models.Report{
+		ID:           ReportID(period, start),
+		Period:       period,
+		Start:        start,
+		End:          end,
+		ReportTotals: reportTotals(stats.Lanes),
+		Chains:       []models.ChainReport{},
+		Lanes:        []models.LaneReport{},
+	}
+	report.MedianLatencySeconds = stats.MedianLatency
+
+	byChain := make(map[string][]database.LaneStats)
+	for _, lane := range stats.Lanes {
+		byChain[lane.SourceChainID] = append(byChain[lane.SourceChainID], lane)
+		destination, _ := registry.ChainIDForSelector(lane.DestinationChainSelector)
+		totals := reportTotals([]database.LaneStats{lane})
+		totals.MedianLatencySeconds = lane.MedianLatency
+		report.Lanes = append(report.Lanes, models.LaneReport{
+			SourceChainID:            lane.SourceChainID,
+			DestinationChainID:       destination,
+			DestinationChainSelector: lane.DestinationChainSelector,
+			ReportTotals:             totals,
+		})
+	}
+	for chainID, lanes := range byChain {
+		totals := reportTotals(lanes)
+		if median, measured := stats.ChainMedianLatencies[chainID]; measured {
+			totals.MedianLatencySeconds = &median
+		}
+		report.Chains = append(report.Chains, models.ChainReport{ChainID: chainID, ReportTotals: totals})
+	}
+
+	sort.Slice(report.Chains, func(i, j int) bool {
+		return report.Chains[i].ChainID < report.Chains[j].ChainID
+	})
+	sort.Slice(report.Lanes, func(i, j int) bool {
+		if report.Lanes[i].SourceChainID != report.Lanes[j].SourceChainID {
+			return report.Lanes[i].SourceChainID < report.Lanes[j].SourceChainID
+		}
+		return report.Lanes[i].DestinationChainSelector < report.Lanes[j].DestinationChainSelector
+	})
+	return report
+}
+
+// reportTotals combines lane statistics. Users are counted once across lanes.
+// The median latency is left for the caller, as it can't be combined.
+func reportTotals(stats []database.LaneStats) models.ReportTotals {
+	volume, fees := new(big.Int), new(big.Int)
+	users := make(map[string]bool)
+	var totals models.ReportTotals
+
+	for _, lane := range stats {
+		totals.Transfers += lane.Transfers
+		totals.Delivered += lane.Delivered
+		addDecimal(volume, lane.Volume)
+		addDecimal(fees, lane.Fees)
+		for _, user := range lane.Users {
+			users[user] = true
+		}
+	}
+
+	totals.UniqueUsers = int64(len(users))
+	totals.Volume = volume.String()
+	totals.FeesPaid = fees.String()
+	return totals
+}
+
+// addDecimal adds a base-unit sum to total. Sums from Mongo are Decimal128
+// strings, which may carry an exponent.
+func addDecimal(total *big.Int, value string) {
+	if amount, ok := new(big.Int).SetString(value, 10); ok {
+		total.Add(total, amount)
+		return
+	}
+	if amount, ok := new(big.Float).SetPrec(256).SetString(value); ok {
+		integer, _ := amount.Int(nil)
+		total.Add(total, integer)
+	}
+}
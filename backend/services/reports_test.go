@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestReportWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		period    string
+		at        time.Time
+		wantStart string
+		wantEnd   string
+	}{
+		{"daily", models.ReportDaily, time.Date(2026, 3, 11, 15, 4, 5, 0, time.UTC), "2026-03-11", "2026-03-12"},
+		{"daily at midnight", models.ReportDaily, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), "2026-03-11", "2026-03-12"},
+		{"daily in another zone", models.ReportDaily, time.Date(2026, 3, 11, 23, 0, 0, 0, time.FixedZone("UTC-3", -3*3600)), "2026-03-12", "2026-03-13"},
+		{"weekly midweek", models.ReportWeekly, time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC), "2026-03-09", "2026-03-16"},
+		{"weekly on monday", models.ReportWeekly, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), "2026-03-09", "2026-03-16"},
+		{"weekly on sunday", models.ReportWeekly, time.Date(2026, 3, 15, 23, 59, 59, 0, time.UTC), "2026-03-09", "2026-03-16"},
+		{"weekly across a year", models.ReportWeekly, time.Date(2027, 1, 1, 8, 0, 0, 0, time.UTC), "2026-12-28", "2027-01-04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := ReportWindow(tt.period, tt.at)
+			if err != nil {
+				t.Fatalf("ReportWindow: %v", err)
+			}
+			if got := start.Format("2006-01-02"); got != tt.wantStart {
+				t.Errorf("start = %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format("2006-01-02"); got != tt.wantEnd {
+				t.Errorf("end = %s, want %s", got, tt.wantEnd)
+			}
+			if start.Location() != time.UTC {
+				t.Errorf("start is in %s, want UTC", start.Location())
+			}
+		})
+	}
+
+	if _, _, err := ReportWindow("monthly", time.Now()); !errors.Is(err, ErrInvalidReport) {
+		t.Fatalf("unknown period err = %v, want ErrInvalidReport", err)
+	}
+}
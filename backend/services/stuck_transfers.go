@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StuckTransfer is a MessageSent with no matching MessageReceived after its
+// lane's timeout. The tokens have left the source chain but not arrived.
+type StuckTransfer struct {
+	MessageID                string    `json:"message_id"`
+	SourceChainID            string    `json:"source_chain_id"`
+	DestinationChainID       string    `json:"destination_chain_id,omitempty"`
+	DestinationChainSelector uint64    `json:"destination_chain_selector,string"`
+	SourceMessenger          string    `json:"source_messenger"`
+	SourceTransaction        string    `json:"source_transaction"`
+	SourceBlock              uint64    `json:"source_block"`
+	Receiver                 string    `json:"receiver,omitempty"`
+	Client                   string    `json:"client,omitempty"`
+	Amount                   string    `json:"amount,omitempty"`
+	FeeToken                 string    `json:"fee_token,omitempty"`
+	Fees                     string    `json:"fees,omitempty"`
+	SentAt                   time.Time `json:"sent_at"`
+	TimeoutMinutes           int       `json:"timeout_minutes"`
+	DetectedAt               time.Time `json:"detected_at"`
+	// DestinationAllowlist tells whether the destination Messenger would accept the message
+	DestinationAllowlist *DestinationAllowlist `json:"destination_allowlist,omitempty"`
+	// ExplorerURL opens the message in the CCIP explorer
+	ExplorerURL string `json:"explorer_url"`
+}
+
+// DestinationAllowlist is the destination Messenger's view of a message's source
+type DestinationAllowlist struct {
+	Messenger         string `json:"messenger,omitempty"`
+	SourceAllowlisted bool   `json:"source_allowlisted"`
+	SenderAllowlisted bool   `json:"sender_allowlisted"`
+	Error             string `json:"error,omitempty"`
+}
+
+// StuckTransferDetector periodically matches sent messages against received
+// ones and alerts once for every transfer that misses its lane's timeout.
+// Alerts are recorded in Alerted, so they are sent once across restarts and
+// servers. Both chains' Messengers must be monitored for their events to be stored.
+type StuckTransferDetector struct {
+	Events   database.EventStore
+	Alerted  database.StuckAlertStore
+	Clients  *ChainClients
+	Notifier Notifier
+
+	mu        sync.RWMutex
+	stuck     map[string]StuckTransfer
+	checkedAt time.Time
+}
+
+// NewStuckTransferDetector creates a detector that alerts through notifier
+func NewStuckTransferDetector(events database.EventStore, alerted database.StuckAlertStore, clients *ChainClients, notifier Notifier) *StuckTransferDetector {
+	return &StuckTransferDetector{Events: events, Alerted: alerted, Clients: clients, Notifier: notifier, stuck: make(map[string]StuckTransfer)}
+}
+
+// Stuck returns the transfers found stuck by the last check, oldest first
+func (d *StuckTransferDetector) Stuck() ([]StuckTransfer, time.Time) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	transfers := make([]StuckTransfer, 0, len(d.stuck))
+	for _, transfer := range d.stuck {
+		transfers = append(transfers, transfer)
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].SentAt.Before(transfers[j].SentAt)
+	})
+	return transfers, d.checkedAt
+}
+
+// Run checks for stuck transfers every configured interval until ctx is cancelled
+func (d *StuckTransferDetector) Run(ctx context.Context) {
+	for {
+		if err := d.Check(ctx); err != nil {
+			log.Printf("Error checking for stuck transfers: %v", err)
+		}
+
+		interval := time.Duration(config.GetStuckTransferConfig().IntervalSeconds) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Check scans the sent messages within the lookback window, alerting on
+// newly stuck transfers and forgetting those that have since arrived
+func (d *StuckTransferDetector) Check(ctx context.Context) error {
+	settings := config.GetStuckTransferConfig()
+	now := time.Now().UTC()
+	filter := database.EventFilter{
+		EventName: "MessageSent",
+		From:      now.Add(-time.Duration(settings.LookbackHours) * time.Hour),
+		Limit:     database.MaxEventLimit,
+	}
+
+	found := make(map[string]StuckTransfer)
+	for {
+		sent, err := d.Events.FindEvents(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, event := range sent {
+			transfer, overdue := d.overdue(event, now)
+			if !overdue {
+				continue
+			}
+			received, err := d.Events.FindEvents(ctx, database.EventFilter{EventName: "MessageReceived", MessageID: event.MessageID, Limit: 1})
+			if err != nil {
+				return err
+			}
+			if len(received) == 0 {
+				found[transfer.MessageID] = transfer
+			}
+		}
+		if len(sent) < filter.Limit {
+			break
+		}
+		filter.Offset += len(sent)
+	}
+
+	// The map is replaced on every check, never modified, so the snapshot can be read unlocked
+	d.mu.RLock()
+	previous := d.stuck
+	d.mu.RUnlock()
+
+	var newlyStuck []StuckTransfer
+	for id, transfer := range found {
+		if known, exists := previous[id]; exists {
+			transfer.DetectedAt = known.DetectedAt
+		} else {
+			transfer.DetectedAt = now
+		}
+		transfer.DestinationAllowlist = d.destinationAllowlist(ctx, transfer)
+		found[id] = transfer
+		if _, exists := previous[id]; !exists {
+			newlyStuck = append(newlyStuck, transfer)
+		}
+	}
+	for id := range previous {
+		if _, stillStuck := found[id]; !stillStuck {
+			log.Printf("Transfer %s is no longer stuck", id)
+		}
+	}
+
+	d.mu.Lock()
+	d.stuck = found
+	d.checkedAt = now
+	d.mu.Unlock()
+
+	lookback := time.Duration(settings.LookbackHours) * time.Hour
+	for _, transfer := range newlyStuck {
+		first, err := d.Alerted.MarkAlerted(ctx, transfer.MessageID, now, transfer.SentAt.Add(lookback))
+		if err != nil {
+			// Alert anyway; a repeated alert is better than a missed one
+			log.Printf("Error recording stuck transfer alert for %s: %v", transfer.MessageID, err)
+		} else if !first {
+			continue
+		}
+		d.alert(ctx, transfer)
+	}
+	return nil
+}
+
+// overdue builds the transfer for a MessageSent and tells whether it is past
+// its lane's timeout. The transfer was sent at the time of its block, which
+// the monitor stores in timestamp; created_at, when the event was stored, is
+// only used for events without one.
+func (d *StuckTransferDetector) overdue(event models.EventData, now time.Time) (StuckTransfer, bool) {
+	sentAt, err := time.Parse(models.TimeLayout, event.Timestamp)
+	if err != nil {
+		sentAt, err = time.Parse(models.TimeLayout, event.CreatedAt)
+	}
+	if err != nil || event.MessageID == "" {
+		return StuckTransfer{}, false
+	}
+	registry := d.Clients.Registry()
+	destination, _ := registry.ChainIDForSelector(event.DestinationChainSelector)
+	timeout := registry.StuckTimeout(event.ChainID, destination)
+	if now.Sub(sentAt) < timeout {
+		return StuckTransfer{}, false
+	}
+
+	return StuckTransfer{
+		MessageID:                event.MessageID,
+		SourceChainID:            event.ChainID,
+		DestinationChainID:       destination,
+		DestinationChainSelector: event.DestinationChainSelector,
+		SourceMessenger:          event.ContractAddress,
+		SourceTransaction:        event.TransactionHash,
+		SourceBlock:              event.BlockNumber,
+		Receiver:                 event.Receiver,
+		Client:                   event.Client,
+		Amount:                   event.Amount,
+		FeeToken:                 event.FeeToken,
+		Fees:                     event.Fees,
+		SentAt:                   sentAt,
+		TimeoutMinutes:           int(timeout / time.Minute),
+		ExplorerURL:              "https://ccip.chain.link/msg/" + event.MessageID,
+	}, true
+}
+
+// destinationAllowlist reads whether the destination Messenger allowlists the
+// source chain and the sending Messenger, the usual reasons delivery fails
+func (d *StuckTransferDetector) destinationAllowlist(ctx context.Context, transfer StuckTransfer) *DestinationAllowlist {
+	status := &DestinationAllowlist{}
+	if transfer.DestinationChainID == "" {
+		status.Error = fmt.Sprintf("no configured chain has CCIP chain selector %d", transfer.DestinationChainSelector)
+		return status
+	}
+	source, err := d.Clients.Registry().GetChainConfig(transfer.SourceChainID)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	messengerAddress, err := d.Clients.Registry().GetContractAddress(transfer.DestinationChainID, "Router")
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	messengerABI, err := config.GetABI("Router")
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	client, err := d.Clients.Get(transfer.DestinationChainID)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Messenger = common.HexToAddress(messengerAddress).Hex()
+	opts := &bind.CallOpts{Context: ctx}
+	messenger := bind.NewBoundContract(common.HexToAddress(messengerAddress), messengerABI, client, nil, nil)
+	if status.SourceAllowlisted, err = callSingle[bool](opts, messenger, "allowlistedSourceChains", source.CCIPChainSelector); err != nil {
+		status.Error = fmt.Sprintf("allowlistedSourceChains: %v", err)
+		return status
+	}
+	if status.SenderAllowlisted, err = callSingle[bool](opts, messenger, "allowlistedSenders", common.HexToAddress(transfer.SourceMessenger)); err != nil {
+		status.Error = fmt.Sprintf("allowlistedSenders: %v", err)
+	}
+	return status
+}
+
+func (d *StuckTransferDetector) alert(ctx context.Context, transfer StuckTransfer) {
+	if d.Notifier == nil {
+		return
+	}
+	message := fmt.Sprintf("Message %s from chain %s to %s was sent at %s in %s and has not arrived within %d minutes",
+		transfer.MessageID, transfer.SourceChainID, transfer.DestinationChainID, transfer.SentAt.Format(time.RFC3339),
+		transfer.SourceTransaction, transfer.TimeoutMinutes)
+	if allowlist := transfer.DestinationAllowlist; allowlist != nil && allowlist.Error == "" {
+		if !allowlist.SourceAllowlisted {
+			message += "; the destination Messenger does not allowlist the source chain"
+		}
+		if !allowlist.SenderAllowlisted {
+			message += "; the destination Messenger does not allowlist the sending Messenger"
+		}
+	}
+
+	err := d.Notifier.Notify(ctx, Alert{
+		Kind:     "stuck_transfer",
+		Severity: SeverityCritical,
+		Title:    "Cross-chain transfer stuck",
+		Message:  message,
+		Details:  transfer,
+		FiredAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error sending stuck transfer alert for %s: %v", transfer.MessageID, err)
+	}
+}
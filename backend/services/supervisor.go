@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/config"
+)
+
+// MonitorState describes the lifecycle stage of a contract event monitor
+type MonitorState string
+
+const (
+	MonitorConnecting MonitorState = "connecting"
+	MonitorRunning    MonitorState = "running"
+	MonitorRetrying   MonitorState = "retrying"
+	MonitorFailed     MonitorState = "failed"
+)
+
+// MonitorStatus is a snapshot of a single monitor as tracked by the supervisor
+type MonitorStatus struct {
+	Tenant       string       `json:"tenant"`
+	ChainID      string       `json:"chain_id"`
+	ContractType string       `json:"contract_type"`
+	State        MonitorState `json:"state"`
+	Attempts     int          `json:"attempts"`
+	LastError    string       `json:"last_error,omitempty"`
+	LastEventAt  *time.Time   `json:"last_event_at,omitempty"`
+	Since        time.Time    `json:"since"`
+}
+
+// Name returns the component name used for health reporting. Monitors of
+// tenants other than the default one are prefixed with the tenant ID.
+func (s MonitorStatus) Name() string {
+	if s.Tenant != config.DefaultTenant {
+		return fmt.Sprintf("monitor:%s/%s/%s", s.Tenant, s.ChainID, s.ContractType)
+	}
+	return fmt.Sprintf("monitor:%s/%s", s.ChainID, s.ContractType)
+}
+
+// monitorSupervisor keeps track of every monitor started by StartContractEventMonitor
+type monitorSupervisor struct {
+	mu       sync.RWMutex
+	monitors map[string]*MonitorStatus
+}
+
+var supervisor = &monitorSupervisor{monitors: make(map[string]*MonitorStatus)}
+
+func monitorKey(tenant, chainID, contractType string) string {
+	return tenant + "/" + chainID + "/" + contractType
+}
+
+// setState records a state transition for a monitor
+func (s *monitorSupervisor) setState(tenant, chainID, contractType string, state MonitorState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := monitorKey(tenant, chainID, contractType)
+	status, exists := s.monitors[key]
+	if !exists {
+		status = &MonitorStatus{Tenant: tenant, ChainID: chainID, ContractType: contractType}
+		s.monitors[key] = status
+	}
+
+	if status.State != state {
+		status.Since = time.Now().UTC()
+	}
+	status.State = state
+
+	switch state {
+	case MonitorRunning:
+		status.Attempts = 0
+		status.LastError = ""
+	case MonitorRetrying, MonitorFailed:
+		status.Attempts++
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+}
+
+// recordEvent marks that a monitor has just received a log
+func (s *monitorSupervisor) recordEvent(tenant, chainID, contractType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status, exists := s.monitors[monitorKey(tenant, chainID, contractType)]; exists {
+		now := time.Now().UTC()
+		status.LastEventAt = &now
+	}
+}
+
+// MonitorStatuses returns a snapshot of all supervised monitors sorted by name
+func MonitorStatuses() []MonitorStatus {
+	supervisor.mu.RLock()
+	defer supervisor.mu.RUnlock()
+
+	statuses := make([]MonitorStatus, 0, len(supervisor.monitors))
+	for _, status := range supervisor.monitors {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name() < statuses[j].Name()
+	})
+	return statuses
+}
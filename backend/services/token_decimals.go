@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/models"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// tokenDecimalsRetry is how long a chain whose Token contract couldn't be read
+// uses the configured decimals before the contract is read again
+const tokenDecimalsRetry = 5 * time.Minute
+
+// TokenDecimals reads the bridged token's decimals from each chain's Token
+// contract and caches them. Until a contract can be read, the chain's
+// configured token_decimals are used.
+type TokenDecimals struct {
+	Clients *ChainClients
+
+	mu     sync.Mutex
+	cached map[string]cachedDecimals
+}
+
+type cachedDecimals struct {
+	decimals int
+	// retryAt is zero once the decimals have been read from the contract
+	retryAt time.Time
+}
+
+// NewTokenDecimals creates a decimals cache reading through clients
+func NewTokenDecimals(clients *ChainClients) *TokenDecimals {
+	return &TokenDecimals{Clients: clients, cached: make(map[string]cachedDecimals)}
+}
+
+// Get returns the token decimals on a chain. A nil TokenDecimals uses the configured decimals.
+func (t *TokenDecimals) Get(ctx context.Context, chainID string) int {
+	if t == nil {
+		return config.TokenDecimals(chainID)
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	cached, exists := t.cached[chainID]
+	t.mu.Unlock()
+	if exists && (cached.retryAt.IsZero() || now.Before(cached.retryAt)) {
+		return cached.decimals
+	}
+
+	decimals, err := t.read(ctx, chainID)
+	if err != nil {
+		log.Printf("Using configured token decimals for chain %s, reading the contract failed: %v", chainID, err)
+		cached = cachedDecimals{decimals: t.Clients.Registry().TokenDecimals(chainID), retryAt: now.Add(tokenDecimalsRetry)}
+	} else {
+		cached = cachedDecimals{decimals: decimals}
+	}
+
+	t.mu.Lock()
+	t.cached[chainID] = cached
+	t.mu.Unlock()
+	return cached.decimals
+}
+
+// Format fills the event's human-readable amount and fees
+func (t *TokenDecimals) Format(ctx context.Context, event *models.EventData) {
+	event.AmountFormatted = FormatTokenAmount(event.Amount, t.Get(ctx, event.ChainID))
+	event.FeesFormatted = FormatTokenAmount(event.Fees, feeDecimals)
+}
+
+func (t *TokenDecimals) read(ctx context.Context, chainID string) (int, error) {
+	tokenAddress, err := t.Clients.Registry().GetContractAddress(chainID, "Token")
+	if err != nil {
+		return 0, err
+	}
+	tokenABI, err := config.GetABI("Token")
+	if err != nil {
+		return 0, err
+	}
+	client, err := t.Clients.Get(chainID)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	token := bind.NewBoundContract(common.HexToAddress(tokenAddress), tokenABI, client, nil, nil)
+	decimals, err := callSingle[uint8](&bind.CallOpts{Context: ctx}, token, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	return int(decimals), nil
+}
@@ -0,0 +1,594 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errSendUnconfirmed is returned when sending failed without a clear rejection,
+// so the node may have accepted the transaction
+var errSendUnconfirmed = errors.New("transaction may have been sent")
+
+// rejectionMessages are node errors that mean the transaction was not accepted.
+// Nodes reached over RPC report rejections as JSON-RPC errors; these cover
+// in-process backends and proxies that pass the message on as plain text.
+var rejectionMessages = []string{
+	"nonce too low",
+	"nonce too high",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"underpriced",
+	"fee cap less than block base fee",
+	"max fee per gas less than block base fee",
+	"max priority fee per gas higher than max fee per gas",
+	"invalid sender",
+	"oversized data",
+	"transaction type not supported",
+	"txpool is full",
+}
+
+// gasLimitBufferPercent is added to every gas estimate so small state changes
+// between estimation and inclusion don't make the transaction run out of gas
+const gasLimitBufferPercent = 20
+
+// TxClient is the part of an RPC client the transaction engine needs;
+// *ethclient.Client satisfies it
+type TxClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// TxRequest describes a transaction to send from the chain's signer
+type TxRequest struct {
+	To    common.Address
+	Data  []byte
+	Value *big.Int
+	// GasLimit skips estimation when set
+	GasLimit uint64
+	// Purpose is a free-form label kept with the transaction record
+	Purpose string
+}
+
+// LoadSignerKey decrypts the signer's keystore file with the password from its
+// environment variable
+func LoadSignerKey(signer config.SignerConfig) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := os.ReadFile(signer.KeystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+	password, exists := os.LookupEnv(signer.PasswordEnvVar)
+	if !exists {
+		return nil, fmt.Errorf("keystore password variable %s is not set", signer.PasswordEnvVar)
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore %s: %v", signer.KeystoreFile, err)
+	}
+	return key.PrivateKey, nil
+}
+
+// TxEngine sends transactions from one chain's signer and follows them until
+// they are final. Nonces are assigned locally and persisted so concurrent
+// submissions and restarts never reuse or skip one. Transactions that stay
+// unmined are replaced with the same nonce and higher fees.
+type TxEngine struct {
+	ChainID string
+
+	client   TxClient
+	store    database.TxStore
+	signer   config.SignerConfig
+	key      *ecdsa.PrivateKey
+	from     common.Address
+	txSigner types.Signer
+
+	// mu serializes nonce assignment and the tracking pass
+	mu    sync.Mutex
+	nonce uint64
+}
+
+// NewTxEngine creates the engine for a chain. The next nonce is the larger of
+// the persisted one and the node's pending nonce, so transactions sent while
+// the engine was down are not overwritten.
+func NewTxEngine(ctx context.Context, chainID string, client TxClient, key *ecdsa.PrivateKey, store database.TxStore, signer config.SignerConfig) (*TxEngine, error) {
+	networkID, ok := new(big.Int).SetString(chainID, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid chain ID: %s", chainID)
+	}
+
+	engine := &TxEngine{
+		ChainID:  chainID,
+		client:   client,
+		store:    store,
+		signer:   signer,
+		key:      key,
+		from:     crypto.PubkeyToAddress(key.PublicKey),
+		txSigner: types.LatestSignerForChainID(networkID),
+	}
+	if err := engine.syncNonce(ctx); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Address returns the signer's address
+func (e *TxEngine) Address() common.Address {
+	return e.from
+}
+
+// syncNonce moves the local nonce forward to the node's pending nonce if the
+// node is ahead; callers hold e.mu or own the engine exclusively
+func (e *TxEngine) syncNonce(ctx context.Context) error {
+	stored, _, err := e.store.GetNonce(ctx, e.ChainID, e.from.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to read stored nonce: %v", err)
+	}
+	pending, err := e.client.PendingNonceAt(ctx, e.from)
+	if err != nil {
+		return fmt.Errorf("failed to read pending nonce: %v", err)
+	}
+
+	e.nonce = stored
+	if pending > e.nonce {
+		e.nonce = pending
+	}
+	if e.nonce != stored {
+		return e.store.SetNonce(ctx, e.ChainID, e.from.Hex(), e.nonce)
+	}
+	return nil
+}
+
+// Submit signs and sends a transaction and records it as pending. The nonce
+// is used up unless the node rejects the transaction. When sending fails
+// without a rejection, e.g. on a timeout, the node may still have it, so the
+// record stays pending and Track follows it by hash like any other.
+func (e *TxEngine) Submit(ctx context.Context, request TxRequest) (*models.Transaction, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	value := request.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	gasLimit := request.GasLimit
+	if gasLimit == 0 {
+		estimate, err := e.client.EstimateGas(ctx, ethereum.CallMsg{From: e.from, To: &request.To, Value: value, Data: request.Data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas: %v", err)
+		}
+		gasLimit = estimate + estimate*gasLimitBufferPercent/100
+	}
+
+	tip, maxFee, err := e.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newTxID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	record := &models.Transaction{
+		ID:        id,
+		ChainID:   e.ChainID,
+		From:      e.from.Hex(),
+		To:        request.To.Hex(),
+		Nonce:     e.nonce,
+		Value:     value.String(),
+		Data:      hexutil.Encode(request.Data),
+		GasLimit:  gasLimit,
+		Purpose:   request.Purpose,
+		Status:    models.TxPending,
+		CreatedAt: now,
+	}
+
+	err = e.send(ctx, record, tip, maxFee)
+	if errors.Is(err, errSendUnconfirmed) {
+		log.Printf("Transaction %s on chain %s with nonce %d may not have reached the node, tracking it by hash: %v", record.ID, e.ChainID, record.Nonce, err)
+	} else if err != nil {
+		record.Status = models.TxFailed
+		record.Error = err.Error()
+		if saveErr := e.store.SaveTx(ctx, record); saveErr != nil {
+			log.Printf("Error saving rejected transaction %s: %v", record.ID, saveErr)
+		}
+		// A nonce error means something else used the signer; pick up its nonce
+		if strings.Contains(strings.ToLower(err.Error()), "nonce") {
+			if syncErr := e.syncNonce(ctx); syncErr != nil {
+				log.Printf("Error resyncing nonce on chain %s: %v", e.ChainID, syncErr)
+			}
+		}
+		return record, fmt.Errorf("failed to send transaction: %v", err)
+	}
+
+	e.nonce++
+	if err := e.store.SetNonce(ctx, e.ChainID, e.from.Hex(), e.nonce); err != nil {
+		log.Printf("Error saving nonce %d on chain %s: %v", e.nonce, e.ChainID, err)
+	}
+	log.Printf("Sent transaction %s (%s) on chain %s with nonce %d: %s", record.ID, record.Purpose, e.ChainID, record.Nonce, record.LastAttempt().Hash)
+	return record, nil
+}
+
+// send signs the record's transaction with the given fees, sends it and
+// persists the attempt whether or not the node accepted it. It returns
+// errSendUnconfirmed when the node neither accepted nor rejected it.
+func (e *TxEngine) send(ctx context.Context, record *models.Transaction, tip, maxFee *big.Int) error {
+	value, _ := new(big.Int).SetString(record.Value, 10)
+	data, err := hexutil.Decode(record.Data)
+	if err != nil {
+		return err
+	}
+	to := common.HexToAddress(record.To)
+
+	signed, err := types.SignNewTx(e.key, e.txSigner, &types.DynamicFeeTx{
+		ChainID:   e.txSigner.ChainID(),
+		Nonce:     record.Nonce,
+		GasTipCap: tip,
+		GasFeeCap: maxFee,
+		Gas:       record.GasLimit,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+
+	attempt := models.TxAttempt{
+		Hash:                 signed.Hash().Hex(),
+		MaxFeePerGas:         maxFee.String(),
+		MaxPriorityFeePerGas: tip.String(),
+		SentAt:               time.Now().UTC(),
+	}
+	sendErr := e.client.SendTransaction(ctx, signed)
+	if alreadyKnown(sendErr) {
+		// The node already has this exact transaction, e.g. from a retried send
+		sendErr = nil
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		attempt.Unconfirmed = !sendRejected(sendErr)
+	}
+	record.Attempts = append(record.Attempts, attempt)
+	record.UpdatedAt = attempt.SentAt
+
+	if err := e.store.SaveTx(ctx, record); err != nil {
+		log.Printf("Error saving transaction %s: %v", record.ID, err)
+	}
+	if attempt.Unconfirmed {
+		return fmt.Errorf("%w: %v", errSendUnconfirmed, sendErr)
+	}
+	return sendErr
+}
+
+// alreadyKnown reports whether the node refused a transaction only because it
+// already has it
+func alreadyKnown(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "already known") || strings.Contains(message, "known transaction") ||
+		strings.Contains(message, "already imported")
+}
+
+// sendRejected reports whether a send error means the node refused the
+// transaction, rather than the send failing in a way that leaves it unknown
+// whether the node received it
+func sendRejected(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, rejection := range rejectionMessages {
+		if strings.Contains(message, rejection) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestFees returns EIP-1559 fees: the node's suggested tip, and a fee cap
+// of twice the latest base fee plus the tip, which stays valid through
+// several full blocks. The configured cap limits both.
+func (e *TxEngine) suggestFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	tip, err := e.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to suggest gas tip: %v", err)
+	}
+	head, err := e.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read latest header: %v", err)
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("chain %s does not support EIP-1559 transactions", e.ChainID)
+	}
+
+	maxFee := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	if limit := e.feeLimit(); limit != nil {
+		if maxFee.Cmp(limit) > 0 {
+			maxFee = limit
+		}
+		if tip.Cmp(maxFee) > 0 {
+			tip = new(big.Int).Set(maxFee)
+		}
+		if maxFee.Cmp(head.BaseFee) < 0 {
+			return nil, nil, fmt.Errorf("base fee %s wei is above the configured fee cap", head.BaseFee)
+		}
+	}
+	return tip, maxFee, nil
+}
+
+// feeLimit returns the configured fee cap in wei, or nil if there is none
+func (e *TxEngine) feeLimit() *big.Int {
+	if e.signer.MaxFeePerGasGwei <= 0 {
+		return nil
+	}
+	limit, _ := new(big.Float).Mul(big.NewFloat(e.signer.MaxFeePerGasGwei), big.NewFloat(1e9)).Int(nil)
+	return limit
+}
+
+// Run tracks pending transactions until the context is cancelled
+func (e *TxEngine) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(e.signer.PollIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Track(ctx); err != nil {
+			log.Printf("Error tracking transactions on chain %s: %v", e.ChainID, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Track makes one pass over the chain's pending and stuck transactions. A
+// transaction is final once one of its attempts has the configured number of
+// confirmations; until then, an attempt that has gone unmined for too long is
+// replaced with bumped fees, and once the last attempt has gone unmined for
+// that long the transaction is marked stuck.
+func (e *TxEngine) Track(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var pending []models.Transaction
+	for _, status := range []string{models.TxPending, models.TxStuck} {
+		txs, err := e.store.ListTxs(ctx, database.TxFilter{ChainID: e.ChainID, Status: status})
+		if err != nil {
+			return err
+		}
+		pending = append(pending, txs...)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	head, err := e.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read block number: %v", err)
+	}
+	minedNonce, err := e.client.NonceAt(ctx, e.from, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read nonce: %v", err)
+	}
+
+	for i := range pending {
+		if err := e.track(ctx, &pending[i], head, minedNonce); err != nil {
+			log.Printf("Error tracking transaction %s: %v", pending[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (e *TxEngine) track(ctx context.Context, record *models.Transaction, head, minedNonce uint64) error {
+	// Any attempt may be the one that was mined, not just the latest,
+	// including sends that failed without a rejection
+	for _, attempt := range record.Attempts {
+		if attempt.Error != "" && !attempt.Unconfirmed {
+			continue
+		}
+		receipt, err := e.client.TransactionReceipt(ctx, common.HexToHash(attempt.Hash))
+		if receiptNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		mined := receipt.BlockNumber.Uint64()
+		if head < mined || head-mined+1 < e.signer.ConfirmationBlocks {
+			return nil
+		}
+
+		record.MinedHash = attempt.Hash
+		record.BlockNumber = mined
+		record.GasUsed = receipt.GasUsed
+		record.Status = models.TxConfirmed
+		record.Error = ""
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			record.Status = models.TxReverted
+		}
+		record.UpdatedAt = time.Now().UTC()
+		log.Printf("Transaction %s on chain %s %s in block %d: %s", record.ID, e.ChainID, record.Status, mined, attempt.Hash)
+		return e.store.SaveTx(ctx, record)
+	}
+
+	// None of our attempts was mined but the nonce was, so another transaction took it
+	if minedNonce > record.Nonce {
+		record.Status = models.TxFailed
+		record.Error = fmt.Sprintf("nonce %d was used by another transaction", record.Nonce)
+		record.UpdatedAt = time.Now().UTC()
+		log.Printf("Transaction %s on chain %s failed: %s", record.ID, e.ChainID, record.Error)
+		return e.store.SaveTx(ctx, record)
+	}
+
+	last := record.LastAttempt()
+	if last == nil || time.Since(last.SentAt) < time.Duration(e.signer.StuckAfterSeconds)*time.Second {
+		return nil
+	}
+	if len(record.Attempts) >= e.signer.MaxAttempts {
+		if record.Status == models.TxStuck {
+			return nil
+		}
+		record.Status = models.TxStuck
+		record.Error = fmt.Sprintf("still unmined after %d attempts", len(record.Attempts))
+		record.UpdatedAt = time.Now().UTC()
+		log.Printf("Transaction %s on chain %s is stuck: %s", record.ID, e.ChainID, record.Error)
+		return e.store.SaveTx(ctx, record)
+	}
+	return e.bump(ctx, record)
+}
+
+// bump replaces the latest attempt with one paying the larger of the bumped
+// fees and the current suggestion. Nodes only accept a replacement that
+// raises both fees by at least 10%.
+func (e *TxEngine) bump(ctx context.Context, record *models.Transaction) error {
+	last := record.LastAttempt()
+	oldTip, _ := new(big.Int).SetString(last.MaxPriorityFeePerGas, 10)
+	oldMaxFee, _ := new(big.Int).SetString(last.MaxFeePerGas, 10)
+
+	tip, maxFee, err := e.suggestFees(ctx)
+	if err != nil {
+		return err
+	}
+	tip = maxBig(tip, bumpFee(oldTip, e.signer.FeeBumpPercent))
+	maxFee = maxBig(maxFee, bumpFee(oldMaxFee, e.signer.FeeBumpPercent))
+	if limit := e.feeLimit(); limit != nil && maxFee.Cmp(limit) > 0 {
+		return fmt.Errorf("replacement fee %s wei is above the configured fee cap", maxFee)
+	}
+	if tip.Cmp(maxFee) > 0 {
+		tip = new(big.Int).Set(maxFee)
+	}
+
+	if err := e.send(ctx, record, tip, maxFee); err != nil && !errors.Is(err, errSendUnconfirmed) {
+		return fmt.Errorf("failed to send replacement: %v", err)
+	}
+	log.Printf("Replaced stuck transaction %s on chain %s (attempt %d): %s", record.ID, e.ChainID, len(record.Attempts), record.LastAttempt().Hash)
+	return nil
+}
+
+// receiptNotFound reports whether a receipt lookup failed only because the
+// transaction is not mined yet. Geth answers "transaction indexing is in
+// progress" instead of not found while its index is catching up.
+func receiptNotFound(err error) bool {
+	return errors.Is(err, ethereum.NotFound) || (err != nil && strings.Contains(err.Error(), "indexing is in progress"))
+}
+
+func bumpFee(fee *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(int64(100+percent)))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func newTxID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "tx_" + hex.EncodeToString(buf), nil
+}
+
+// TxEngines holds the transaction engine of every chain with a signer
+type TxEngines struct {
+	Store database.TxStore
+
+	mu      sync.RWMutex
+	engines map[string]*TxEngine
+}
+
+// NewTxEngines creates an empty registry backed by a transaction store
+func NewTxEngines(store database.TxStore) *TxEngines {
+	return &TxEngines{Store: store, engines: make(map[string]*TxEngine)}
+}
+
+// StartTxEngines starts an engine for every chain of the clients' tenant with
+// a signer. Chains whose key or RPC connection cannot be loaded are logged and skipped.
+func StartTxEngines(ctx context.Context, clients *ChainClients, store database.TxStore) *TxEngines {
+	engines := NewTxEngines(store)
+	registry := clients.Registry()
+	for _, chainID := range registry.ChainIDs() {
+		signer, exists := registry.GetSignerConfig(chainID)
+		if !exists {
+			continue
+		}
+
+		key, err := LoadSignerKey(signer)
+		if err != nil {
+			log.Printf("Transaction engine for chain %s disabled: %v", chainID, err)
+			continue
+		}
+		client, err := clients.Get(chainID)
+		if err != nil {
+			log.Printf("Transaction engine for chain %s disabled: %v", chainID, err)
+			continue
+		}
+		engine, err := NewTxEngine(ctx, chainID, client, key, store, signer)
+		if err != nil {
+			log.Printf("Transaction engine for chain %s disabled: %v", chainID, err)
+			continue
+		}
+
+		engines.Add(engine)
+		go engine.Run(ctx)
+		log.Printf("Transaction engine for chain %s started with signer %s", chainID, engine.Address().Hex())
+	}
+	return engines
+}
+
+// Add registers an engine
+func (r *TxEngines) Add(engine *TxEngine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.engines[engine.ChainID] = engine
+}
+
+// Get returns the engine for a chain, or false if the chain has no signer
+func (r *TxEngines) Get(chainID string) (*TxEngine, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	engine, exists := r.engines[chainID]
+	return engine, exists
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+// flakyTxClient fails the next send with err, after passing the transaction
+// to the chain when delivered is set
+type flakyTxClient struct {
+	simulated.Client
+	err       error
+	delivered bool
+}
+
+func (c *flakyTxClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	err := c.err
+	c.err = nil
+	if err == nil || c.delivered {
+		if sendErr := c.Client.SendTransaction(ctx, tx); sendErr != nil {
+			return sendErr
+		}
+	}
+	return err
+}
+
+func TestTxEngineSendErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		delivered  bool
+		wantStatus string
+		wantNonce  uint64
+		// wantAttempts is the number of attempts once the transaction is confirmed
+		wantAttempts int
+	}{
+		{"accepted", nil, true, models.TxConfirmed, 1, 1},
+		{"already known", errors.New("already known"), true, models.TxConfirmed, 1, 1},
+		{"timeout after delivery", context.DeadlineExceeded, true, models.TxConfirmed, 1, 1},
+		{"connection lost before delivery", errors.New("connection reset by peer"), false, models.TxConfirmed, 1, 2},
+		{"rejected", errors.New("insufficient funds for gas * price + value"), false, models.TxFailed, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			key, _ := crypto.GenerateKey()
+			from := crypto.PubkeyToAddress(key.PublicKey)
+			backend := simulated.NewBackend(types.GenesisAlloc{from: {Balance: big.NewInt(1e18)}})
+			defer backend.Close()
+
+			client := &flakyTxClient{Client: backend.Client()}
+			store := database.NewMemoryTxStore()
+			signer := config.SignerConfig{ConfirmationBlocks: 1, FeeBumpPercent: 12, MaxAttempts: 3}
+			engine, err := NewTxEngine(ctx, "1337", client, key, store, signer)
+			if err != nil {
+				t.Fatalf("NewTxEngine: %v", err)
+			}
+
+			client.err, client.delivered = tt.err, tt.delivered
+			record, err := engine.Submit(ctx, TxRequest{To: common.HexToAddress("0x1234"), Value: big.NewInt(1), GasLimit: 21000})
+			if (err != nil) != (tt.wantStatus == models.TxFailed) {
+				t.Fatalf("Submit err = %v", err)
+			}
+			if engine.nonce != tt.wantNonce {
+				t.Fatalf("next nonce = %d, want %d", engine.nonce, tt.wantNonce)
+			}
+
+			// Transactions the node received are mined before the first pass;
+			// the others are resent by it, since StuckAfterSeconds is 0, and
+			// mined before the second
+			for i := 0; i < 2; i++ {
+				backend.Commit()
+				if err := engine.Track(ctx); err != nil {
+					t.Fatalf("Track: %v", err)
+				}
+			}
+			stored, err := store.GetTx(ctx, record.ID)
+			if err != nil {
+				t.Fatalf("GetTx: %v", err)
+			}
+			if stored.Status != tt.wantStatus || len(stored.Attempts) != tt.wantAttempts {
+				t.Fatalf("status %s with %d attempts, want %s with %d", stored.Status, len(stored.Attempts), tt.wantStatus, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+// droppingTxClient times out on every send without passing the transaction
+// to the chain, keeping the last one so a test can mine it later
+type droppingTxClient struct {
+	simulated.Client
+	last *types.Transaction
+}
+
+func (c *droppingTxClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.last = tx
+	return context.DeadlineExceeded
+}
+
+func TestTxEngineMarksStuckAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	backend := simulated.NewBackend(types.GenesisAlloc{from: {Balance: big.NewInt(1e18)}})
+	defer backend.Close()
+
+	client := &droppingTxClient{Client: backend.Client()}
+	store := database.NewMemoryTxStore()
+	signer := config.SignerConfig{ConfirmationBlocks: 1, FeeBumpPercent: 12, MaxAttempts: 2}
+	engine, err := NewTxEngine(ctx, "1337", client, key, store, signer)
+	if err != nil {
+		t.Fatalf("NewTxEngine: %v", err)
+	}
+	record, err := engine.Submit(ctx, TxRequest{To: common.HexToAddress("0x1234"), Value: big.NewInt(1), GasLimit: 21000})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	// The first pass sends the second and final attempt, since
+	// StuckAfterSeconds is 0; the second finds it unmined too
+	wantStatuses := []string{models.TxPending, models.TxStuck, models.TxStuck}
+	for i, want := range wantStatuses {
+		if err := engine.Track(ctx); err != nil {
+			t.Fatalf("Track: %v", err)
+		}
+		stored, err := store.GetTx(ctx, record.ID)
+		if err != nil {
+			t.Fatalf("GetTx: %v", err)
+		}
+		if stored.Status != want || len(stored.Attempts) != 2 {
+			t.Fatalf("pass %d: status %s with %d attempts, want %s with 2", i+1, stored.Status, len(stored.Attempts), want)
+		}
+	}
+	stuck, err := store.ListTxs(ctx, database.TxFilter{Status: models.TxStuck})
+	if err != nil || len(stuck) != 1 || stuck[0].Error == "" {
+		t.Fatalf("stuck transactions %+v, %v, want the one with an error", stuck, err)
+	}
+
+	// A stuck transaction is still confirmed if its last attempt is mined
+	if err := backend.Client().SendTransaction(ctx, client.last); err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	backend.Commit()
+	if err := engine.Track(ctx); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	stored, err := store.GetTx(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("GetTx: %v", err)
+	}
+	if stored.Status != models.TxConfirmed || stored.Error != "" || stored.MinedHash != client.last.Hash().Hex() {
+		t.Fatalf("status %s, error %q, mined %s, want confirmed with %s", stored.Status, stored.Error, stored.MinedHash, client.last.Hash().Hex())
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Thresholds bound how much worse a run may be than its baseline
+type Thresholds struct {
+	// Latency is the allowed relative increase of p50, p95 and p99 (0.2 = 20%)
+	Latency float64
+	// Success is the allowed absolute drop in success ratio (0.01 = one point)
+	Success float64
+	// Throughput is the allowed relative drop in successful requests per second
+	Throughput float64
+}
+
+// compareReports prints current against baseline and returns every
+// regression beyond the thresholds. Endpoints missing from either side are skipped.
+func compareReports(w io.Writer, baseline, current Report, limits Thresholds) []string {
+	var regressions []string
+	check := func(name string, base, cur Summary) {
+		if base.Requests == 0 || cur.Requests == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%-18s p50 %8.2f → %8.2f ms  p99 %8.2f → %8.2f ms  success %6.2f%% → %6.2f%%  throughput %8.1f → %8.1f/s\n",
+			name, base.Latencies.P50, cur.Latencies.P50, base.Latencies.P99, cur.Latencies.P99,
+			base.Success*100, cur.Success*100, base.Throughput, cur.Throughput)
+
+		latencies := []struct {
+			label     string
+			base, cur float64
+		}{
+			{"p50", base.Latencies.P50, cur.Latencies.P50},
+			{"p95", base.Latencies.P95, cur.Latencies.P95},
+			{"p99", base.Latencies.P99, cur.Latencies.P99},
+		}
+		for _, l := range latencies {
+			if l.base > 0 && l.cur > l.base*(1+limits.Latency) {
+				regressions = append(regressions, fmt.Sprintf("%s %s latency %.2fms is %.0f%% above baseline %.2fms",
+					name, l.label, l.cur, (l.cur/l.base-1)*100, l.base))
+			}
+		}
+		if cur.Success < base.Success-limits.Success {
+			regressions = append(regressions, fmt.Sprintf("%s success ratio %.2f%% is below baseline %.2f%%",
+				name, cur.Success*100, base.Success*100))
+		}
+		if base.Throughput > 0 && cur.Throughput < base.Throughput*(1-limits.Throughput) {
+			regressions = append(regressions, fmt.Sprintf("%s throughput %.1f/s is %.0f%% below baseline %.1f/s",
+				name, cur.Throughput, (1-cur.Throughput/base.Throughput)*100, base.Throughput))
+		}
+	}
+
+	for _, name := range sortedKeys(current.Endpoints) {
+		check(name, baseline.Endpoints[name], current.Endpoints[name])
+	}
+	check("overall", baseline.Overall, current.Overall)
+	return regressions
+}
@@ -1,168 +1,220 @@
 package main
 
+// Load test CLI for the event API.
+//
+//	go run ./tests/load -scenario tests/load/scenarios/ramp.json -report run.json
+//	go run ./tests/load -rate 200 -duration 30s -mix mint=50,list-events=50
+//	go run ./tests/load -scenario tests/load/scenarios/steady.json -baseline run.json
+//	go run ./tests/load -duration 30s -verify
+//
+// Flags override the scenario file, which overrides the built-in defaults.
+
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
-	"math/rand"
+	"os"
 	"time"
 
-	vegeta "github.com/tsenart/vegeta/v12/lib"
-)
+	"backend/config"
+	"backend/services"
 
-var (
-	// Seed for randomization, based on current time
-	seed       = time.Now().UnixNano()
-	// Random source to generate varying values
-	randSource = rand.New(rand.NewSource(seed))
+	"github.com/ethereum/go-ethereum/common"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
 )
 
 func main() {
-	// Define the duration of the load test (5 minutes)
-	duration := 1 * time.Minute
-	// Number of concurrent virtual users making requests
-	virtualUsers := 100
-
-	// ConstantPacer maintains a consistent request frequency of 60 per second
-	pacer := vegeta.ConstantPacer{Freq: 500, Per: time.Second}
-
-	// Define the targets (API endpoints) to test with different HTTP methods
-	targets := []*vegeta.Target{
-		generateTarget("POST", "http://localhost:8080/api/events/mint", generateMintPayload),
-		generateTarget("POST", "http://localhost:8080/api/events/burn", generateBurnPayload),
+	scenarioPath := flag.String("scenario", "", "Scenario JSON file")
+	baseURL := flag.String("url", "", "Base URL of the API under test")
+	duration := flag.Duration("duration", 0, "Length of the attack")
+	workers := flag.Uint64("workers", 0, "Initial number of concurrent workers")
+	pacerType := flag.String("pacer", "", "Pacer type: constant, ramp, step or spike")
+	rate := flag.Float64("rate", 0, "Requests per second for the constant and spike pacers")
+	peak := flag.Float64("peak", 0, "Peak requests per second for the ramp, step and spike pacers")
+	mix := flag.String("mix", "", "Endpoint weights, e.g. mint=40,burn=40,list-events=20")
+	chainID := flag.String("chain", "", "Chain ID used in event payloads")
+	configFile := flag.String("config", "", "Backend config file with the contract registry (defaults to CONFIG_FILE_PATH)")
+	apiKeyEnv := flag.String("api-key-env", "", "Environment variable holding the API key")
+	signed := flag.Bool("signed", false, "Send HMAC-signed requests instead of the plain API key")
+	reportPath := flag.String("report", "", "Write the JSON report to this file")
+	hdrPath := flag.String("hdr", "", "Write the HDR histogram plot of latencies to this file")
+	baselinePath := flag.String("baseline", "", "Compare against this saved JSON report and exit 1 on regression")
+	maxLatency := flag.Float64("max-latency-regression", 0.2, "Allowed relative increase of p50/p95/p99 latency in comparison mode")
+	maxSuccessDrop := flag.Float64("max-success-drop", 0.01, "Allowed absolute drop in success ratio in comparison mode")
+	maxThroughputDrop := flag.Float64("max-throughput-drop", 0.1, "Allowed relative drop in throughput in comparison mode")
+	verify := flag.Bool("verify", false, "Tag written events with a run ID and check afterwards that every acknowledged event was stored intact")
+	runID := flag.String("run-id", "", "Run ID for -verify (generated when empty)")
+	verbose := flag.Bool("v", false, "Log every failed request")
+	flag.Parse()
+
+	scenario := defaultScenario()
+	if *scenarioPath != "" {
+		var err error
+		if scenario, err = loadScenario(*scenarioPath); err != nil {
+			log.Fatalf("Failed to load scenario: %v", err)
+		}
 	}
 
-	// Convert target pointers to values for the Vegeta attack
-	targetValues := make([]vegeta.Target, len(targets))
-	for i, t := range targets {
-		targetValues[i] = *t
+	// Only flags given on the command line override the scenario
+	var flagErr error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "url":
+			scenario.BaseURL = *baseURL
+		case "duration":
+			scenario.Duration = Duration(*duration)
+		case "workers":
+			scenario.Workers = *workers
+		case "pacer":
+			scenario.Pacer.Type = *pacerType
+		case "rate":
+			scenario.Pacer.Rate = *rate
+		case "peak":
+			scenario.Pacer.Peak = *peak
+		case "mix":
+			scenario.Mix, flagErr = parseMix(*mix)
+		case "chain":
+			scenario.ChainID = *chainID
+		case "config":
+			scenario.ConfigFile = *configFile
+		case "api-key-env":
+			scenario.Auth.APIKeyEnv = *apiKeyEnv
+		case "signed":
+			scenario.Auth.Signed = *signed
+		case "verify":
+			scenario.Verify = *verify
+		}
+	})
+	if flagErr != nil {
+		log.Fatalf("Invalid flags: %v", flagErr)
+	}
+	if err := scenario.validate(); err != nil {
+		log.Fatalf("Invalid scenario: %v", err)
 	}
 
-	// Create a targeter to handle a fixed list of targets
-	targeter := vegeta.NewStaticTargeter(targetValues...)
-	// Set up the attacker with the number of workers and a timeout of 30 seconds
-	attacker := vegeta.NewAttacker(vegeta.Workers(uint64(virtualUsers)), vegeta.Timeout(30*time.Second))
+	contracts, err := loadContracts(scenario)
+	if err != nil {
+		log.Fatalf("Failed to load contract registry: %v", err)
+	}
 
-	// Initialize metrics to track the test's performance
-	var metrics vegeta.Metrics
-	for res := range attacker.Attack(targeter, pacer, duration, "Load Test") {
-		// Log any failed requests for debugging
-		if res.Error != "" {
-			log.Printf("Request failed: %s\n", res.Error)
+	credential := ""
+	if scenario.Auth.APIKeyEnv != "" {
+		credential = os.Getenv(scenario.Auth.APIKeyEnv)
+		if credential == "" {
+			log.Printf("Warning: %s is not set; requests are sent without an API key", scenario.Auth.APIKeyEnv)
 		}
-		metrics.Add(res)
-	}
-	metrics.Close()
-
-	// Print summary statistics
-	fmt.Printf("Total requests: %d\n", metrics.Requests)
-	fmt.Printf("Duration of test: %s\n", metrics.Duration)
-	fmt.Printf("Mean requests per second: %.2f\n", metrics.Rate)
-	fmt.Printf("99th percentile latency: %s\n", metrics.Latencies.P99)
-	fmt.Printf("Average latency: %s\n", metrics.Latencies.Mean)
-	fmt.Printf("Minimum latency: %s\n", metrics.Latencies.Min)
-	fmt.Printf("Maximum latency: %s\n", metrics.Latencies.Max)
-	fmt.Printf("Success ratio: %.2f%%\n", metrics.Success*100)
-	fmt.Printf("Average bytes in response: %d bytes\n", int(metrics.BytesIn.Mean))
-	fmt.Printf("Total bytes in: %d bytes\n", metrics.BytesIn.Total)
-	fmt.Printf("Total bytes out: %d bytes\n", metrics.BytesOut.Total)
-}
-
-// Helper function to generate a target with an optional payload
-func generateTarget(method, url string, payloadFunc func() []byte) *vegeta.Target {
-	target := &vegeta.Target{
-		Method: method,
-		URL:    url,
 	}
 
-	if payloadFunc != nil {
-		payload := payloadFunc()
-		if len(payload) == 0 {
-			log.Printf("Warning: Payload returned empty for %s target\n", method)
-			payload = []byte(`{"id":"0x123456","ChainId":"80002","caller_address":"0xC2F20D5c81F5B4450aA9cE62638d0bB01DF1935a","contract_address":"0x1234567890123456789012345678901234567890","block_number":"0x12345678","transaction_hash":"0x1234567890123456789012345678901234567890123456789012345678901234","timestamp":"2023-05-15 12:34:56 MST","amount_from_event":"1000000000000000000","to_from_event":"0x0000000000000000000000000000000000000000"}`)
+	pacer, _ := newPacer(scenario.Pacer, time.Duration(scenario.Duration))
+	targets := newTargetGenerator(scenario, contracts, credential)
+	var checker *verifier
+	if scenario.Verify {
+		if *runID == "" {
+			*runID = newRunID()
 		}
-		target.Body = payload
+		checker = newVerifier(*runID)
+		targets.verifier = checker
+		fmt.Printf("Tagging events with run ID %s\n", *runID)
 	}
+	attacker := vegeta.NewAttacker(vegeta.Workers(scenario.Workers), vegeta.Timeout(time.Duration(scenario.Timeout)))
 
-	return target
-}
+	fmt.Printf("Attacking %s for %v: %s, mix %s\n", scenario.BaseURL, time.Duration(scenario.Duration), pacer, formatMix(scenario.Mix))
 
-// Function to generate random payload for mint events
-func generateMintPayload() []byte {
-	payload := map[string]interface{}{
-		"id":                fmt.Sprintf("0x%016x", randSource.Int63()),
-		"ChainId":           "80002",
-		"caller_address":    "0xC2F20D5c81F5B4450aA9cE62638d0bB01DF1935a",
-		"contract_address":  "0x1234567890123456789012345678901234567890",
-		"block_number":      randSource.Uint64(),
-		"transaction_hash":  fmt.Sprintf("0x%064x", randSource.Uint64()),
-		"timestamp":         time.Now().UTC().Format("2006-01-02 15:04:05 MST"),
-		"amount_from_event": fmt.Sprintf("%d", randSource.Int63n(1000000000000000000)),
-		"to_from_event":     generateRandomAddress(),
-	}
-	jsonPayload, _ := json.Marshal(payload)
-	return jsonPayload
-}
+	startedAt := time.Now()
+	results := newCollector()
+	for res := range attacker.Attack(targets.Targeter(), pacer, time.Duration(scenario.Duration), "Load Test") {
+		if *verbose && (res.Error != "" || res.Code >= 400) {
+			log.Printf("%s %s failed: %d %s %s", res.Method, res.URL, res.Code, res.Error, res.Body)
+		}
+		results.Add(res)
+		if checker != nil {
+			checker.observe(res)
+		}
+	}
+	results.Close()
 
-// Function to generate random payload for burn events
-func generateBurnPayload() []byte {
-	payload := map[string]interface{}{
-		"id":                fmt.Sprintf("0x%016x", randSource.Int63()),
-		"ChainId":           "80002",
-		"caller_address":    "0xC2F20D5c81F5B4450aA9cE62638d0bB01DF1935a",
-		"contract_address":  "0x1234567890123456789012345678901234567890",
-		"block_number":      randSource.Uint64(),
-		"transaction_hash":  fmt.Sprintf("0x%064x", randSource.Uint64()),
-		"timestamp":         time.Now().UTC().Format("2006-01-02 15:04:05 MST"),
-		"amount_from_event": fmt.Sprintf("%d", randSource.Int63n(1000000000000000000)),
-		"to_from_event":     "0x0000000000000000000000000000000000000000",
-	}
-	jsonPayload, _ := json.Marshal(payload)
-	return jsonPayload
-}
+	report := results.report(scenario, pacer, startedAt)
+	printSummary(os.Stdout, report)
 
-// Helper function to generate a random Ethereum address
-func generateRandomAddress() string {
-	return fmt.Sprintf("0x%040x", randSource.Uint64())
-}
+	failed := false
+	if checker != nil {
+		verification, err := checker.verify(scenario.BaseURL, credential)
+		if err != nil {
+			log.Fatalf("Failed to verify run %s: %v", checker.runID, err)
+		}
+		fmt.Println()
+		printVerification(os.Stdout, verification)
+		report.Verification = &verification
+		failed = !verification.Passed
+	}
 
-// Pacer to handle dynamic ramp-up and ramp-down of load (not used in this test)
-type rampPacer struct {
-	start, peak vegeta.Rate
-	duration    time.Duration
-}
+	if *reportPath != "" {
+		if err := writeJSONReport(*reportPath, report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+	if *hdrPath != "" {
+		if err := writeHDRHistogram(*hdrPath, results); err != nil {
+			log.Fatalf("Failed to write HDR histogram: %v", err)
+		}
+	}
 
-// Function to create a custom ramping pacer for dynamic load tests
-func newRampPacer(start, peak float64, duration time.Duration) vegeta.Pacer {
-	return &rampPacer{
-		start:    vegeta.Rate{Freq: int(start), Per: time.Second},
-		peak:     vegeta.Rate{Freq: int(peak), Per: time.Second},
-		duration: duration,
+	if *baselinePath != "" {
+		baseline, err := readJSONReport(*baselinePath)
+		if err != nil {
+			log.Fatalf("Failed to read baseline: %v", err)
+		}
+		fmt.Printf("\nComparing against %s\n", *baselinePath)
+		regressions := compareReports(os.Stdout, baseline, report, Thresholds{
+			Latency:    *maxLatency,
+			Success:    *maxSuccessDrop,
+			Throughput: *maxThroughputDrop,
+		})
+		if len(regressions) > 0 {
+			fmt.Println("\nRegressions:")
+			for _, regression := range regressions {
+				fmt.Printf("  %s\n", regression)
+			}
+			failed = true
+		} else {
+			fmt.Println("\nNo regressions")
+		}
 	}
-}
 
-func (p *rampPacer) Rate(elapsedTime time.Duration) float64 {
-	return float64(p.start.Freq)
+	if failed {
+		os.Exit(1)
+	}
 }
 
-func (p *rampPacer) Pace(elapsedTime time.Duration, elapsedHits uint64) (time.Duration, bool) {
-	if elapsedTime >= p.duration {
-		return 0, false
+// loadContracts reads the contract registry so event payloads name the
+// contracts the server expects for the chain
+func loadContracts(scenario Scenario) (map[string]common.Address, error) {
+	var err error
+	if scenario.ConfigFile != "" {
+		err = config.InitFromFile(scenario.ConfigFile)
+	} else {
+		err = config.Init()
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	var freq int
-	switch {
-	case elapsedTime < p.duration/4:
-		// Ramp up
-		freq = p.start.Freq + int(float64(p.peak.Freq-p.start.Freq)*elapsedTime.Seconds()/(p.duration/4).Seconds())
-	case elapsedTime >= p.duration/4 && elapsedTime < p.duration*3/4:
-		// Maintain peak
-		freq = p.peak.Freq
-	default:
-		// Ramp down
-		remaining := p.duration - elapsedTime
-		freq = p.start.Freq + int(float64(p.peak.Freq-p.start.Freq)*remaining.Seconds()/(p.duration/4).Seconds())
+	contracts := make(map[string]common.Address)
+	for _, contractType := range []string{"Token", "Vault", "Router"} {
+		if address, err := config.GetContractAddress(scenario.ChainID, contractType); err == nil {
+			contracts[contractType] = common.HexToAddress(address)
+		}
 	}
 
-	return time.Second / time.Duration(freq), true
-}
\ No newline at end of file
+	for name, weight := range scenario.Mix {
+		eventName := endpointCatalogue[name].eventName
+		if weight == 0 || eventName == "" {
+			continue
+		}
+		contractType, _ := services.ContractTypeForEvent(eventName)
+		if _, exists := contracts[contractType]; !exists {
+			return nil, fmt.Errorf("mix includes %s but chain %s has no %s contract configured", name, scenario.ChainID, contractType)
+		}
+	}
+	return contracts, nil
+}
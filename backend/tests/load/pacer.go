@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// segment is a stretch of the load profile whose rate moves linearly from
+// one value to another
+type segment struct {
+	duration time.Duration
+	from, to float64
+}
+
+// profilePacer paces an attack along a piecewise-linear rate profile. The
+// ramp, step and spike shapes are all built from segments.
+type profilePacer struct {
+	description string
+	segments    []segment
+	total       time.Duration
+}
+
+func newProfilePacer(description string, segments ...segment) *profilePacer {
+	p := &profilePacer{description: description}
+	for _, s := range segments {
+		if s.duration > 0 {
+			p.segments = append(p.segments, s)
+			p.total += s.duration
+		}
+	}
+	return p
+}
+
+// newPacer builds the pacer described by the config for a run of the given length
+func newPacer(cfg PacerConfig, duration time.Duration) (vegeta.Pacer, error) {
+	switch cfg.Type {
+	case "", "constant":
+		if cfg.Rate <= 0 {
+			return nil, fmt.Errorf("constant pacer needs a positive rate")
+		}
+		return newProfilePacer(fmt.Sprintf("constant %.0f/s", cfg.Rate),
+			segment{duration, cfg.Rate, cfg.Rate}), nil
+
+	case "ramp":
+		if cfg.Start < 0 || cfg.Peak <= 0 {
+			return nil, fmt.Errorf("ramp pacer needs start >= 0 and a positive peak")
+		}
+		up, down := time.Duration(cfg.RampUp), time.Duration(cfg.RampDown)
+		if up == 0 && down == 0 {
+			up, down = duration/4, duration/4
+		}
+		hold := duration - up - down
+		if hold < 0 {
+			return nil, fmt.Errorf("ramp_up and ramp_down (%v) exceed the duration %v", up+down, duration)
+		}
+		return newProfilePacer(fmt.Sprintf("ramp %.0f→%.0f/s up %v hold %v down %v", cfg.Start, cfg.Peak, up, hold, down),
+			segment{up, cfg.Start, cfg.Peak},
+			segment{hold, cfg.Peak, cfg.Peak},
+			segment{down, cfg.Peak, cfg.Start},
+		), nil
+
+	case "step":
+		every := time.Duration(cfg.StepEvery)
+		if cfg.Start <= 0 || cfg.Step <= 0 || every <= 0 {
+			return nil, fmt.Errorf("step pacer needs a positive start, step and step_every")
+		}
+		var segments []segment
+		rate := cfg.Start
+		for elapsed := time.Duration(0); elapsed < duration; elapsed += every {
+			length := every
+			if remaining := duration - elapsed; remaining < length {
+				length = remaining
+			}
+			segments = append(segments, segment{length, rate, rate})
+			if rate += cfg.Step; cfg.Peak > 0 && rate > cfg.Peak {
+				rate = cfg.Peak
+			}
+		}
+		return newProfilePacer(fmt.Sprintf("step %.0f/s +%.0f every %v up to %.0f/s", cfg.Start, cfg.Step, every, cfg.Peak), segments...), nil
+
+	case "spike":
+		at, length := time.Duration(cfg.SpikeAt), time.Duration(cfg.SpikeFor)
+		if cfg.Rate <= 0 || cfg.Peak <= cfg.Rate || length <= 0 {
+			return nil, fmt.Errorf("spike pacer needs a positive rate, a higher peak and a positive spike_for")
+		}
+		if at+length > duration {
+			return nil, fmt.Errorf("spike ends at %v, after the duration %v", at+length, duration)
+		}
+		return newProfilePacer(fmt.Sprintf("spike %.0f/s to %.0f/s at %v for %v", cfg.Rate, cfg.Peak, at, length),
+			segment{at, cfg.Rate, cfg.Rate},
+			segment{length, cfg.Peak, cfg.Peak},
+			segment{duration - at - length, cfg.Rate, cfg.Rate},
+		), nil
+
+	default:
+		return nil, fmt.Errorf("unknown pacer type %q (constant, ramp, step or spike)", cfg.Type)
+	}
+}
+
+func (p *profilePacer) String() string {
+	return p.description
+}
+
+// Pace waits until the profile's cumulative hit count reaches the next hit
+func (p *profilePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	if elapsed >= p.total {
+		return 0, true
+	}
+
+	expected := p.hits(elapsed)
+	if hits == 0 || float64(hits) < expected {
+		// Running behind, send the next hit immediately
+		return 0, false
+	}
+
+	next := float64(hits + 1)
+	if p.hits(p.total) < next {
+		return 0, true
+	}
+
+	// hits is non-decreasing in time, so bisect for the moment it reaches next
+	lo, hi := elapsed, p.total
+	for hi-lo > time.Microsecond {
+		mid := lo + (hi-lo)/2
+		if p.hits(mid) < next {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi - elapsed, false
+}
+
+// Rate returns the instantaneous rate in requests per second
+func (p *profilePacer) Rate(elapsed time.Duration) float64 {
+	for _, s := range p.segments {
+		if elapsed < s.duration {
+			return s.from + (s.to-s.from)*elapsed.Seconds()/s.duration.Seconds()
+		}
+		elapsed -= s.duration
+	}
+	return 0
+}
+
+// hits returns how many requests the profile has sent after t, the area
+// under the rate curve
+func (p *profilePacer) hits(t time.Duration) float64 {
+	total := 0.0
+	for _, s := range p.segments {
+		if t <= 0 {
+			break
+		}
+		span := s.duration
+		if t < span {
+			span = t
+		}
+		end := s.from + (s.to-s.from)*span.Seconds()/s.duration.Seconds()
+		total += (s.from + end) / 2 * span.Seconds()
+		t -= s.duration
+	}
+	return total
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testProfile ramps 0→10/s over 2s, holds 10/s for 1s and ramps down to 0
+// over 1s. The zero-length segment in the middle is dropped.
+func testProfile() *profilePacer {
+	return newProfilePacer("test",
+		segment{2 * time.Second, 0, 10},
+		segment{0, 50, 50},
+		segment{time.Second, 10, 10},
+		segment{time.Second, 10, 0},
+	)
+}
+
+func TestProfilePacerSegments(t *testing.T) {
+	p := testProfile()
+	if len(p.segments) != 3 || p.total != 4*time.Second {
+		t.Fatalf("got %d segments over %v, want 3 over 4s", len(p.segments), p.total)
+	}
+}
+
+func TestProfilePacerHits(t *testing.T) {
+	p := testProfile()
+	tests := []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, 0},
+		{time.Second, 2.5},
+		{2 * time.Second, 10},
+		{2500 * time.Millisecond, 15},
+		{3 * time.Second, 20},
+		{3500 * time.Millisecond, 23.75},
+		{4 * time.Second, 25},
+		{5 * time.Second, 25},
+	}
+	for _, tt := range tests {
+		if got := p.hits(tt.elapsed); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("hits(%v) = %v, want %v", tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestProfilePacerRate(t *testing.T) {
+	p := testProfile()
+	tests := []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, 0},
+		{time.Second, 5},
+		// A boundary belongs to the segment that starts there
+		{2 * time.Second, 10},
+		{3 * time.Second, 10},
+		{3500 * time.Millisecond, 5},
+		{3900 * time.Millisecond, 1},
+		// The final segment ends at the profile's end
+		{4 * time.Second, 0},
+		{5 * time.Second, 0},
+	}
+	for _, tt := range tests {
+		if got := p.Rate(tt.elapsed); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Rate(%v) = %v, want %v", tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestProfilePacerPace(t *testing.T) {
+	p := testProfile()
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		hits     uint64
+		wantWait time.Duration
+		wantStop bool
+	}{
+		{"first hit", 0, 0, 0, false},
+		{"behind", 3 * time.Second, 15, 0, false},
+		{"on schedule in the hold", 2 * time.Second, 10, 100 * time.Millisecond, false},
+		// The 21st hit falls 1 - √0.8 ≈ 0.106s into the ramp down
+		{"ahead into the next segment", 2500 * time.Millisecond, 20, 606 * time.Millisecond, false},
+		{"every hit sent", 3900 * time.Millisecond, 25, 0, true},
+		{"past the end", 4 * time.Second, 3, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, stop := p.Pace(tt.elapsed, tt.hits)
+			if stop != tt.wantStop {
+				t.Fatalf("stop = %v, want %v", stop, tt.wantStop)
+			}
+			if diff := wait - tt.wantWait; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Fatalf("wait = %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestStepPacerFinalSegment(t *testing.T) {
+	pacer, err := newPacer(PacerConfig{Type: "step", Start: 10, Step: 10, Peak: 20, StepEvery: Duration(time.Second)}, 2500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("newPacer: %v", err)
+	}
+	p := pacer.(*profilePacer)
+	if len(p.segments) != 3 || p.total != 2500*time.Millisecond {
+		t.Fatalf("got %d segments over %v, want 3 over 2.5s", len(p.segments), p.total)
+	}
+	// 10/s, then 20/s, then the capped 20/s for the last half second
+	if got := p.hits(p.total); math.Abs(got-40) > 1e-9 {
+		t.Fatalf("hits over the run = %v, want 40", got)
+	}
+	if got := p.Rate(2400 * time.Millisecond); got != 20 {
+		t.Fatalf("Rate in the final segment = %v, want 20", got)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// Report is the JSON result of a run. A saved report is also the baseline
+// format for comparison mode.
+type Report struct {
+	Scenario  Scenario           `json:"scenario"`
+	Pacer     string             `json:"pacer"`
+	StartedAt time.Time          `json:"started_at"`
+	Overall   Summary            `json:"overall"`
+	Endpoints map[string]Summary `json:"endpoints"`
+	// Verification is set when the run was verified
+	Verification *VerificationReport `json:"verification,omitempty"`
+}
+
+// Summary condenses vegeta metrics for one endpoint or the whole run
+type Summary struct {
+	Requests    uint64         `json:"requests"`
+	Rate        float64        `json:"rate"`
+	Throughput  float64        `json:"throughput"`
+	Success     float64        `json:"success"`
+	Latencies   LatencySummary `json:"latencies_ms"`
+	StatusCodes map[string]int `json:"status_codes"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+// LatencySummary holds latency percentiles in milliseconds
+type LatencySummary struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// collector accumulates results overall and per endpoint
+type collector struct {
+	overall   vegeta.Metrics
+	endpoints map[string]*vegeta.Metrics
+}
+
+func newCollector() *collector {
+	return &collector{endpoints: make(map[string]*vegeta.Metrics)}
+}
+
+func (c *collector) Add(result *vegeta.Result) {
+	c.overall.Add(result)
+
+	name := endpointFor(result.Method, result.URL)
+	metrics, exists := c.endpoints[name]
+	if !exists {
+		metrics = &vegeta.Metrics{}
+		c.endpoints[name] = metrics
+	}
+	metrics.Add(result)
+}
+
+func (c *collector) Close() {
+	c.overall.Close()
+	for _, metrics := range c.endpoints {
+		metrics.Close()
+	}
+}
+
+// report builds the JSON report once the collector is closed
+func (c *collector) report(scenario Scenario, pacer vegeta.Pacer, startedAt time.Time) Report {
+	report := Report{
+		Scenario:  scenario,
+		Pacer:     fmt.Sprint(pacer),
+		StartedAt: startedAt.UTC(),
+		Overall:   summarize(&c.overall),
+		Endpoints: make(map[string]Summary, len(c.endpoints)),
+	}
+	for name, metrics := range c.endpoints {
+		report.Endpoints[name] = summarize(metrics)
+	}
+	return report
+}
+
+func summarize(m *vegeta.Metrics) Summary {
+	return Summary{
+		Requests:   m.Requests,
+		Rate:       m.Rate,
+		Throughput: m.Throughput,
+		Success:    m.Success,
+		Latencies: LatencySummary{
+			Mean: milliseconds(m.Latencies.Mean),
+			P50:  milliseconds(m.Latencies.P50),
+			P90:  milliseconds(m.Latencies.P90),
+			P95:  milliseconds(m.Latencies.P95),
+			P99:  milliseconds(m.Latencies.P99),
+			Max:  milliseconds(m.Latencies.Max),
+		},
+		StatusCodes: m.StatusCodes,
+		Errors:      m.Errors,
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// printSummary writes a human-readable summary of the run
+func printSummary(w io.Writer, report Report) {
+	fmt.Fprintf(w, "Pacer: %s\n", report.Pacer)
+	fmt.Fprintf(w, "%-18s %9s %9s %8s %9s %9s %9s %9s\n", "endpoint", "requests", "rate/s", "success", "mean ms", "p50 ms", "p99 ms", "max ms")
+	row := func(name string, s Summary) {
+		fmt.Fprintf(w, "%-18s %9d %9.1f %7.2f%% %9.2f %9.2f %9.2f %9.2f\n",
+			name, s.Requests, s.Rate, s.Success*100, s.Latencies.Mean, s.Latencies.P50, s.Latencies.P99, s.Latencies.Max)
+	}
+	for _, name := range sortedKeys(report.Endpoints) {
+		row(name, report.Endpoints[name])
+	}
+	row("overall", report.Overall)
+
+	if len(report.Overall.Errors) > 0 {
+		fmt.Fprintln(w, "Errors:")
+		for _, err := range report.Overall.Errors {
+			fmt.Fprintf(w, "  %s\n", err)
+		}
+	}
+}
+
+// writeJSONReport saves the report for later comparison
+func writeJSONReport(path string, report Report) error {
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(contents, '\n'), 0644)
+}
+
+// readJSONReport loads a saved report
+func readJSONReport(path string) (Report, error) {
+	var report Report
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return report, err
+	}
+	if err := json.Unmarshal(contents, &report); err != nil {
+		return report, fmt.Errorf("failed to parse report %s: %v", path, err)
+	}
+	return report, nil
+}
+
+// writeHDRHistogram saves the overall latency distribution in HDR histogram
+// plot format, which plotters such as hdrhistogram.github.io/HdrHistogram/plotFiles.html read
+func writeHDRHistogram(path string, c *collector) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return vegeta.NewHDRHistogramPlotReporter(&c.overall).Report(file)
+}
+
+func sortedKeys(m map[string]Summary) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scenario describes one load test run. It can be read from a JSON file and
+// any field can be overridden on the command line.
+type Scenario struct {
+	// BaseURL is the API under test
+	BaseURL string `json:"base_url"`
+	// Duration caps the attack; shaped pacers also stop when their profile ends
+	Duration Duration `json:"duration"`
+	// Workers is the initial number of concurrent workers
+	Workers uint64 `json:"workers"`
+	// Timeout is the per-request timeout
+	Timeout Duration    `json:"timeout"`
+	Pacer   PacerConfig `json:"pacer"`
+	Auth    AuthConfig  `json:"auth"`
+	// ChainID and ConfigFile select the chain and contract registry used to
+	// build event payloads the server will accept
+	ChainID    string `json:"chain_id"`
+	ConfigFile string `json:"config_file,omitempty"`
+	// Wallets is how many distinct caller addresses payloads are spread over
+	Wallets int `json:"wallets"`
+	// Mix maps endpoint names to relative weights
+	Mix map[string]int `json:"mix"`
+	// Verify tags written events with a run ID and checks afterwards that the
+	// server stored exactly what it acknowledged
+	Verify bool `json:"verify,omitempty"`
+}
+
+// PacerConfig selects and shapes the request rate. Rates are requests per second.
+//
+//	constant: Rate for the whole run
+//	ramp:     Start to Peak over RampUp, hold at Peak, back to Start over RampDown
+//	step:     Start, rising by Step every StepEvery up to Peak
+//	spike:    Rate, jumping to Peak at SpikeAt for SpikeFor
+type PacerConfig struct {
+	Type      string   `json:"type"`
+	Rate      float64  `json:"rate,omitempty"`
+	Start     float64  `json:"start,omitempty"`
+	Peak      float64  `json:"peak,omitempty"`
+	RampUp    Duration `json:"ramp_up,omitempty"`
+	RampDown  Duration `json:"ramp_down,omitempty"`
+	Step      float64  `json:"step,omitempty"`
+	StepEvery Duration `json:"step_every,omitempty"`
+	SpikeAt   Duration `json:"spike_at,omitempty"`
+	SpikeFor  Duration `json:"spike_for,omitempty"`
+}
+
+// AuthConfig selects how requests are authenticated. The key is read from
+// the named environment variable so scenario files hold no secrets.
+type AuthConfig struct {
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// Signed sends HMAC-signed requests instead of the plain key; the key
+	// must then be an issued "<id>.<secret>" key
+	Signed bool `json:"signed,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "90s" in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("durations must be strings such as \"30s\": %v", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// defaultScenario is a steady mixed read and write load
+func defaultScenario() Scenario {
+	return Scenario{
+		BaseURL:  "http://localhost:8080",
+		Duration: Duration(time.Minute),
+		Workers:  100,
+		Timeout:  Duration(30 * time.Second),
+		Pacer:    PacerConfig{Type: "constant", Rate: 100},
+		Auth:     AuthConfig{APIKeyEnv: "INGEST_API_KEY"},
+		ChainID:  "80002",
+		Wallets:  500,
+		Mix: map[string]int{
+			"mint":        35,
+			"burn":        35,
+			"list-events": 15,
+			"event-stats": 5,
+			"last-event":  10,
+		},
+	}
+}
+
+// loadScenario reads a scenario file on top of the defaults
+func loadScenario(path string) (Scenario, error) {
+	scenario := defaultScenario()
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return scenario, err
+	}
+
+	// A file that sets a mix replaces the default mix rather than merging with it
+	scenario.Mix = nil
+	if err := json.Unmarshal(contents, &scenario); err != nil {
+		return scenario, fmt.Errorf("failed to parse scenario %s: %v", path, err)
+	}
+	if scenario.Mix == nil {
+		scenario.Mix = defaultScenario().Mix
+	}
+	return scenario, nil
+}
+
+// validate checks the scenario before any request is sent
+func (s Scenario) validate() error {
+	if s.BaseURL == "" {
+		return fmt.Errorf("base_url is required")
+	}
+	if s.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if s.Workers == 0 {
+		return fmt.Errorf("workers must be positive")
+	}
+	if s.Wallets <= 0 {
+		return fmt.Errorf("wallets must be positive")
+	}
+	if len(s.Mix) == 0 {
+		return fmt.Errorf("mix must name at least one endpoint")
+	}
+	for name, weight := range s.Mix {
+		if _, exists := endpointCatalogue[name]; !exists {
+			return fmt.Errorf("unknown endpoint %q in mix (known: %s)", name, strings.Join(endpointNames(), ", "))
+		}
+		if weight < 0 {
+			return fmt.Errorf("weight for %s must not be negative", name)
+		}
+	}
+	_, err := newPacer(s.Pacer, time.Duration(s.Duration))
+	return err
+}
+
+// parseMix reads a mix written as "mint=40,burn=40,list-events=20"
+func parseMix(value string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return nil, fmt.Errorf("mix entry %q must be name=weight", part)
+		}
+		parsed, err := strconv.Atoi(weight)
+		if err != nil {
+			return nil, fmt.Errorf("mix weight for %s: %v", name, err)
+		}
+		mix[name] = parsed
+	}
+	return mix, nil
+}
+
+// formatMix writes a mix in the same form parseMix reads, sorted by name
+func formatMix(mix map[string]int) string {
+	names := make([]string, 0, len(mix))
+	for name := range mix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, mix[name]))
+	}
+	return strings.Join(parts, ",")
+}
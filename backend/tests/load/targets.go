@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/services"
+
+	"github.com/ethereum/go-ethereum/common"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// otherChainSelector is the CCIP selector used as the remote side of
+// generated message events (Ethereum Sepolia)
+const otherChainSelector = uint64(16015286601757825753)
+
+// endpoint is an API route the load test can hit
+type endpoint struct {
+	method string
+	// path is the route with :params, as registered in routes.SetupRouter
+	path string
+	// eventName is set for ingestion routes
+	eventName string
+}
+
+// endpointCatalogue names every route a scenario mix can use
+var endpointCatalogue = map[string]endpoint{
+	"mint":             {method: http.MethodPost, path: "/api/events/mint", eventName: "Mint"},
+	"burn":             {method: http.MethodPost, path: "/api/events/burn", eventName: "Burn"},
+	"tokens-locked":    {method: http.MethodPost, path: "/api/events/tokens-locked", eventName: "TokensLocked"},
+	"tokens-released":  {method: http.MethodPost, path: "/api/events/tokens-released", eventName: "TokensReleased"},
+	"message-sent":     {method: http.MethodPost, path: "/api/events/message-sent", eventName: "MessageSent"},
+	"message-received": {method: http.MethodPost, path: "/api/events/message-received", eventName: "MessageReceived"},
+	"list-events":      {method: http.MethodGet, path: "/api/events"},
+	"event-stats":      {method: http.MethodGet, path: "/api/events/stats"},
+	"last-event":       {method: http.MethodGet, path: "/api/events/:callerAddress/last"},
+	"contract":         {method: http.MethodGet, path: "/api/contract/:chainID/:index"},
+	"metrics":          {method: http.MethodGet, path: "/api/metrics"},
+}
+
+func endpointNames() []string {
+	names := make([]string, 0, len(endpointCatalogue))
+	for name := range endpointCatalogue {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// endpointFor finds the catalogue entry a request was sent to, so results can
+// be reported per endpoint
+func endpointFor(method, rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "unknown"
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+
+	// Prefer routes without params so /api/events/stats is not taken for a caller address
+	best, bestParams := "unknown", -1
+	for name, e := range endpointCatalogue {
+		if e.method != method {
+			continue
+		}
+		pattern := strings.Split(strings.Trim(e.path, "/"), "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+		params, matched := 0, true
+		for i, part := range pattern {
+			if strings.HasPrefix(part, ":") {
+				params++
+			} else if part != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched && (bestParams < 0 || params < bestParams) {
+			best, bestParams = name, params
+		}
+	}
+	return best
+}
+
+// targetGenerator produces requests for a weighted mix of endpoints. Write
+// payloads follow models.EventData and pass the server's validation.
+type targetGenerator struct {
+	baseURL    string
+	chainID    string
+	contracts  map[string]common.Address
+	credential string
+	signed     bool
+	// verifier, when set, tags write payloads with the run ID and records them
+	verifier *verifier
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	wallets []common.Address
+	names   []string
+	weights []int
+	total   int
+	block   uint64
+}
+
+func newTargetGenerator(scenario Scenario, contracts map[string]common.Address, credential string) *targetGenerator {
+	g := &targetGenerator{
+		baseURL:    strings.TrimSuffix(scenario.BaseURL, "/"),
+		chainID:    scenario.ChainID,
+		contracts:  contracts,
+		credential: credential,
+		signed:     scenario.Auth.Signed,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		block:      1,
+	}
+	for i := 0; i < scenario.Wallets; i++ {
+		g.wallets = append(g.wallets, g.address())
+	}
+	for _, name := range endpointNames() {
+		if weight := scenario.Mix[name]; weight > 0 {
+			g.names = append(g.names, name)
+			g.weights = append(g.weights, weight)
+			g.total += weight
+		}
+	}
+	return g
+}
+
+// Targeter returns a vegeta targeter drawing endpoints by weight
+func (g *targetGenerator) Targeter() vegeta.Targeter {
+	return func(target *vegeta.Target) error {
+		if target == nil {
+			return vegeta.ErrNilTarget
+		}
+		return g.next(target)
+	}
+}
+
+func (g *targetGenerator) next(target *vegeta.Target) error {
+	g.mu.Lock()
+	name := g.pick()
+	e := endpointCatalogue[name]
+	path, body, err := g.request(e)
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	*target = vegeta.Target{Method: e.method, URL: g.baseURL + path, Body: body, Header: http.Header{}}
+	if body != nil {
+		target.Header.Set("Content-Type", "application/json")
+	}
+	return g.authenticate(target)
+}
+
+// pick draws an endpoint name by weight; callers hold g.mu
+func (g *targetGenerator) pick() string {
+	n := g.rng.Intn(g.total)
+	for i, weight := range g.weights {
+		if n < weight {
+			return g.names[i]
+		}
+		n -= weight
+	}
+	return g.names[len(g.names)-1]
+}
+
+// request fills in the path params and body for an endpoint; callers hold g.mu
+func (g *targetGenerator) request(e endpoint) (string, []byte, error) {
+	switch e.path {
+	case "/api/events":
+		query := url.Values{"chain_id": {g.chainID}, "limit": {"50"}}
+		if g.rng.Intn(2) == 0 {
+			query.Set("event_name", []string{"Mint", "Burn"}[g.rng.Intn(2)])
+		}
+		return e.path + "?" + query.Encode(), nil, nil
+	case "/api/events/stats":
+		return e.path + "?chain_id=" + url.QueryEscape(g.chainID), nil, nil
+	case "/api/events/:callerAddress/last":
+		return "/api/events/" + g.wallet().Hex() + "/last", nil, nil
+	case "/api/contract/:chainID/:index":
+		return "/api/contract/" + g.chainID + "/" + []string{"Token", "Vault", "Router"}[g.rng.Intn(3)], nil, nil
+	}
+
+	if e.eventName == "" {
+		return e.path, nil, nil
+	}
+	event, err := g.event(e.eventName)
+	if err != nil {
+		return "", nil, err
+	}
+	body, err := json.Marshal(event)
+	return e.path, body, err
+}
+
+// event builds a payload the monitor could have sent for the event; callers hold g.mu
+func (g *targetGenerator) event(eventName string) (models.EventData, error) {
+	contractType, _ := services.ContractTypeForEvent(eventName)
+	contract, exists := g.contracts[contractType]
+	if !exists {
+		return models.EventData{}, fmt.Errorf("no %s contract configured for chain %s", contractType, g.chainID)
+	}
+
+	g.block += uint64(g.rng.Intn(3))
+	txHash := g.hash()
+	caller := g.wallet()
+	event := models.EventData{
+		ID:              fmt.Sprintf("%x", txHash),
+		ChainID:         g.chainID,
+		ContractAddress: contract.Hex(),
+		EventName:       eventName,
+		CallerAddress:   caller.Hex(),
+		BlockNumber:     g.block,
+		TransactionHash: txHash.Hex(),
+		Timestamp:       models.FormatTime(time.Now()),
+		Amount:          g.amount(),
+	}
+
+	switch eventName {
+	case "Mint":
+		event.ToFromUser = caller.Hex()
+	case "MessageSent":
+		event.MessageID = g.hash().Hex()
+		event.DestinationChainSelector = otherChainSelector
+		event.Receiver = g.address().Hex()
+		event.Text = "load test"
+		event.Client = caller.Hex()
+		event.FeeToken = g.address().Hex()
+		event.Fees = new(big.Int).Mul(big.NewInt(int64(1+g.rng.Intn(100))), big.NewInt(1e15)).String()
+	case "MessageReceived":
+		event.MessageID = g.hash().Hex()
+		event.SourceChainSelector = otherChainSelector
+		event.Sender = g.address().Hex()
+		event.Text = "load test"
+		event.Client = caller.Hex()
+	}
+
+	if g.verifier != nil {
+		g.verifier.tag(&event)
+	}
+	return event, nil
+}
+
+// authenticate adds the API key, or signs the request with it
+func (g *targetGenerator) authenticate(target *vegeta.Target) error {
+	if g.credential == "" {
+		return nil
+	}
+	if !g.signed {
+		target.Header.Set(services.HeaderAPIKey, g.credential)
+		return nil
+	}
+
+	req, err := http.NewRequest(target.Method, target.URL, bytes.NewReader(target.Body))
+	if err != nil {
+		return err
+	}
+	if err := services.SignRequest(req, g.credential, target.Body); err != nil {
+		return err
+	}
+	for _, header := range []string{services.HeaderKeyID, services.HeaderTimestamp, services.HeaderNonce, services.HeaderSignature} {
+		target.Header.Set(header, req.Header.Get(header))
+	}
+	return nil
+}
+
+func (g *targetGenerator) wallet() common.Address {
+	return g.wallets[g.rng.Intn(len(g.wallets))]
+}
+
+func (g *targetGenerator) address() common.Address {
+	var address common.Address
+	g.rng.Read(address[:])
+	return address
+}
+
+func (g *targetGenerator) hash() common.Hash {
+	var hash common.Hash
+	g.rng.Read(hash[:])
+	return hash
+}
+
+// amount returns up to 1000 tokens with 18 decimals
+func (g *targetGenerator) amount() string {
+	wei := new(big.Int).Mul(big.NewInt(1+g.rng.Int63n(1000)), big.NewInt(1e18))
+	return wei.Add(wei, big.NewInt(g.rng.Int63n(1e18))).String()
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/services"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// maxListedProblems caps how many individual records a verification report names
+const maxListedProblems = 50
+
+// VerificationReport compares what the server acknowledged with what it stored
+type VerificationReport struct {
+	RunID        string `json:"run_id"`
+	Sent         int    `json:"sent"`
+	Acknowledged int    `json:"acknowledged"`
+	Stored       int    `json:"stored"`
+	// Missing were acknowledged but not stored
+	Missing int `json:"missing"`
+	// Duplicated were stored more than once
+	Duplicated int `json:"duplicated"`
+	// Corrupted were stored with fields that differ from what was sent
+	Corrupted int `json:"corrupted"`
+	// Unacknowledged were stored although the request failed or timed out
+	Unacknowledged int      `json:"unacknowledged"`
+	Problems       []string `json:"problems,omitempty"`
+	Passed         bool     `json:"passed"`
+}
+
+// verifier tags every generated event with the run ID, remembers what was
+// sent and which requests the server acknowledged, and checks the store
+// against both once the run is over. Generated transaction hashes are unique,
+// so they key the records.
+type verifier struct {
+	runID string
+
+	mu           sync.Mutex
+	sent         map[string]models.EventData
+	acknowledged map[string]bool
+}
+
+func newVerifier(runID string) *verifier {
+	return &verifier{
+		runID:        runID,
+		sent:         make(map[string]models.EventData),
+		acknowledged: make(map[string]bool),
+	}
+}
+
+// newRunID returns a run ID that sorts by start time
+func newRunID() string {
+	return fmt.Sprintf("load-%s-%04x", time.Now().UTC().Format("20060102T150405"), rand.Intn(0x10000))
+}
+
+// tag marks an event as part of this run and records it as sent
+func (v *verifier) tag(event *models.EventData) {
+	event.RunID = v.runID
+
+	v.mu.Lock()
+	v.sent[event.TransactionHash] = *event
+	v.mu.Unlock()
+}
+
+// observe records the events the server acknowledged storing
+func (v *verifier) observe(result *vegeta.Result) {
+	if result.Method != http.MethodPost || result.Code != http.StatusOK {
+		return
+	}
+
+	var response struct {
+		Data models.EventData `json:"data"`
+	}
+	if err := json.Unmarshal(result.Body, &response); err != nil || response.Data.RunID != v.runID {
+		return
+	}
+
+	v.mu.Lock()
+	v.acknowledged[response.Data.TransactionHash] = true
+	v.mu.Unlock()
+}
+
+// verify reads back every event stored under the run ID and reports missing,
+// duplicated, corrupted and unacknowledged records
+func (v *verifier) verify(baseURL, credential string) (VerificationReport, error) {
+	stored, err := fetchRunEvents(baseURL, credential, v.runID)
+	if err != nil {
+		return VerificationReport{}, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	report := VerificationReport{
+		RunID:        v.runID,
+		Sent:         len(v.sent),
+		Acknowledged: len(v.acknowledged),
+		Stored:       len(stored),
+	}
+	problem := func(format string, args ...interface{}) {
+		if len(report.Problems) < maxListedProblems {
+			report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	byHash := make(map[string][]models.EventData)
+	for _, event := range stored {
+		byHash[event.TransactionHash] = append(byHash[event.TransactionHash], event)
+	}
+
+	for _, txHash := range sortedHashes(v.acknowledged) {
+		if len(byHash[txHash]) == 0 {
+			report.Missing++
+			problem("missing: %s %s was acknowledged but not stored", v.sent[txHash].EventName, txHash)
+		}
+	}
+
+	for _, txHash := range sortedHashes(byHash) {
+		copies := byHash[txHash]
+		if len(copies) > 1 {
+			report.Duplicated++
+			problem("duplicated: %s %s stored %d times", copies[0].EventName, txHash, len(copies))
+		}
+
+		sent, wasSent := v.sent[txHash]
+		if !wasSent {
+			report.Corrupted++
+			problem("corrupted: %s %s was stored under this run but never sent", copies[0].EventName, txHash)
+			continue
+		}
+		if !v.acknowledged[txHash] {
+			report.Unacknowledged++
+			problem("unacknowledged: %s %s was stored although its request failed", sent.EventName, txHash)
+		}
+		for _, record := range copies {
+			if fields := differingFields(sent, record); len(fields) > 0 {
+				report.Corrupted++
+				problem("corrupted: %s %s differs in %s", sent.EventName, txHash, strings.Join(fields, ", "))
+				break
+			}
+		}
+	}
+
+	report.Passed = report.Missing == 0 && report.Duplicated == 0 && report.Corrupted == 0
+	return report, nil
+}
+
+// fetchRunEvents pages through the query API for every event of a run
+func fetchRunEvents(baseURL, credential, runID string) ([]models.EventData, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var events []models.EventData
+	for offset := 0; ; {
+		query := url.Values{
+			"run_id": {runID},
+			"limit":  {strconv.Itoa(1000)},
+			"offset": {strconv.Itoa(offset)},
+		}
+
+		var page struct {
+			Data []models.EventData `json:"data"`
+		}
+		if err := getJSON(client, baseURL+"/api/events?"+query.Encode(), credential, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page.Data...)
+		if len(page.Data) == 0 {
+			return events, nil
+		}
+		offset += len(page.Data)
+	}
+}
+
+// getJSON fetches a URL, waiting out rate limiting
+func getJSON(client *http.Client, rawURL, credential string, target interface{}) error {
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			return err
+		}
+		if credential != "" {
+			req.Header.Set(services.HeaderAPIKey, credential)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < 10:
+			wait, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			time.Sleep(time.Duration(wait+1) * time.Second)
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return fmt.Errorf("GET %s returned %d: %s", rawURL, resp.StatusCode, body)
+		}
+		return json.Unmarshal(body, target)
+	}
+}
+
+// differingFields lists the JSON fields of a stored event that differ from
+// what was sent. The server sets created_at and updated_at itself, and adds
+// the formatted amounts to its responses.
+func differingFields(sent, stored models.EventData) []string {
+	stored.CreatedAt, stored.UpdatedAt = sent.CreatedAt, sent.UpdatedAt
+	stored.AmountFormatted, stored.FeesFormatted = "", ""
+
+	want, got := fieldMap(sent), fieldMap(stored)
+
+	var fields []string
+	for field := range union(want, got) {
+		if fmt.Sprint(want[field]) != fmt.Sprint(got[field]) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// fieldMap flattens an event to its JSON fields, keeping numbers exact
+func fieldMap(event models.EventData) map[string]interface{} {
+	encoded, _ := json.Marshal(event)
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	fields := make(map[string]interface{})
+	decoder.Decode(&fields)
+	return fields
+}
+
+func union(a, b map[string]interface{}) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
+
+func sortedHashes[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printVerification writes a human-readable verification summary
+func printVerification(w io.Writer, report VerificationReport) {
+	fmt.Fprintf(w, "Run %s: sent %d, acknowledged %d, stored %d\n", report.RunID, report.Sent, report.Acknowledged, report.Stored)
+	fmt.Fprintf(w, "Missing %d, duplicated %d, corrupted %d, unacknowledged %d\n",
+		report.Missing, report.Duplicated, report.Corrupted, report.Unacknowledged)
+	for _, problem := range report.Problems {
+		fmt.Fprintf(w, "  %s\n", problem)
+	}
+	if report.Passed {
+		fmt.Fprintln(w, "Verification passed")
+	} else {
+		fmt.Fprintln(w, "Verification FAILED")
+	}
+}
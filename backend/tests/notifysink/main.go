@@ -0,0 +1,120 @@
+package main
+
+// Local stand-in for the alert notifiers.
+//
+// It accepts webhook and Slack posts over HTTP and mail over a minimal SMTP
+// server, and prints everything it receives, so alert rules can be tried
+// without real endpoints. Point the notifiers in config.json at it:
+//
+//	{"name": "hook", "type": "webhook", "url": "http://localhost:9099/webhook"}
+//	{"name": "slack", "type": "slack", "url": "http://localhost:9099/slack"}
+//	{"name": "mail", "type": "smtp", "smtp_host": "localhost", "smtp_port": 2525, "from": "bridge@localhost", "to": ["ops@localhost"]}
+//
+//	cd backend && go run ./tests/notifysink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+func main() {
+	httpAddr := flag.String("http", ":9099", "address for webhook and Slack posts")
+	smtpAddr := flag.String("smtp", ":2525", "address for SMTP")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *smtpAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen for SMTP: %v", err)
+	}
+	go serveSMTP(listener)
+	log.Printf("SMTP sink listening on %s", *smtpAddr)
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") != nil {
+			pretty.Reset()
+			pretty.Write(body)
+		}
+		log.Printf("HTTP %s %s\n%s", r.Method, r.URL.Path, pretty.String())
+		w.WriteHeader(http.StatusOK)
+	})
+	log.Printf("HTTP sink listening on %s", *httpAddr)
+	log.Fatal(http.ListenAndServe(*httpAddr, nil))
+}
+
+func serveSMTP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("SMTP accept failed: %v", err)
+			return
+		}
+		go handleSMTP(conn)
+	}
+}
+
+// handleSMTP speaks just enough SMTP for net/smtp.SendMail without TLS or AUTH
+func handleSMTP(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 notifysink ready")
+	var from string
+	var to []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 notifysink")
+		case "MAIL":
+			from = command
+			reply("250 OK")
+		case "RCPT":
+			to = append(to, command)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var message strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" || dataLine == ".\n" {
+					break
+				}
+				message.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			log.Printf("SMTP %s %s\n%s", from, strings.Join(to, " "), message.String())
+			from, to = "", nil
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
@@ -0,0 +1,547 @@
+//go:build simulated
+
+// Package simulated checks the event monitor pipeline end to end on a simulated chain.
+//
+// The Token, Vault and Messenger contracts are deployed from the bytecode
+// fixtures in testdata onto go-ethereum's simulated backend, real transactions
+// are sent to them, and the decoded events are pushed through the monitor and
+// the ingestion API into an in-memory store. Nothing touches the network.
+//
+//	cd backend && go test -tags simulated ./tests/simulated
+//
+// After changing the contracts, compile them and refresh the fixtures:
+//
+//	cd smart-contracts && npx hardhat compile
+//	cd backend && go test -tags simulated ./tests/simulated -update
+package simulated
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/config"
+	"backend/database"
+	"backend/models"
+	"backend/routes"
+	"backend/services"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	chainID     = "1337"
+	ingestKey   = "simulated-ingest-key"
+	sourceChain = uint64(16015286601757825753)
+)
+
+var (
+	update       = flag.Bool("update", false, "Rewrite the bytecode fixtures in testdata from the Hardhat artifacts")
+	artifactsDir = flag.String("artifacts", filepath.Join("..", "..", "..", "smart-contracts", "artifacts", "contracts"), "Hardhat artifacts directory read by -update")
+	timeout      = flag.Duration("event-wait", 30*time.Second, "How long to wait for the pipeline to store every expected event")
+)
+
+// contractNames are the contracts deployed by the test, named as their sources
+var contractNames = []string{"CCIP_Token", "CCIP_TokenVault", "CrossChain_Messanger"}
+
+// artifact is the subset of a Hardhat build artifact needed to deploy a
+// contract, and the format of the fixtures in testdata
+type artifact struct {
+	ABI      abi.ABI `json:"abi"`
+	Bytecode string  `json:"bytecode"`
+}
+
+// account is a funded key on the simulated chain
+type account struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// expectation describes an event the pipeline must have stored
+type expectation struct {
+	EventName       string
+	ContractAddress common.Address
+	CallerAddress   common.Address
+	ToFromUser      string
+	Amount          string
+	TransactionHash common.Hash
+	MessageID       string
+	SourceSelector  uint64
+	Sender          string
+	Client          string
+	Text            string
+}
+
+func TestEventPipeline(t *testing.T) {
+	if *update {
+		for _, name := range contractNames {
+			if err := updateFixture(*artifactsDir, name); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	log.SetFlags(0)
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	if err := run(t, *timeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func run(t *testing.T, timeout time.Duration) error {
+	token, err := loadFixture("CCIP_Token")
+	if err != nil {
+		return err
+	}
+	vault, err := loadFixture("CCIP_TokenVault")
+	if err != nil {
+		return err
+	}
+	messenger, err := loadFixture("CrossChain_Messanger")
+	if err != nil {
+		return err
+	}
+
+	deployer, user, client, sender := newAccount(), newAccount(), newAccount(), newAccount()
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		deployer.address: {Balance: funds},
+		user.address:     {Balance: funds},
+	})
+	defer backend.Close()
+	chain := backend.Client()
+	// Gas is estimated against the latest block and the genesis block predates
+	// Shanghai, so mine one block before deploying code that uses PUSH0
+	backend.Commit()
+
+	// The deployer doubles as the CCIP router so it may call ccipReceive directly
+	tokenAddress, err := deploy(backend, deployer, token, "CCIP Token", "CCT", big.NewInt(1_000_000))
+	if err != nil {
+		return fmt.Errorf("deploying token: %v", err)
+	}
+	vaultAddress, err := deploy(backend, deployer, vault, tokenAddress)
+	if err != nil {
+		return fmt.Errorf("deploying vault: %v", err)
+	}
+	messengerAddress, err := deploy(backend, deployer, messenger, deployer.address, common.Address{0x01}, vaultAddress)
+	if err != nil {
+		return fmt.Errorf("deploying messenger: %v", err)
+	}
+	if _, err := transact(backend, deployer, token, tokenAddress, "setVaultAddress", vaultAddress); err != nil {
+		return err
+	}
+
+	// Point the backend at the simulated deployment and serve the real API from memory
+	api, err := startAPI(tokenAddress, vaultAddress, messengerAddress)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watched := map[string]common.Address{"Token": tokenAddress, "Vault": vaultAddress, "Router": messengerAddress}
+	for contractType, address := range watched {
+		go func(contractType string, address common.Address) {
+			if err := services.WatchContractEvents(ctx, chain, address, config.DefaultTenant, chainID, contractType); err != nil && ctx.Err() == nil {
+				t.Errorf("monitor for %s stopped: %v", contractType, err)
+			}
+		}(contractType, address)
+	}
+	if err := waitForMonitors(len(watched)); err != nil {
+		return err
+	}
+
+	var expected []expectation
+
+	// mint → Mint(to, amount)
+	tx, err := transact(backend, deployer, token, tokenAddress, "mint", user.address, big.NewInt(1000))
+	if err != nil {
+		return err
+	}
+	expected = append(expected, expectation{EventName: "Mint", ContractAddress: tokenAddress, CallerAddress: user.address,
+		ToFromUser: user.address.Hex(), Amount: "1000", TransactionHash: tx})
+
+	// burn → Burn(from, amount)
+	tx, err = transact(backend, deployer, token, tokenAddress, "burn", user.address, big.NewInt(100))
+	if err != nil {
+		return err
+	}
+	expected = append(expected, expectation{EventName: "Burn", ContractAddress: tokenAddress, CallerAddress: user.address,
+		Amount: "100", TransactionHash: tx})
+
+	// transferToCCIPVault then lockTokenInVault → Burn(vault, amount) and TokensLocked(user, amount)
+	if _, err := transact(backend, user, token, tokenAddress, "transferToCCIPVault", big.NewInt(200)); err != nil {
+		return err
+	}
+	tx, err = transact(backend, user, vault, vaultAddress, "lockTokenInVault", user.address, big.NewInt(200))
+	if err != nil {
+		return err
+	}
+	expected = append(expected,
+		expectation{EventName: "Burn", ContractAddress: tokenAddress, CallerAddress: vaultAddress, Amount: "200", TransactionHash: tx},
+		expectation{EventName: "TokensLocked", ContractAddress: vaultAddress, CallerAddress: user.address, Amount: "200", TransactionHash: tx},
+	)
+
+	// ccipReceive from the router → Mint(client, amount) via the vault and MessageReceived
+	for _, call := range []struct {
+		method string
+		args   []interface{}
+	}{
+		{"allowlistSourceChain", []interface{}{sourceChain, true}},
+		{"allowlistSender", []interface{}{sender.address, true}},
+	} {
+		if _, err := transact(backend, deployer, messenger, messengerAddress, call.method, call.args...); err != nil {
+			return err
+		}
+	}
+	messageID, data, err := ccipPayload("bridged", big.NewInt(50), client.address)
+	if err != nil {
+		return err
+	}
+	message := ccipMessage{
+		MessageId:           messageID,
+		SourceChainSelector: sourceChain,
+		Sender:              common.LeftPadBytes(sender.address.Bytes(), 32),
+		Data:                data,
+		DestTokenAmounts:    []ccipTokenAmount{},
+	}
+	tx, err = transact(backend, deployer, messenger, messengerAddress, "ccipReceive", message)
+	if err != nil {
+		return err
+	}
+	expected = append(expected,
+		expectation{EventName: "Mint", ContractAddress: tokenAddress, CallerAddress: client.address,
+			ToFromUser: client.address.Hex(), Amount: "50", TransactionHash: tx},
+		expectation{EventName: "MessageReceived", ContractAddress: messengerAddress, CallerAddress: client.address,
+			Amount: "50", TransactionHash: tx, MessageID: common.Hash(messageID).Hex(), SourceSelector: sourceChain,
+			Sender: sender.address.Hex(), Client: client.address.Hex(), Text: "bridged"},
+	)
+
+	stored, err := waitForEvents(api.events, len(expected), timeout)
+	if err != nil {
+		return err
+	}
+	return compare(t, expected, stored)
+}
+
+// ccipMessage mirrors Client.Any2EVMMessage for ABI encoding
+type ccipMessage struct {
+	MessageId           [32]byte
+	SourceChainSelector uint64
+	Sender              []byte
+	Data                []byte
+	DestTokenAmounts    []ccipTokenAmount
+}
+
+type ccipTokenAmount struct {
+	Token  common.Address
+	Amount *big.Int
+}
+
+// ccipPayload encodes (text, amount, client) the way the Messenger's _buildCCIPMessage does
+func ccipPayload(text string, amount *big.Int, client common.Address) ([32]byte, []byte, error) {
+	stringType, _ := abi.NewType("string", "", nil)
+	uintType, _ := abi.NewType("uint256", "", nil)
+	addressType, _ := abi.NewType("address", "", nil)
+
+	data, err := abi.Arguments{{Type: stringType}, {Type: uintType}, {Type: addressType}}.Pack(text, amount, client)
+	if err != nil {
+		return [32]byte{}, nil, fmt.Errorf("encoding CCIP payload: %v", err)
+	}
+	return crypto.Keccak256Hash(data), data, nil
+}
+
+type apiServer struct {
+	*httptest.Server
+	events *database.MemoryEventStore
+}
+
+// startAPI writes a config for the simulated chain and serves the full router from memory
+func startAPI(token, vault, messenger common.Address) (*apiServer, error) {
+	events := database.NewMemoryEventStore()
+	server := &apiServer{events: events}
+	server.Server = httptest.NewUnstartedServer(nil)
+
+	configFile, err := os.CreateTemp("", "simulated-config-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer configFile.Close()
+
+	contents := map[string]interface{}{
+		"chains": map[string]interface{}{
+			chainID: map[string]string{
+				"chain_id":                 chainID,
+				"rpc_url_env_var":          "SIMULATED_RPC_URL",
+				"websocket_url_env_var":    "SIMULATED_WS_URL",
+				"token_contract_addr_env":  "SIMULATED_TOKEN_ADDRESS",
+				"vault_contract_addr_env":  "SIMULATED_VAULT_ADDRESS",
+				"router_contract_addr_env": "SIMULATED_ROUTER_ADDRESS",
+			},
+		},
+		"auth":          map[string]string{"admin_key_env_var": "SIMULATED_ADMIN_KEY", "ingest_key_env_var": "SIMULATED_INGEST_KEY"},
+		"rate_limits":   map[string]bool{"disabled": true},
+		"event_api_url": "http://" + server.Listener.Addr().String(),
+	}
+	if err := json.NewEncoder(configFile).Encode(contents); err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{
+		"CONFIG_FILE_PATH":         configFile.Name(),
+		"SIMULATED_TOKEN_ADDRESS":  token.Hex(),
+		"SIMULATED_VAULT_ADDRESS":  vault.Hex(),
+		"SIMULATED_ROUTER_ADDRESS": messenger.Hex(),
+		"SIMULATED_ADMIN_KEY":      ingestKey,
+		"SIMULATED_INGEST_KEY":     ingestKey,
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+	if err := config.Init(); err != nil {
+		return nil, err
+	}
+
+	auth := services.NewAuthService(database.NewMemoryAPIKeyStore(), database.NewMemoryNonceStore())
+	clients := services.NewChainClients()
+	dispatcher, err := services.NewAlertDispatcher(nil)
+	if err != nil {
+		return nil, err
+	}
+	server.Config.Handler = routes.SetupRouter(routes.Dependencies{
+		Events:         events,
+		Health:         &services.HealthService{Ping: events.Ping, Monitors: services.MonitorStatuses, Config: config.GetHealthConfig()},
+		Auth:           auth,
+		RateLimiter:    services.NewRateLimiter(config.GetRateLimitConfig()),
+		Transactions:   services.NewTxEngines(database.NewMemoryTxStore()),
+		Quotes:         services.NewQuoteService(clients),
+		Calls:          services.NewContractCaller(clients),
+		ContractState:  services.NewContractStateService(clients),
+		Audit:          services.NewAuditLog(database.NewMemoryAuditStore()),
+		Replayer:       &services.EventReplayer{Clients: clients, Events: events},
+		AllowlistDrift: services.NewAllowlistDriftDetector(clients),
+		StuckTransfers: services.NewStuckTransferDetector(events, database.NewMemoryStuckAlertStore(), clients, dispatcher),
+		Alerts:         services.NewAlertEngine(database.NewMemoryAlertRuleStore(), dispatcher),
+		Reports:        services.NewReportGenerator(database.NewMemoryReportStore(events)),
+		TokenDecimals:  services.NewTokenDecimals(clients),
+		Archiver:       services.NewEventArchiver(events, database.NewMemoryArchiveStore(), &services.LocalArchiveStorage{Directory: filepath.Join(os.TempDir(), "simulated-archive")}),
+	})
+	server.Start()
+	return server, nil
+}
+
+// loadFixture reads a contract's ABI and bytecode from testdata
+func loadFixture(name string) (*artifact, error) {
+	path := filepath.Join("testdata", name+".json")
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fixture %s (compile the contracts and run with -update to create it): %v", path, err)
+	}
+	return parseArtifact(path, contents)
+}
+
+// updateFixture copies the ABI and bytecode of a Hardhat artifact to testdata
+func updateFixture(dir, name string) error {
+	path := filepath.Join(dir, name+".sol", name+".json")
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading artifact %s (run `npx hardhat compile` in smart-contracts first): %v", path, err)
+	}
+	var fixture struct {
+		ABI      json.RawMessage `json:"abi"`
+		Bytecode string          `json:"bytecode"`
+	}
+	if err := json.Unmarshal(contents, &fixture); err != nil {
+		return fmt.Errorf("parsing artifact %s: %v", path, err)
+	}
+	if _, err := parseArtifact(path, contents); err != nil {
+		return err
+	}
+	encoded, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll("testdata", 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join("testdata", name+".json"), append(encoded, '\n'), 0o644)
+}
+
+func parseArtifact(path string, contents []byte) (*artifact, error) {
+	var parsed artifact
+	if err := json.Unmarshal(contents, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	if len(parsed.Bytecode) <= 2 {
+		return nil, fmt.Errorf("%s has no bytecode", path)
+	}
+	return &parsed, nil
+}
+
+func newAccount() account {
+	key, _ := crypto.GenerateKey()
+	return account{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func transactor(from account) *bind.TransactOpts {
+	opts, _ := bind.NewKeyedTransactorWithChainID(from.key, big.NewInt(1337))
+	return opts
+}
+
+func deploy(backend *simulated.Backend, from account, contract *artifact, args ...interface{}) (common.Address, error) {
+	address, tx, _, err := bind.DeployContract(transactor(from), contract.ABI, common.FromHex(contract.Bytecode), backend.Client(), args...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	backend.Commit()
+	return address, checkReceipt(backend, tx)
+}
+
+// transact sends a transaction, mines it and returns its hash
+func transact(backend *simulated.Backend, from account, contract *artifact, address common.Address, method string, args ...interface{}) (common.Hash, error) {
+	bound := bind.NewBoundContract(address, contract.ABI, backend.Client(), backend.Client(), backend.Client())
+	tx, err := bound.Transact(transactor(from), method, args...)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("%s: %v", method, err)
+	}
+	backend.Commit()
+	if err := checkReceipt(backend, tx); err != nil {
+		return common.Hash{}, fmt.Errorf("%s: %v", method, err)
+	}
+	return tx.Hash(), nil
+}
+
+func checkReceipt(backend *simulated.Backend, tx *types.Transaction) error {
+	receipt, err := backend.Client().TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		return err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	}
+	return nil
+}
+
+func waitForMonitors(count int) error {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		running := 0
+		for _, status := range services.MonitorStatuses() {
+			if status.State == services.MonitorRunning {
+				running++
+			}
+		}
+		if running == count {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return fmt.Errorf("monitors did not start: %+v", services.MonitorStatuses())
+}
+
+func waitForEvents(store *database.MemoryEventStore, count int, timeout time.Duration) ([]models.EventData, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		events, err := store.FindEvents(context.Background(), database.EventFilter{Limit: database.MaxEventLimit})
+		if err != nil {
+			return nil, err
+		}
+		if len(events) >= count {
+			// Give stragglers a moment so unexpected extra events are caught too
+			time.Sleep(200 * time.Millisecond)
+			return store.FindEvents(context.Background(), database.EventFilter{Limit: database.MaxEventLimit})
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out with %d of %d events stored: %s", len(events), count, describe(events))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// compare matches stored events against expectations regardless of arrival order
+func compare(t *testing.T, expected []expectation, stored []models.EventData) error {
+	if len(stored) != len(expected) {
+		return fmt.Errorf("stored %d events, expected %d: %s", len(stored), len(expected), describe(stored))
+	}
+
+	remaining := append([]models.EventData(nil), stored...)
+	var problems []string
+	for _, want := range expected {
+		index := -1
+		for i, got := range remaining {
+			if got.EventName == want.EventName && got.TransactionHash == want.TransactionHash.Hex() &&
+				got.ContractAddress == want.ContractAddress.Hex() {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			problems = append(problems, fmt.Sprintf("missing %s from %s in tx %s", want.EventName, want.ContractAddress.Hex(), want.TransactionHash.Hex()))
+			continue
+		}
+		got := remaining[index]
+		remaining = append(remaining[:index], remaining[index+1:]...)
+
+		checks := []struct {
+			field     string
+			got, want string
+		}{
+			{"ChainId", got.ChainID, chainID},
+			{"caller_address", got.CallerAddress, want.CallerAddress.Hex()},
+			{"to_from_user", got.ToFromUser, want.ToFromUser},
+			{"amount", got.Amount, want.Amount},
+			{"message_id", got.MessageID, want.MessageID},
+			{"source_chain_selector", fmt.Sprint(got.SourceChainSelector), fmt.Sprint(want.SourceSelector)},
+			{"sender", got.Sender, want.Sender},
+			{"client", got.Client, want.Client},
+			{"text", got.Text, want.Text},
+		}
+		for _, check := range checks {
+			if check.got != check.want {
+				problems = append(problems, fmt.Sprintf("%s in tx %s: %s = %q, want %q", want.EventName, want.TransactionHash.Hex(), check.field, check.got, check.want))
+			}
+		}
+		if got.BlockNumber == 0 {
+			problems = append(problems, fmt.Sprintf("%s in tx %s has no block number", want.EventName, want.TransactionHash.Hex()))
+		}
+		t.Logf("ok   %-16s %s", want.EventName, want.TransactionHash.Hex())
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%d mismatches:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func describe(events []models.EventData) string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, event.EventName+"@"+event.TransactionHash)
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
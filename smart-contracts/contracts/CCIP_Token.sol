@@ -112,6 +112,20 @@ contract CCIP_Token is ERC20, Ownable, Pausable {
         emit TokenSupplyIncreased(amount);
     }
 
+    /**
+     * @dev Pauses transfers. Only the owner can pause.
+     */
+    function pause() public onlyOwner {
+        _pause();
+    }
+
+    /**
+     * @dev Resumes transfers. Only the owner can unpause.
+     */
+    function unpause() public onlyOwner {
+        _unpause();
+    }
+
     /**
      * @dev Transfers tokens to a specified address.
      * @param recipient The address to transfer to.